@@ -0,0 +1,17 @@
+// Package openapi embeds the OpenAPI 3.1 description of the HTTP API and the
+// page that renders it.
+package openapi
+
+import _ "embed"
+
+// Spec is the OpenAPI document. It is maintained by hand: every route added
+// to the router must be described here, which the request validation
+// middleware and its tests rely on.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// DocsPage is an HTML page that renders Spec with Redoc.
+//
+//go:embed docs.html
+var DocsPage []byte
@@ -1,15 +1,28 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
 	"net/http"
 
+	"users-api/src/api/openapi"
+	"users-api/src/internal/app"
 	"users-api/src/internal/config"
 	"users-api/src/internal/db"
+	graphqlDelivery "users-api/src/internal/delivery/graphql"
+	grpcDelivery "users-api/src/internal/delivery/grpc"
 	"users-api/src/internal/delivery/handlers"
 	httpDelivery "users-api/src/internal/delivery/http"
+	"users-api/src/internal/delivery/middleware"
+	"users-api/src/internal/delivery/scim"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/importer"
+	"users-api/src/internal/mailer"
+	"users-api/src/internal/outbox"
 	"users-api/src/internal/repository/postgres"
 	"users-api/src/internal/service"
+	"users-api/src/internal/webhook"
 )
 
 func main() {
@@ -24,16 +37,195 @@ func main() {
 	}
 	defer database.Close()
 
-	userRepo := postgres.NewUserRepository(database.DB)
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create mailer: %v", err)
+	}
+
+	verificationRepo := postgres.NewEmailVerificationRepository(database.DB)
+	mfaRepo := postgres.NewMFARepository(database.DB)
+	lockoutRepo := postgres.NewLockoutRepository(database.DB)
+	idempotencyRepo := postgres.NewIdempotencyRepository(database.DB)
+	webhookRepo := postgres.NewWebhookSubscriptionRepository(database.DB)
+	deliveryRepo := postgres.NewWebhookDeliveryRepository(database.DB)
+	outboxRepo := postgres.NewUserEventOutboxRepository(database.DB)
+	attributeRepo := postgres.NewAttributeDefinitionRepository(database.DB)
+	tenantRepo := postgres.NewTenantRepository(database.DB)
+
+	var publisher outbox.Publisher
+	if cfg.OutboxPublisher != "" {
+		publisher, err = outbox.NewPublisher(cfg)
+		if err != nil {
+			log.Fatalf("Failed to create outbox publisher: %v", err)
+		}
+		defer publisher.Close()
+	}
+
+	attributeService := service.NewAttributeService(attributeRepo)
+	tenantService := service.NewTenantService(tenantRepo)
+
+	users, err := app.NewUsers(cfg, database.DB, mail, attributeService)
+	if err != nil {
+		log.Fatalf("Failed to configure email validation: %v", err)
+	}
+
+	lockoutService := service.NewLockoutService(lockoutRepo, service.LockoutPolicy{
+		UserThreshold: cfg.LockoutUserThreshold,
+		IPThreshold:   cfg.LockoutIPThreshold,
+		BaseDelay:     cfg.LockoutBaseDelay,
+		MaxDelay:      cfg.LockoutMaxDelay,
+		Window:        cfg.LockoutWindow,
+	})
+	webhookService := service.NewWebhookService(webhookRepo, deliveryRepo)
+
+	docsHandler := handlers.NewDocsHandler(openapi.Spec, openapi.DocsPage)
+	lockoutHandler := handlers.NewLockoutHandler(lockoutService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	attributeHandler := handlers.NewAttributeHandler(attributeService)
+
+	// Webhook subscriptions, attribute definitions and lockouts are shared by
+	// the deployment; users and their events belong to a tenant.
+	var verifier *service.EmailVerifier
+	tenants := newTenants(cfg.TenantIdleTimeout, func(tenantID int64) (*tenant, error) {
+		conn := database.DB
+		var closeConn func() error
+		if cfg.DBRowLevelSecurity {
+			tenantDB, err := db.NewTenantDB(cfg, tenantID)
+			if err != nil {
+				return nil, err
+			}
+			conn = tenantDB.DB
+			closeConn = tenantDB.Close
+		}
+
+		userRepo := postgres.NewUserRepository(conn).ForTenant(tenantID)
+		userService := users.Service(conn, tenantID)
+		groupService := service.NewGroupService(postgres.NewGroupRepository(conn).ForTenant(tenantID), userRepo)
+
+		graphqlHandler, err := graphqlDelivery.NewHandler(userService, graphqlDelivery.Limits{
+			MaxDepth:      cfg.GraphQLMaxDepth,
+			MaxComplexity: cfg.GraphQLMaxComplexity,
+		})
+		if err != nil {
+			if closeConn != nil {
+				closeConn()
+			}
+			return nil, err
+		}
+
+		return &tenant{
+			users:        userRepo,
+			userService:  userService,
+			groupService: groupService,
+			handler: httpDelivery.NewRouter(
+				handlers.NewUserHandler(tenantUserService{userService, verifier}),
+				handlers.NewMFAHandler(service.NewMFAService(userRepo, mfaRepo, lockoutService, cfg.MFAIssuer)),
+				lockoutHandler,
+				handlers.NewImportHandler(importer.New(userService)),
+				handlers.NewEventHandler(userService),
+				webhookHandler,
+				attributeHandler,
+				handlers.NewGroupHandler(groupService),
+				docsHandler,
+				graphqlHandler,
+				scim.NewHandler(userService),
+			),
+			close: closeConn,
+		}, nil
+	})
 
-	userService := service.NewUserService(userRepo)
+	verifier = service.NewEmailVerifier(verificationRepo, func(tenantID int64) (*service.UserService, func(), error) {
+		t, release, err := tenants.acquire(tenantID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return t.userService, release, nil
+	})
 
-	userHandler := handlers.NewUserHandler(userService)
+	// The default tenant is never dropped, so it is never released.
+	defaultTenant, _, err := tenants.acquire(domain.DefaultTenantID)
+	if err != nil {
+		log.Fatalf("Failed to set up the default tenant: %v", err)
+	}
+
+	router := httpDelivery.NewTenantRouter(func(tenantID int64) (http.Handler, func(), error) {
+		t, release, err := tenants.acquire(tenantID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return t.handler, release, nil
+	})
+
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimitDefault, cfg.RateLimitRoutes)
+	ipRateLimiter := middleware.NewIPRateLimiter(cfg.RateLimitIP)
+	idempotency := middleware.NewIdempotency(idempotencyRepo, cfg.IdempotencyKeyTTL, "POST /users", "POST /users:batch")
+	validator, err := middleware.NewOpenAPIValidator(openapi.Spec, cfg.OpenAPIValidateResponses)
+	if err != nil {
+		log.Fatalf("Failed to load OpenAPI document: %v", err)
+	}
 
-	router := httpDelivery.NewRouter(userHandler)
+	authenticate := middleware.Tenant(tenantService, cfg.TenantRequireAPIKey, "/openapi.json", "/docs", "/users/verify-email")
+	operatorOnly := middleware.DefaultTenantOnly(
+		"/webhooks",
+		"/webhooks/deliveries",
+		"/webhooks/deliveries/retry",
+		"POST /users/attributes",
+		"PUT /users/attributes",
+		"DELETE /users/attributes",
+		"/users/unlock",
+	)
+	keyRequired := middleware.KeyRequired(
+		"/users/mfa/enroll",
+		"/users/mfa/confirm",
+		"/users/mfa/verify",
+		"/users/mfa/disable",
+		"/users/mfa/recovery-codes",
+	)
+
+	handler := middleware.ClientIP(trustedProxies)(ipRateLimiter.Handler(authenticate(rateLimiter.Handler(operatorOnly(keyRequired(validator.Handler(idempotency.Handler(router))))))))
+
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		log.Fatalf("Failed to listen on gRPC port: %v", err)
+	}
+	grpcServer := grpcDelivery.NewServer(defaultTenant.userService, grpcDelivery.TenantInterceptors(tenantService, cfg.TenantRequireAPIKey, func(tenantID int64) (grpcDelivery.UserService, func(), error) {
+		t, release, err := tenants.acquire(tenantID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return t.userService, release, nil
+	})...)
+	go func() {
+		log.Printf("gRPC server starting on :%s", cfg.GRPCPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	dispatcher := webhook.NewDispatcher(deliveryRepo, webhook.Config{
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		BaseDelay:    cfg.WebhookBaseDelay,
+		MaxDelay:     cfg.WebhookMaxDelay,
+		Timeout:      cfg.WebhookTimeout,
+		PollInterval: cfg.WebhookPollInterval,
+	})
+	go dispatcher.Run(context.Background())
+
+	if publisher != nil {
+		relay := outbox.NewRelay(outboxRepo, publisher, outbox.Config{
+			BatchSize:    cfg.OutboxBatchSize,
+			PollInterval: cfg.OutboxPollInterval,
+			RetryDelay:   cfg.OutboxRetryDelay,
+		})
+		go relay.Run(context.Background())
+	}
 
 	log.Println("Server starting on :8080")
-	if err := http.ListenAndServe(":8080", router); err != nil {
+	if err := http.ListenAndServe(":8080", handler); err != nil {
 		log.Fatal(err)
 	}
 }
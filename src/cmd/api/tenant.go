@@ -0,0 +1,111 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/repository/postgres"
+	"users-api/src/internal/service"
+)
+
+// tenant holds what the REST and gRPC APIs serve a tenant with. Both share
+// one UserService per tenant so that event subscribers see every change.
+type tenant struct {
+	users        *postgres.UserRepository
+	userService  *service.UserService
+	groupService *service.GroupService
+	handler      http.Handler
+	close        func() error
+
+	active   int
+	lastUsed time.Time
+}
+
+// tenants builds tenants on first use. Tenants other than the default one
+// are dropped, and their pools closed, once nothing has used them for
+// idleTimeout, so a deployment with many tenants does not keep a pool open
+// for each tenant that was ever served. A tenant is kept while acquired.
+type tenants struct {
+	build       func(tenantID int64) (*tenant, error)
+	idleTimeout time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	byID      map[int64]*tenant
+	lastSweep time.Time
+}
+
+func newTenants(idleTimeout time.Duration, build func(tenantID int64) (*tenant, error)) *tenants {
+	return &tenants{
+		build:       build,
+		idleTimeout: idleTimeout,
+		now:         time.Now,
+		byID:        make(map[int64]*tenant),
+	}
+}
+
+// acquire returns the tenant and a func that releases it. The tenant is not
+// dropped until every acquirer has released it.
+func (t *tenants) acquire(tenantID int64) (*tenant, func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+
+	tn, ok := t.byID[tenantID]
+	if !ok {
+		var err error
+		if tn, err = t.build(tenantID); err != nil {
+			return nil, nil, err
+		}
+		t.byID[tenantID] = tn
+	}
+	tn.active++
+	tn.lastUsed = now
+
+	var once sync.Once
+	return tn, func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			tn.active--
+			tn.lastUsed = t.now()
+		})
+	}, nil
+}
+
+func (t *tenants) sweep(now time.Time) {
+	if t.idleTimeout <= 0 || now.Sub(t.lastSweep) < t.idleTimeout {
+		return
+	}
+	t.lastSweep = now
+
+	for id, tn := range t.byID {
+		if id == domain.DefaultTenantID || tn.active > 0 || now.Sub(tn.lastUsed) < t.idleTimeout {
+			continue
+		}
+		delete(t.byID, id)
+		if tn.close != nil {
+			// Closing waits for queries in flight, and there are none.
+			if err := tn.close(); err != nil {
+				log.Printf("Failed to close the pool of tenant %d: %v", id, err)
+			}
+		}
+	}
+}
+
+// tenantUserService is the UserService of a tenant, except that email
+// verification is handed to the tenant the token was issued in: the link in
+// the email carries no API key, so the request acts as the default tenant.
+type tenantUserService struct {
+	*service.UserService
+	verifier *service.EmailVerifier
+}
+
+func (s tenantUserService) VerifyEmail(token string) (*domain.User, error) {
+	return s.verifier.VerifyEmail(token)
+}
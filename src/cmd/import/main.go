@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"users-api/src/internal/app"
+	"users-api/src/internal/config"
+	"users-api/src/internal/db"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/importer"
+	"users-api/src/internal/mailer"
+	"users-api/src/internal/repository/postgres"
+	"users-api/src/internal/service"
+)
+
+const checkpointEvery = 100
+
+func main() {
+	file := flag.String("file", "", "path to the CSV or NDJSON file, - for stdin")
+	format := flag.String("format", "", "input format: csv or ndjson (default: by file extension)")
+	dryRun := flag.Bool("dry-run", false, "validate rows and report what would change without writing")
+	upsert := flag.Bool("upsert", false, "update existing users matched by email instead of failing")
+	mapping := flag.String("map", "", "column mapping, e.g. name=full_name,email=mail")
+	checkpoint := flag.String("checkpoint", "", "file to store progress in (default: <file>.progress)")
+	resume := flag.Bool("resume", false, "skip rows recorded in the checkpoint file")
+	tenant := flag.Int64("tenant", domain.DefaultTenantID, "ID of the tenant to import the users into")
+	flag.Parse()
+
+	if *file == "" {
+		log.Fatal("-file is required")
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*file), ".")
+	}
+	if *checkpoint == "" && *file != "-" {
+		*checkpoint = *file + ".progress"
+	}
+
+	opts := importer.Options{
+		Format: *format,
+		DryRun: *dryRun,
+		Upsert: *upsert,
+	}
+
+	if *mapping != "" {
+		m, err := importer.ParseMapping(*mapping)
+		if err != nil {
+			log.Fatalf("Invalid mapping: %v", err)
+		}
+		opts.Mapping = m
+	}
+
+	if *resume && *checkpoint != "" {
+		skip, err := readCheckpoint(*checkpoint)
+		if err != nil {
+			log.Fatalf("Failed to read checkpoint: %v", err)
+		}
+		opts.Skip = skip
+		log.Printf("Resuming after row %d", skip)
+	}
+
+	var input io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatalf("Failed to open input: %v", err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	database, err := db.NewDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create mailer: %v", err)
+	}
+
+	users, err := app.NewUsers(cfg, database.DB, mail, service.NewAttributeService(postgres.NewAttributeDefinitionRepository(database.DB)))
+	if err != nil {
+		log.Fatalf("Failed to configure email validation: %v", err)
+	}
+
+	conn := database.DB
+	if cfg.DBRowLevelSecurity {
+		tenantDB, err := db.NewTenantDB(cfg, *tenant)
+		if err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+		defer tenantDB.Close()
+		conn = tenantDB.DB
+	}
+	userService := users.Service(conn, *tenant)
+
+	saveProgress := !*dryRun && *checkpoint != ""
+	encoder := json.NewEncoder(os.Stdout)
+
+	summary, err := importer.New(userService).Run(input, opts, func(row importer.RowResult) error {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+		if saveProgress && row.Row%checkpointEvery == 0 {
+			return writeCheckpoint(*checkpoint, row.Row)
+		}
+		return nil
+	})
+
+	if summary != nil && saveProgress {
+		if cpErr := writeCheckpoint(*checkpoint, summary.LastRow); cpErr != nil {
+			log.Printf("Failed to write checkpoint: %v", cpErr)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Import stopped: %v", err)
+	}
+
+	log.Printf(
+		"Import finished: rows=%d skipped=%d created=%d updated=%d unchanged=%d would_create=%d would_update=%d failed=%d",
+		summary.Rows,
+		summary.Skipped,
+		summary.Created,
+		summary.Updated,
+		summary.Unchanged,
+		summary.WouldCreate,
+		summary.WouldUpdate,
+		summary.Failed,
+	)
+}
+
+func readCheckpoint(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+func writeCheckpoint(path string, row int) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(row)+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"users-api/src/internal/config"
+	"users-api/src/internal/db"
+	"users-api/src/internal/repository/postgres"
+	"users-api/src/internal/service"
+)
+
+func main() {
+	name := flag.String("name", "", "create a tenant with this name")
+	id := flag.Int64("id", 0, "issue another API key for an existing tenant")
+	flag.Parse()
+
+	if (*name == "") == (*id == 0) {
+		log.Fatal("exactly one of -name and -id is required")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	database, err := db.NewDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	tenantService := service.NewTenantService(postgres.NewTenantRepository(database.DB))
+
+	// The key is shown once; only its hash is stored.
+	if *name != "" {
+		tenant, key, err := tenantService.CreateTenant(*name)
+		if err != nil {
+			log.Fatalf("Failed to create tenant: %v", err)
+		}
+		fmt.Printf("tenant_id=%d\napi_key=%s\n", tenant.ID, key)
+		return
+	}
+
+	key, err := tenantService.IssueAPIKey(*id)
+	if err != nil {
+		log.Fatalf("Failed to issue API key: %v", err)
+	}
+	fmt.Printf("tenant_id=%d\napi_key=%s\n", *id, key)
+}
@@ -0,0 +1,69 @@
+// Package app builds the services the API and the command-line tools share,
+// so that every entry point applies the same rules to users.
+package app
+
+import (
+	"database/sql"
+
+	"users-api/src/internal/config"
+	"users-api/src/internal/emailaddr"
+	"users-api/src/internal/mailer"
+	"users-api/src/internal/repository/postgres"
+	"users-api/src/internal/service"
+)
+
+// Users builds the UserService of each tenant from the configuration:
+// email verification, canonicalization and validators, the attribute
+// schema, transactions, the event log with its webhook queue and, when a
+// publisher is configured, the outbox.
+type Users struct {
+	cfg        *config.Config
+	db         *sql.DB
+	mail       mailer.Mailer
+	validators []emailaddr.Validator
+	attributes *service.AttributeService
+}
+
+// NewUsers uses db for the tables shared by the deployment.
+func NewUsers(cfg *config.Config, db *sql.DB, mail mailer.Mailer, attributes *service.AttributeService) (*Users, error) {
+	validators, err := emailaddr.NewValidators(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Users{
+		cfg:        cfg,
+		db:         db,
+		mail:       mail,
+		validators: validators,
+		attributes: attributes,
+	}, nil
+}
+
+// Service returns a UserService for the users of tenantID. conn is the
+// shared pool, or the tenant's own one with row-level security.
+func (u *Users) Service(conn *sql.DB, tenantID int64) *service.UserService {
+	opts := []service.Option{
+		service.WithEmailVerification(postgres.NewEmailVerificationRepository(u.db), u.mail, service.EmailVerificationConfig{
+			BaseURL: u.cfg.AppBaseURL,
+			From:    u.cfg.MailFrom,
+			TTL:     u.cfg.EmailVerificationTTL,
+		}),
+		service.WithTxManager(postgres.NewTxManager(conn).ForTenant(tenantID)),
+		service.WithEmailValidators(u.validators...),
+		service.WithAttributeSchema(u.attributes),
+		service.WithEventLog(postgres.NewUserEventRepository(conn).ForTenant(tenantID)),
+		service.WithWebhookQueue(postgres.NewWebhookDeliveryRepository(u.db)),
+		service.WithBatchConfig(service.BatchConfig{
+			MaxSize:       u.cfg.BatchMaxSize,
+			CopyThreshold: u.cfg.BatchCopyThreshold,
+		}),
+	}
+	// Events staged here are published by the relay of the API.
+	if u.cfg.OutboxPublisher != "" {
+		opts = append(opts, service.WithOutbox(postgres.NewUserEventOutboxRepository(u.db)))
+	}
+	if u.cfg.EmailCanonicalizeGmail {
+		opts = append(opts, service.WithGmailCanonicalization())
+	}
+	return service.NewUserService(postgres.NewUserRepository(conn).ForTenant(tenantID), opts...)
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,6 +17,74 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	DBSSLMode  string
+
+	DBRowLevelSecurity      bool
+	DBTenantMaxOpenConns    int
+	DBTenantConnMaxIdleTime time.Duration
+
+	TenantRequireAPIKey bool
+	TenantIdleTimeout   time.Duration
+
+	AppBaseURL           string
+	MailerType           string
+	MailerFileDir        string
+	MailFrom             string
+	EmailVerificationTTL time.Duration
+
+	EmailCanonicalizeGmail     bool
+	EmailAllowedDomains        []string
+	EmailDeniedDomains         []string
+	EmailDisposableDomainsFile string
+	EmailCheckMX               bool
+	EmailMXTimeout             time.Duration
+
+	MFAIssuer string
+
+	LockoutUserThreshold int
+	LockoutIPThreshold   int
+	LockoutBaseDelay     time.Duration
+	LockoutMaxDelay      time.Duration
+	LockoutWindow        time.Duration
+
+	RateLimitDefault RateLimit
+	RateLimitRoutes  map[string]RateLimit
+	RateLimitIP      RateLimit
+	TrustedProxies   []string
+
+	IdempotencyKeyTTL time.Duration
+
+	BatchMaxSize       int
+	BatchCopyThreshold int
+
+	GRPCPort string
+
+	GraphQLMaxDepth      int
+	GraphQLMaxComplexity int
+
+	WebhookMaxAttempts  int
+	WebhookBaseDelay    time.Duration
+	WebhookMaxDelay     time.Duration
+	WebhookTimeout      time.Duration
+	WebhookPollInterval time.Duration
+
+	OutboxPublisher    string
+	OutboxFilePath     string
+	OutboxBatchSize    int
+	OutboxPollInterval time.Duration
+	OutboxRetryDelay   time.Duration
+	NATSURL            string
+	NATSSubjectPrefix  string
+	KafkaBrokers       []string
+	KafkaTopic         string
+
+	OpenAPIValidateResponses bool
+}
+
+// RateLimit is a token bucket refilled at Rate tokens per second and holding
+// at most Burst tokens. A zero limit disables rate limiting.
+type RateLimit struct {
+	Rate  float64
+	Burst int
 }
 
 func LoadConfig() (*Config, error) {
@@ -23,6 +94,142 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	emailVerificationTTL, err := getDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	emailCanonicalizeGmail, err := getBool("EMAIL_CANONICALIZE_GMAIL", false)
+	if err != nil {
+		return nil, err
+	}
+	emailCheckMX, err := getBool("EMAIL_CHECK_MX", false)
+	if err != nil {
+		return nil, err
+	}
+	emailMXTimeout, err := getDuration("EMAIL_MX_TIMEOUT", 3*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutUserThreshold, err := getInt("LOCKOUT_USER_THRESHOLD", 5)
+	if err != nil {
+		return nil, err
+	}
+	lockoutIPThreshold, err := getInt("LOCKOUT_IP_THRESHOLD", 20)
+	if err != nil {
+		return nil, err
+	}
+	lockoutBaseDelay, err := getDuration("LOCKOUT_BASE_DELAY", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	lockoutMaxDelay, err := getDuration("LOCKOUT_MAX_DELAY", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	lockoutWindow, err := getDuration("LOCKOUT_WINDOW", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitDefault, err := parseRateLimit(getEnv("RATE_LIMIT_DEFAULT", "10:20"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_DEFAULT: %w", err)
+	}
+	rateLimitRoutes, err := parseRateLimitRoutes(getEnv("RATE_LIMIT_ROUTES", "POST /users=1:5"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTES: %w", err)
+	}
+	rateLimitIP, err := parseRateLimit(getEnv("RATE_LIMIT_IP", "50:100"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_IP: %w", err)
+	}
+
+	idempotencyKeyTTL, err := getDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	batchMaxSize, err := getInt("BATCH_MAX_SIZE", 10000)
+	if err != nil {
+		return nil, err
+	}
+	batchCopyThreshold, err := getInt("BATCH_COPY_THRESHOLD", 500)
+	if err != nil {
+		return nil, err
+	}
+
+	graphQLMaxDepth, err := getInt("GRAPHQL_MAX_DEPTH", 10)
+	if err != nil {
+		return nil, err
+	}
+	graphQLMaxComplexity, err := getInt("GRAPHQL_MAX_COMPLEXITY", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookMaxAttempts, err := getInt("WEBHOOK_MAX_ATTEMPTS", 10)
+	if err != nil {
+		return nil, err
+	}
+	webhookBaseDelay, err := getDuration("WEBHOOK_BASE_DELAY", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	webhookMaxDelay, err := getDuration("WEBHOOK_MAX_DELAY", 6*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	webhookTimeout, err := getDuration("WEBHOOK_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	webhookPollInterval, err := getDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	outboxBatchSize, err := getInt("OUTBOX_BATCH_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+	outboxPollInterval, err := getDuration("OUTBOX_POLL_INTERVAL", time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	outboxRetryDelay, err := getDuration("OUTBOX_RETRY_DELAY", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	openAPIValidateResponses, err := getBool("OPENAPI_VALIDATE_RESPONSES", false)
+	if err != nil {
+		return nil, err
+	}
+
+	dbRowLevelSecurity, err := getBool("DB_ROW_LEVEL_SECURITY", false)
+	if err != nil {
+		return nil, err
+	}
+	dbTenantMaxOpenConns, err := getInt("DB_TENANT_MAX_OPEN_CONNS", 5)
+	if err != nil {
+		return nil, err
+	}
+	dbTenantConnMaxIdleTime, err := getDuration("DB_TENANT_CONN_MAX_IDLE_TIME", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	tenantRequireAPIKey, err := getBool("TENANT_REQUIRE_API_KEY", false)
+	if err != nil {
+		return nil, err
+	}
+	tenantIdleTimeout, err := getDuration("TENANT_IDLE_TIMEOUT", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
@@ -30,6 +237,67 @@ func LoadConfig() (*Config, error) {
 		DBPassword: getEnv("DB_PASSWORD", "postgres"),
 		DBName:     getEnv("DB_NAME", "users_db"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
+
+		DBRowLevelSecurity:      dbRowLevelSecurity,
+		DBTenantMaxOpenConns:    dbTenantMaxOpenConns,
+		DBTenantConnMaxIdleTime: dbTenantConnMaxIdleTime,
+
+		TenantRequireAPIKey: tenantRequireAPIKey,
+		TenantIdleTimeout:   tenantIdleTimeout,
+
+		AppBaseURL:           getEnv("APP_BASE_URL", "http://localhost:8080"),
+		MailerType:           getEnv("MAILER", "log"),
+		MailerFileDir:        getEnv("MAILER_FILE_DIR", "mail"),
+		MailFrom:             getEnv("MAIL_FROM", "no-reply@users-api.local"),
+		EmailVerificationTTL: emailVerificationTTL,
+
+		EmailCanonicalizeGmail:     emailCanonicalizeGmail,
+		EmailAllowedDomains:        splitList(getEnv("EMAIL_ALLOWED_DOMAINS", "")),
+		EmailDeniedDomains:         splitList(getEnv("EMAIL_DENIED_DOMAINS", "")),
+		EmailDisposableDomainsFile: getEnv("EMAIL_DISPOSABLE_DOMAINS_FILE", ""),
+		EmailCheckMX:               emailCheckMX,
+		EmailMXTimeout:             emailMXTimeout,
+
+		MFAIssuer: getEnv("MFA_ISSUER", "users-api"),
+
+		LockoutUserThreshold: lockoutUserThreshold,
+		LockoutIPThreshold:   lockoutIPThreshold,
+		LockoutBaseDelay:     lockoutBaseDelay,
+		LockoutMaxDelay:      lockoutMaxDelay,
+		LockoutWindow:        lockoutWindow,
+
+		RateLimitDefault: rateLimitDefault,
+		RateLimitRoutes:  rateLimitRoutes,
+		RateLimitIP:      rateLimitIP,
+		TrustedProxies:   splitList(getEnv("TRUSTED_PROXIES", "")),
+
+		IdempotencyKeyTTL: idempotencyKeyTTL,
+
+		BatchMaxSize:       batchMaxSize,
+		BatchCopyThreshold: batchCopyThreshold,
+
+		GRPCPort: getEnv("GRPC_PORT", "9090"),
+
+		GraphQLMaxDepth:      graphQLMaxDepth,
+		GraphQLMaxComplexity: graphQLMaxComplexity,
+
+		WebhookMaxAttempts:  webhookMaxAttempts,
+		WebhookBaseDelay:    webhookBaseDelay,
+		WebhookMaxDelay:     webhookMaxDelay,
+		WebhookTimeout:      webhookTimeout,
+		WebhookPollInterval: webhookPollInterval,
+
+		OutboxPublisher:    getEnv("OUTBOX_PUBLISHER", ""),
+		OutboxFilePath:     getEnv("OUTBOX_FILE_PATH", "user-events.ndjson"),
+		OutboxBatchSize:    outboxBatchSize,
+		OutboxPollInterval: outboxPollInterval,
+		OutboxRetryDelay:   outboxRetryDelay,
+		NATSURL:            getEnv("NATS_URL", "nats://localhost:4222"),
+		NATSSubjectPrefix:  getEnv("NATS_SUBJECT_PREFIX", "users.events"),
+		KafkaBrokers:       splitList(getEnv("KAFKA_BROKERS", "localhost:9092")),
+		KafkaTopic:         getEnv("KAFKA_TOPIC", "user-events"),
+
+		OpenAPIValidateResponses: openAPIValidateResponses,
 	}, nil
 }
 
@@ -51,3 +319,87 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration in %s: %w", key, err)
+	}
+	return d, nil
+}
+
+func getBool(key string, defaultValue bool) (bool, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean in %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func getInt(key string, defaultValue int) (int, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer in %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// parseRateLimit parses "<rate>:<burst>", where rate is in requests per second.
+func parseRateLimit(value string) (RateLimit, error) {
+	rate, burst, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("expected <rate>:<burst>, got %q", value)
+	}
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	b, err := strconv.Atoi(burst)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	return RateLimit{Rate: r, Burst: b}, nil
+}
+
+// parseRateLimitRoutes parses "POST /users=1:5;/users/mfa/verify=0.5:3".
+func parseRateLimitRoutes(value string) (map[string]RateLimit, error) {
+	routes := make(map[string]RateLimit)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected <route>=<rate>:<burst>, got %q", entry)
+		}
+		l, err := parseRateLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.TrimSpace(route)] = l
+	}
+	return routes, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
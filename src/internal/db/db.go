@@ -3,6 +3,7 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"users-api/src/internal/config"
 
 	_ "github.com/lib/pq"
@@ -32,3 +33,27 @@ func NewDB(cfg *config.Config) (*DB, error) {
 
 	return database, nil
 }
+
+// NewTenantDB opens a pool whose sessions set app.tenant_id, so the row
+// level security policies limit them to the tenant's rows. Migrations are
+// left to the pool opened by NewDB. Every tenant gets its own pool, so each
+// is capped at DB_TENANT_MAX_OPEN_CONNS and keeps at most one idle
+// connection, closed after DB_TENANT_CONN_MAX_IDLE_TIME: tenants that are
+// not in use hold no connections.
+func NewTenantDB(cfg *config.Config, tenantID int64) (*DB, error) {
+	dsn := fmt.Sprintf("%s&options=%s", cfg.GetDBURL(), url.QueryEscape(fmt.Sprintf("-c app.tenant_id=%d", tenantID)))
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+	db.SetMaxOpenConns(cfg.DBTenantMaxOpenConns)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxIdleTime(cfg.DBTenantConnMaxIdleTime)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to the database: %w", err)
+	}
+
+	return &DB{DB: db}, nil
+}
@@ -0,0 +1,152 @@
+package codec
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes and decodes one media type. Every format uses the json
+// struct tags, except XML, which has its own.
+type Codec interface {
+	ContentType() string
+	Encode(w io.Writer, v interface{}) error
+	Decode(r io.Reader, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string { return "application/xml" }
+
+func (xmlCodec) Encode(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func (xmlCodec) Decode(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// msgpackCodec reuses the json struct tags so that field names are identical
+// across all formats.
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string { return "application/msgpack" }
+
+func (msgpackCodec) Encode(w io.Writer, v interface{}) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+func (msgpackCodec) Decode(r io.Reader, v interface{}) error {
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+type cborCodec struct{}
+
+func (cborCodec) ContentType() string { return "application/cbor" }
+
+func (cborCodec) Encode(w io.Writer, v interface{}) error {
+	return cbor.NewEncoder(w).Encode(v)
+}
+
+func (cborCodec) Decode(r io.Reader, v interface{}) error {
+	return cbor.NewDecoder(r).Decode(v)
+}
+
+var defaultCodec Codec = jsonCodec{}
+
+// codecs maps every accepted media type, including common aliases, to its codec.
+var codecs = map[string]Codec{
+	"application/json":      jsonCodec{},
+	"application/xml":       xmlCodec{},
+	"text/xml":              xmlCodec{},
+	"application/msgpack":   msgpackCodec{},
+	"application/x-msgpack": msgpackCodec{},
+	"application/cbor":      cborCodec{},
+}
+
+// ForResponse picks the codec for the response from the Accept header,
+// honoring q-values. A missing header or a wildcard selects JSON; ok is false
+// when nothing the client accepts can be produced.
+func ForResponse(r *http.Request) (c Codec, ok bool) {
+	header := r.Header.Get("Accept")
+	if strings.TrimSpace(header) == "" {
+		return defaultCodec, true
+	}
+
+	type candidate struct {
+		mediaType string
+		q         float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{mediaType, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, cand := range candidates {
+		switch {
+		case cand.mediaType == "*/*", cand.mediaType == "application/*":
+			return defaultCodec, true
+		case cand.mediaType == "text/*":
+			return codecs["text/xml"], true
+		}
+		if c, ok := codecs[cand.mediaType]; ok {
+			return c, true
+		}
+	}
+	return defaultCodec, false
+}
+
+// ForRequest picks the codec for the request body from Content-Type.
+// Bodies without one are treated as JSON.
+func ForRequest(r *http.Request) (Codec, bool) {
+	header := r.Header.Get("Content-Type")
+	if header == "" {
+		return defaultCodec, true
+	}
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return nil, false
+	}
+	c, ok := codecs[mediaType]
+	return c, ok
+}
@@ -0,0 +1,37 @@
+package codec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForResponse(t *testing.T) {
+	tests := []struct {
+		accept      string
+		contentType string
+		ok          bool
+	}{
+		{"", "application/json", true},
+		{"*/*", "application/json", true},
+		{"application/xml", "application/xml", true},
+		{"text/html, application/msgpack;q=0.9", "application/msgpack", true},
+		{"application/json;q=0.5, application/cbor", "application/cbor", true},
+		{"application/x-msgpack, application/json;q=0", "application/msgpack", true},
+		{"text/html", "application/json", false},
+		{"application/json;q=0", "application/json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users?id=1", nil)
+			req.Header.Set("Accept", tt.accept)
+
+			c, ok := ForResponse(req)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.contentType, c.ContentType())
+		})
+	}
+}
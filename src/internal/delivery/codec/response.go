@@ -0,0 +1,33 @@
+package codec
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+type ErrorResponse struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+	Error   string   `json:"error" xml:"error"`
+	Code    int      `json:"code" xml:"code"`
+	Message string   `json:"message" xml:"message"`
+}
+
+// WriteResponse encodes data in the format negotiated from the request's
+// Accept header, falling back to JSON.
+func WriteResponse(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	c, _ := ForResponse(r)
+	w.Header().Set("Content-Type", c.ContentType())
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	c.Encode(w, data)
+}
+
+// WriteError writes the error body every endpoint and middleware responds
+// with, in the negotiated format.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, err, message string) {
+	WriteResponse(w, r, status, ErrorResponse{
+		Error:   err,
+		Code:    status,
+		Message: message,
+	})
+}
@@ -0,0 +1,42 @@
+package graphql
+
+import (
+	stdErrors "errors"
+
+	"users-api/src/internal/errors"
+)
+
+// resolverError carries a machine-readable code in the "extensions" member
+// of the GraphQL error, mirroring the statuses used by the REST handlers.
+type resolverError struct {
+	message string
+	code    string
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+func (e *resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+func resolveError(err error) error {
+	if stdErrors.Is(err, errors.ErrInvalidAttribute) {
+		return &resolverError{message: err.Error(), code: "BAD_USER_INPUT"}
+	}
+	switch err {
+	case errors.ErrUserNotFound:
+		return &resolverError{message: err.Error(), code: "NOT_FOUND"}
+	case errors.ErrInvalidInput, errors.ErrInvalidEmail, errors.ErrEmailRejected:
+		return &resolverError{message: err.Error(), code: "BAD_USER_INPUT"}
+	case errors.ErrEmailTaken, errors.ErrInvalidStatusTransition:
+		return &resolverError{message: err.Error(), code: "CONFLICT"}
+	default:
+		return &resolverError{message: "internal error", code: "INTERNAL_SERVER_ERROR"}
+	}
+}
+
+func badInput(message string) error {
+	return &resolverError{message: message, code: "BAD_USER_INPUT"}
+}
@@ -0,0 +1,120 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+const maxRequestBytes = 1 << 20
+
+type Handler struct {
+	schema      graphql.Schema
+	userService UserService
+	limits      Limits
+}
+
+func NewHandler(userService UserService, limits Limits) (*Handler, error) {
+	schema, err := NewSchema(userService)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{schema: schema, userService: userService, limits: limits}, nil
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// ServeHTTP accepts queries as a JSON POST body or, for queries only, in the
+// query string of a GET request. Documents that fail to parse, validate or
+// stay within the depth and complexity limits are rejected with 400 before
+// any resolver runs.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if v := query.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+			writeErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+		return
+	}
+
+	if validation := graphql.ValidateDocument(&h.schema, doc, nil); !validation.IsValid {
+		writeErrors(w, http.StatusBadRequest, validation.Errors)
+		return
+	}
+
+	if err := checkLimits(doc, req.Variables, h.limits); err != nil {
+		writeErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+		return
+	}
+
+	if r.Method == http.MethodGet && isMutation(doc, req.OperationName) {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Mutations require POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoader(r.Context(), newUserLoader(h.userService)),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func writeErrors(w http.ResponseWriter, status int, errs []gqlerrors.FormattedError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(graphql.Result{Errors: errs})
+}
+
+func isMutation(doc *ast.Document, operationName string) bool {
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName != "" && (op.Name == nil || op.Name.Value != operationName) {
+			continue
+		}
+		if op.Operation == ast.OperationTypeMutation {
+			return true
+		}
+	}
+	return false
+}
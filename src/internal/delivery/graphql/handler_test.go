@@ -0,0 +1,244 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockUserService struct {
+	mock.Mock
+}
+
+func (m *MockUserService) CreateUser(user *domain.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserService) GetUsersByIDs(ids []int64) ([]*domain.User, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockUserService) UpdateUser(user *domain.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserService) DeleteUser(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ListUsers(filter domain.UserFilter) ([]*domain.User, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func doQuery(t *testing.T, h http.Handler, query string, variables map[string]interface{}) (int, response) {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	var resp response
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return w.Code, resp
+}
+
+func newTestHandler(t *testing.T, svc UserService, limits Limits) *Handler {
+	h, err := NewHandler(svc, limits)
+	require.NoError(t, err)
+	return h
+}
+
+func TestUserLookupsAreBatched(t *testing.T) {
+	mockService := new(MockUserService)
+	h := newTestHandler(t, mockService, Limits{})
+
+	mockService.On("GetUsersByIDs", mock.MatchedBy(func(ids []int64) bool {
+		return assert.ElementsMatch(t, []int64{1, 2, 99}, ids)
+	})).Return([]*domain.User{
+		{ID: 1, Name: "John Doe"},
+		{ID: 2, Name: "Jane Doe"},
+	}, nil).Once()
+
+	code, resp := doQuery(t, h, `{
+		a: user(id: "1") { name }
+		b: user(id: "2") { name }
+		again: user(id: "1") { id }
+		missing: user(id: "99") { name }
+	}`, nil)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]interface{}{"name": "John Doe"}, resp.Data["a"])
+	assert.Equal(t, map[string]interface{}{"name": "Jane Doe"}, resp.Data["b"])
+	assert.Equal(t, map[string]interface{}{"id": "1"}, resp.Data["again"])
+	assert.Nil(t, resp.Data["missing"])
+	mockService.AssertExpectations(t)
+}
+
+func TestUsersConnection(t *testing.T) {
+	mockService := new(MockUserService)
+	h := newTestHandler(t, mockService, Limits{})
+
+	query := `query($after: String) {
+		users(first: 2, after: $after, filter: {emailContains: "example"}) {
+			edges { cursor node { id } }
+			pageInfo { hasNextPage hasPreviousPage endCursor }
+		}
+	}`
+
+	mockService.On("ListUsers", domain.UserFilter{EmailContains: "example", Limit: 3}).
+		Return([]*domain.User{{ID: 1}, {ID: 2}, {ID: 3}}, nil).Once()
+
+	code, resp := doQuery(t, h, query, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Errors)
+
+	users := resp.Data["users"].(map[string]interface{})
+	assert.Len(t, users["edges"], 2)
+	pageInfo := users["pageInfo"].(map[string]interface{})
+	assert.Equal(t, true, pageInfo["hasNextPage"])
+	assert.Equal(t, false, pageInfo["hasPreviousPage"])
+
+	mockService.On("ListUsers", domain.UserFilter{EmailContains: "example", Limit: 3, Offset: 2}).
+		Return([]*domain.User{{ID: 3}}, nil).Once()
+
+	_, resp = doQuery(t, h, query, map[string]interface{}{"after": pageInfo["endCursor"]})
+	require.Empty(t, resp.Errors)
+	pageInfo = resp.Data["users"].(map[string]interface{})["pageInfo"].(map[string]interface{})
+	assert.Equal(t, false, pageInfo["hasNextPage"])
+	assert.Equal(t, true, pageInfo["hasPreviousPage"])
+	mockService.AssertExpectations(t)
+}
+
+func TestMutations(t *testing.T) {
+	t.Run("create", func(t *testing.T) {
+		mockService := new(MockUserService)
+		h := newTestHandler(t, mockService, Limits{})
+
+		mockService.On("CreateUser", &domain.User{Name: "John Doe", Email: "john@example.com"}).
+			Run(func(args mock.Arguments) { args.Get(0).(*domain.User).ID = 7 }).
+			Return(nil)
+
+		code, resp := doQuery(t, h, `mutation { createUser(input: {name: "John Doe", email: "john@example.com"}) { id email } }`, nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Empty(t, resp.Errors)
+		assert.Equal(t, map[string]interface{}{"id": "7", "email": "john@example.com"}, resp.Data["createUser"])
+	})
+
+	t.Run("create with status and attributes", func(t *testing.T) {
+		mockService := new(MockUserService)
+		h := newTestHandler(t, mockService, Limits{})
+
+		mockService.On("CreateUser", &domain.User{Name: "John Doe", Email: "john@example.com", Status: domain.UserStatusPending, Attributes: domain.Attributes{"department": "IT", "level": int64(3)}}).
+			Run(func(args mock.Arguments) { args.Get(0).(*domain.User).ID = 7 }).
+			Return(nil)
+
+		_, resp := doQuery(t, h, `mutation { createUser(input: {name: "John Doe", email: "john@example.com", status: "pending", attributes: {department: "IT", level: 3}}) { status attributes } }`, nil)
+		require.Empty(t, resp.Errors)
+		assert.Equal(t, map[string]interface{}{"status": "pending", "attributes": map[string]interface{}{"department": "IT", "level": float64(3)}}, resp.Data["createUser"])
+	})
+
+	t.Run("update status and attributes", func(t *testing.T) {
+		mockService := new(MockUserService)
+		h := newTestHandler(t, mockService, Limits{})
+
+		changedAt := "2025-03-21T13:45:30Z"
+		mockService.On("UpdateUser", &domain.User{ID: 7, Status: domain.UserStatusSuspended, StatusReason: "chargeback", Attributes: domain.Attributes{"department": nil}}).
+			Run(func(args mock.Arguments) {
+				user := args.Get(0).(*domain.User)
+				user.StatusChangedAt = &changedAt
+				user.Attributes = nil
+			}).
+			Return(nil)
+
+		query := `mutation($input: UpdateUserInput!) { updateUser(input: $input) { statusReason statusChangedAt attributes } }`
+		_, resp := doQuery(t, h, query, map[string]interface{}{"input": map[string]interface{}{
+			"id": "7", "status": "suspended", "statusReason": "chargeback", "attributes": map[string]interface{}{"department": nil},
+		}})
+		require.Empty(t, resp.Errors)
+		assert.Equal(t, map[string]interface{}{"statusReason": "chargeback", "statusChangedAt": changedAt, "attributes": nil}, resp.Data["updateUser"])
+	})
+
+	t.Run("error codes", func(t *testing.T) {
+		mockService := new(MockUserService)
+		h := newTestHandler(t, mockService, Limits{})
+
+		mockService.On("CreateUser", mock.Anything).Return(errors.ErrEmailTaken)
+
+		_, resp := doQuery(t, h, `mutation { createUser(input: {name: "John Doe", email: "john@example.com"}) { id } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "CONFLICT", resp.Errors[0].Extensions["code"])
+	})
+
+	t.Run("not over GET", func(t *testing.T) {
+		h := newTestHandler(t, new(MockUserService), Limits{})
+
+		req := httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { deleteUser(id: "1") }`), nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestLimits(t *testing.T) {
+	t.Run("depth", func(t *testing.T) {
+		mockService := new(MockUserService)
+		h := newTestHandler(t, mockService, Limits{MaxDepth: 3})
+
+		code, resp := doQuery(t, h, `{ users { edges { node { id } } } }`, nil)
+		assert.Equal(t, http.StatusBadRequest, code)
+		require.Len(t, resp.Errors, 1)
+		assert.Contains(t, resp.Errors[0].Message, "depth 4")
+		mockService.AssertNotCalled(t, "ListUsers", mock.Anything)
+	})
+
+	t.Run("complexity scales with page size", func(t *testing.T) {
+		mockService := new(MockUserService)
+		h := newTestHandler(t, mockService, Limits{MaxComplexity: 100})
+
+		code, resp := doQuery(t, h, `query($n: Int) { users(first: $n) { edges { node { ...f } } } } fragment f on User { id name email }`,
+			map[string]interface{}{"n": 100})
+		assert.Equal(t, http.StatusBadRequest, code)
+		require.Len(t, resp.Errors, 1)
+		assert.Contains(t, resp.Errors[0].Message, "complexity 501")
+	})
+
+	t.Run("introspection is free", func(t *testing.T) {
+		h := newTestHandler(t, new(MockUserService), Limits{MaxDepth: 1, MaxComplexity: 1})
+
+		code, resp := doQuery(t, h, `{ __schema { types { name fields { name type { name ofType { name } } } } } }`, nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Empty(t, resp.Errors)
+	})
+}
@@ -0,0 +1,112 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// queryCost computes the depth and complexity of every operation in a
+// validated document. Each field costs 1 and the cost of a paginated field's
+// selection is multiplied by the number of items it may return. Introspection
+// fields are free so that tooling keeps working under tight limits.
+type queryCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+func checkLimits(doc *ast.Document, variables map[string]interface{}, limits Limits) error {
+	cost := queryCost{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+	}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			cost.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		complexity, depth := cost.selectionSet(op.SelectionSet)
+		if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, limits.MaxDepth)
+		}
+		if limits.MaxComplexity > 0 && complexity > limits.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, limits.MaxComplexity)
+		}
+	}
+	return nil
+}
+
+func (c *queryCost) selectionSet(set *ast.SelectionSet) (complexity, depth int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, selection := range set.Selections {
+		var cx, d int
+		switch sel := selection.(type) {
+		case *ast.Field:
+			cx, d = c.field(sel)
+		case *ast.InlineFragment:
+			cx, d = c.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if fragment, ok := c.fragments[sel.Name.Value]; ok {
+				cx, d = c.selectionSet(fragment.SelectionSet)
+			}
+		}
+		complexity += cx
+		if d > depth {
+			depth = d
+		}
+	}
+	return complexity, depth
+}
+
+func (c *queryCost) field(field *ast.Field) (complexity, depth int) {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+	childComplexity, childDepth := c.selectionSet(field.SelectionSet)
+	return 1 + childComplexity*c.multiplier(field), 1 + childDepth
+}
+
+func (c *queryCost) multiplier(field *ast.Field) int {
+	if field.Name.Value != "users" {
+		return 1
+	}
+	for _, arg := range field.Arguments {
+		if arg.Name.Value == "first" {
+			if n, ok := c.intValue(arg.Value); ok && n > 0 {
+				return n
+			}
+		}
+	}
+	return defaultPageSize
+}
+
+func (c *queryCost) intValue(value ast.Value) (int, bool) {
+	switch v := value.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(v.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch n := c.variables[v.Name.Value].(type) {
+		case int:
+			return n, true
+		case float64:
+			return int(n), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	"users-api/src/internal/domain"
+)
+
+type loaderKey struct{}
+
+// userLoader batches user lookups made while resolving one request. Load only
+// records the ID and returns a thunk; graphql-go resolves every thunk of a
+// level after all of that level's fields have been visited, so the first
+// thunk to run fetches all pending IDs with a single GetUsersByIDs call.
+type userLoader struct {
+	userService UserService
+
+	mu      sync.Mutex
+	pending map[int64]struct{}
+	cache   map[int64]*domain.User
+}
+
+func newUserLoader(userService UserService) *userLoader {
+	return &userLoader{
+		userService: userService,
+		pending:     make(map[int64]struct{}),
+		cache:       make(map[int64]*domain.User),
+	}
+}
+
+func withLoader(ctx context.Context, loader *userLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+func loaderFromContext(ctx context.Context) *userLoader {
+	return ctx.Value(loaderKey{}).(*userLoader)
+}
+
+func (l *userLoader) Load(id int64) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.cache[id]; !ok {
+		l.pending[id] = struct{}{}
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		if err := l.flush(); err != nil {
+			return nil, err
+		}
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if user := l.cache[id]; user != nil {
+			return user, nil
+		}
+		return nil, nil
+	}
+}
+
+// Prime stores users that were fetched some other way, e.g. by a listing,
+// so that later loads of the same IDs need no query.
+func (l *userLoader) Prime(users ...*domain.User) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, user := range users {
+		l.cache[user.ID] = user
+	}
+}
+
+// Clear drops a cached user after a mutation changed or removed it.
+func (l *userLoader) Clear(id int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, id)
+}
+
+func (l *userLoader) flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(l.pending))
+	for id := range l.pending {
+		ids = append(ids, id)
+	}
+	l.pending = make(map[int64]struct{})
+
+	users, err := l.userService.GetUsersByIDs(ids)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		l.cache[id] = nil
+	}
+	for _, user := range users {
+		l.cache[user.ID] = user
+	}
+	return nil
+}
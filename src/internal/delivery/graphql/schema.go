@@ -0,0 +1,425 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"users-api/src/internal/domain"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	cursorPrefix    = "offset:"
+)
+
+type UserService interface {
+	CreateUser(user *domain.User) error
+	GetUsersByIDs(ids []int64) ([]*domain.User, error)
+	UpdateUser(user *domain.User) error
+	DeleteUser(id int64) error
+	ListUsers(filter domain.UserFilter) ([]*domain.User, error)
+}
+
+type userEdge struct {
+	cursor string
+	node   *domain.User
+}
+
+type userConnection struct {
+	edges       []userEdge
+	hasNext     bool
+	hasPrevious bool
+}
+
+func NewSchema(userService UserService) (graphql.Schema, error) {
+	r := &resolver{userService: userService}
+
+	attributesType := graphql.NewScalar(graphql.ScalarConfig{
+		Name:         "Attributes",
+		Description:  "Custom attribute values by name, as a JSON object. In updates a null value removes the attribute.",
+		Serialize:    func(value interface{}) interface{} { return value },
+		ParseValue:   parseAttributes,
+		ParseLiteral: parseAttributesLiteral,
+	})
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":              userField(graphql.NewNonNull(graphql.ID), func(u *domain.User) interface{} { return strconv.FormatInt(u.ID, 10) }),
+			"name":            userField(graphql.NewNonNull(graphql.String), func(u *domain.User) interface{} { return u.Name }),
+			"email":           userField(graphql.NewNonNull(graphql.String), func(u *domain.User) interface{} { return u.Email }),
+			"emailVerifiedAt": userField(graphql.String, func(u *domain.User) interface{} { return optional(u.EmailVerifiedAt) }),
+			"mfaEnabled":      userField(graphql.NewNonNull(graphql.Boolean), func(u *domain.User) interface{} { return u.MFAEnabled }),
+			"lockedUntil":     userField(graphql.String, func(u *domain.User) interface{} { return optional(u.LockedUntil) }),
+			"createdAt":       userField(graphql.NewNonNull(graphql.String), func(u *domain.User) interface{} { return u.CreatedAt }),
+			"updatedAt":       userField(graphql.NewNonNull(graphql.String), func(u *domain.User) interface{} { return u.UpdatedAt }),
+			"status":          userField(graphql.NewNonNull(graphql.String), func(u *domain.User) interface{} { return u.Status }),
+			"statusReason":    userField(graphql.String, func(u *domain.User) interface{} { return optional(&u.StatusReason) }),
+			"statusChangedAt": userField(graphql.String, func(u *domain.User) interface{} { return optional(u.StatusChangedAt) }),
+			"attributes":      userField(attributesType, func(u *domain.User) interface{} { return attributesOrNil(u.Attributes) }),
+		},
+	})
+
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "UserEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(userEdge).cursor, nil },
+			},
+			"node": &graphql.Field{
+				Type:    graphql.NewNonNull(userType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(userEdge).node, nil },
+			},
+		},
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Boolean),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*userConnection).hasNext, nil },
+			},
+			"hasPreviousPage": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Boolean),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*userConnection).hasPrevious, nil },
+			},
+			"startCursor": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					conn := p.Source.(*userConnection)
+					if len(conn.edges) == 0 {
+						return nil, nil
+					}
+					return conn.edges[0].cursor, nil
+				},
+			},
+			"endCursor": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					conn := p.Source.(*userConnection)
+					if len(conn.edges) == 0 {
+						return nil, nil
+					}
+					return conn.edges[len(conn.edges)-1].cursor, nil
+				},
+			},
+		},
+	})
+
+	connectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "UserConnection",
+		Fields: graphql.Fields{
+			"edges": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*userConnection).edges, nil },
+			},
+			"pageInfo": &graphql.Field{
+				Type:    graphql.NewNonNull(pageInfoType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source, nil },
+			},
+		},
+	})
+
+	filterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UserFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"nameContains":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"emailContains": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"createdAfter":  &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "RFC 3339 timestamp, inclusive."},
+			"createdBefore": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "RFC 3339 timestamp, exclusive."},
+		},
+	})
+
+	createInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreateUserInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"email":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"status":     &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "pending or active; defaults to active."},
+			"attributes": &graphql.InputObjectFieldConfig{Type: attributesType},
+		},
+	})
+
+	updateInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UpdateUserInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"id":           &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.ID)},
+			"name":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"email":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"status":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"statusReason": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Recorded with the status change; ignored if status is unchanged."},
+			"attributes":   &graphql.InputObjectFieldConfig{Type: attributesType, Description: "Merged into the stored attributes."},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.user,
+			},
+			"users": &graphql.Field{
+				Type: graphql.NewNonNull(connectionType),
+				Args: graphql.FieldConfigArgument{
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String},
+					"filter": &graphql.ArgumentConfig{Type: filterType},
+				},
+				Resolve: r.users,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createUser": &graphql.Field{
+				Type: graphql.NewNonNull(userType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createInputType)},
+				},
+				Resolve: r.createUser,
+			},
+			"updateUser": &graphql.Field{
+				Type: graphql.NewNonNull(userType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateInputType)},
+				},
+				Resolve: r.updateUser,
+			},
+			"deleteUser": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.deleteUser,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func userField(t graphql.Output, value func(u *domain.User) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return value(p.Source.(*domain.User)), nil
+		},
+	}
+}
+
+func optional(value *string) interface{} {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return *value
+}
+
+func attributesOrNil(attributes domain.Attributes) interface{} {
+	if len(attributes) == 0 {
+		return nil
+	}
+	return map[string]interface{}(attributes)
+}
+
+func parseAttributes(value interface{}) interface{} {
+	attributes, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return domain.Attributes(attributes)
+}
+
+// parseAttributesLiteral accepts an object of scalar values written inline
+// in the query. Values that are not scalars make the whole literal invalid.
+func parseAttributesLiteral(value ast.Value) interface{} {
+	object, ok := value.(*ast.ObjectValue)
+	if !ok {
+		return nil
+	}
+	attributes := make(domain.Attributes, len(object.Fields))
+	for _, field := range object.Fields {
+		switch v := field.Value.(type) {
+		case *ast.StringValue:
+			attributes[field.Name.Value] = v.Value
+		case *ast.BooleanValue:
+			attributes[field.Name.Value] = v.Value
+		case *ast.IntValue:
+			n, err := strconv.ParseInt(v.Value, 10, 64)
+			if err != nil {
+				return nil
+			}
+			attributes[field.Name.Value] = n
+		case *ast.FloatValue:
+			n, err := strconv.ParseFloat(v.Value, 64)
+			if err != nil {
+				return nil
+			}
+			attributes[field.Name.Value] = n
+		default:
+			return nil
+		}
+	}
+	return attributes
+}
+
+type resolver struct {
+	userService UserService
+}
+
+func (r *resolver) user(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	return loaderFromContext(p.Context).Load(id), nil
+}
+
+func (r *resolver) users(p graphql.ResolveParams) (interface{}, error) {
+	first, _ := p.Args["first"].(int)
+	if first < 1 || first > maxPageSize {
+		return nil, badInput("first must be between 1 and " + strconv.Itoa(maxPageSize))
+	}
+
+	offset := 0
+	if after, ok := p.Args["after"].(string); ok && after != "" {
+		n, err := decodeCursor(after)
+		if err != nil {
+			return nil, badInput("invalid cursor")
+		}
+		offset = n + 1
+	}
+
+	filter, err := parseFilter(p.Args["filter"])
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = first + 1
+	filter.Offset = offset
+
+	users, err := r.userService.ListUsers(filter)
+	if err != nil {
+		return nil, resolveError(err)
+	}
+
+	conn := &userConnection{hasPrevious: offset > 0}
+	if len(users) > first {
+		users = users[:first]
+		conn.hasNext = true
+	}
+	loaderFromContext(p.Context).Prime(users...)
+	for i, user := range users {
+		conn.edges = append(conn.edges, userEdge{cursor: encodeCursor(offset + i), node: user})
+	}
+	return conn, nil
+}
+
+func (r *resolver) createUser(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+	user := &domain.User{}
+	user.Name, _ = input["name"].(string)
+	user.Email, _ = input["email"].(string)
+	user.Status, _ = input["status"].(string)
+	user.Attributes, _ = input["attributes"].(domain.Attributes)
+
+	if err := r.userService.CreateUser(user); err != nil {
+		return nil, resolveError(err)
+	}
+	loaderFromContext(p.Context).Prime(user)
+	return user, nil
+}
+
+func (r *resolver) updateUser(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+	id, err := parseID(input["id"])
+	if err != nil {
+		return nil, err
+	}
+	user := &domain.User{ID: id}
+	user.Name, _ = input["name"].(string)
+	user.Email, _ = input["email"].(string)
+	user.Status, _ = input["status"].(string)
+	user.StatusReason, _ = input["statusReason"].(string)
+	user.Attributes, _ = input["attributes"].(domain.Attributes)
+
+	if err := r.userService.UpdateUser(user); err != nil {
+		return nil, resolveError(err)
+	}
+	loaderFromContext(p.Context).Prime(user)
+	return user, nil
+}
+
+func (r *resolver) deleteUser(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	if err := r.userService.DeleteUser(id); err != nil {
+		return nil, resolveError(err)
+	}
+	loaderFromContext(p.Context).Clear(id)
+	return true, nil
+}
+
+func parseID(value interface{}) (int64, error) {
+	s, _ := value.(string)
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, badInput("invalid user ID")
+	}
+	return id, nil
+}
+
+func parseFilter(value interface{}) (domain.UserFilter, error) {
+	var filter domain.UserFilter
+	input, ok := value.(map[string]interface{})
+	if !ok {
+		return filter, nil
+	}
+
+	filter.NameContains, _ = input["nameContains"].(string)
+	filter.EmailContains, _ = input["emailContains"].(string)
+
+	for name, dst := range map[string]**time.Time{
+		"createdAfter":  &filter.CreatedAfter,
+		"createdBefore": &filter.CreatedBefore,
+	} {
+		if s, ok := input[name].(string); ok && s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return filter, badInput(name + " must be an RFC 3339 timestamp")
+			}
+			*dst = &t
+		}
+	}
+	return filter, nil
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix))
+	if err != nil || offset < 0 || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, strconv.ErrSyntax
+	}
+	return offset, nil
+}
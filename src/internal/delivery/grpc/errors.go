@@ -0,0 +1,36 @@
+package grpc
+
+import (
+	stdErrors "errors"
+
+	"users-api/src/internal/errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError maps service errors to gRPC status codes the same way the REST
+// handlers map them to HTTP statuses. Unknown errors become Internal without
+// leaking their message.
+func statusError(err error) error {
+	var locked *errors.LockedError
+	if stdErrors.As(err, &locked) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if stdErrors.Is(err, errors.ErrInvalidAttribute) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	switch err {
+	case errors.ErrUserNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case errors.ErrInvalidInput, errors.ErrInvalidEmail, errors.ErrEmailRejected:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.ErrEmailTaken:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.ErrInvalidStatusTransition:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
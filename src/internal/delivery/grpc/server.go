@@ -0,0 +1,242 @@
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	usersv1 "users-api/src/api/gen/users/v1"
+	"users-api/src/internal/domain"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type UserService interface {
+	CreateUser(user *domain.User) error
+	GetUser(id int64) (*domain.User, error)
+	UpdateUser(user *domain.User) error
+	DeleteUser(id int64) error
+	ListUsers(filter domain.UserFilter) ([]*domain.User, error)
+	Subscribe() (<-chan domain.UserEvent, func())
+}
+
+// NewServer returns a gRPC server exposing userService together with the
+// standard health service and server reflection.
+func NewServer(userService UserService, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+
+	usersv1.RegisterUserServiceServer(server, NewUserServer(userService))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(usersv1.UserService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+
+	return server
+}
+
+type UserServer struct {
+	usersv1.UnimplementedUserServiceServer
+	userService UserService
+}
+
+func NewUserServer(userService UserService) *UserServer {
+	return &UserServer{userService: userService}
+}
+
+func (s *UserServer) CreateUser(ctx context.Context, req *usersv1.CreateUserRequest) (*usersv1.CreateUserResponse, error) {
+	user := &domain.User{
+		Name:       req.GetName(),
+		Email:      req.GetEmail(),
+		Status:     req.GetStatus(),
+		Attributes: fromProtoAttributes(req.GetAttributes()),
+	}
+	if err := s.service(ctx).CreateUser(user); err != nil {
+		return nil, statusError(err)
+	}
+	return &usersv1.CreateUserResponse{User: toProtoUser(user)}, nil
+}
+
+func (s *UserServer) GetUser(ctx context.Context, req *usersv1.GetUserRequest) (*usersv1.GetUserResponse, error) {
+	user, err := s.service(ctx).GetUser(req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &usersv1.GetUserResponse{User: toProtoUser(user)}, nil
+}
+
+func (s *UserServer) UpdateUser(ctx context.Context, req *usersv1.UpdateUserRequest) (*usersv1.UpdateUserResponse, error) {
+	user := &domain.User{
+		ID:           req.GetId(),
+		Name:         req.GetName(),
+		Email:        req.GetEmail(),
+		Status:       req.GetStatus(),
+		StatusReason: req.GetStatusReason(),
+		Attributes:   fromProtoAttributes(req.GetAttributes()),
+	}
+	if err := s.service(ctx).UpdateUser(user); err != nil {
+		return nil, statusError(err)
+	}
+	return &usersv1.UpdateUserResponse{User: toProtoUser(user)}, nil
+}
+
+func (s *UserServer) DeleteUser(ctx context.Context, req *usersv1.DeleteUserRequest) (*usersv1.DeleteUserResponse, error) {
+	if err := s.service(ctx).DeleteUser(req.GetId()); err != nil {
+		return nil, statusError(err)
+	}
+	return &usersv1.DeleteUserResponse{}, nil
+}
+
+func (s *UserServer) ListUsers(ctx context.Context, req *usersv1.ListUsersRequest) (*usersv1.ListUsersResponse, error) {
+	offset, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+
+	filter := domain.UserFilter{
+		NameContains:  req.GetNameContains(),
+		EmailContains: req.GetEmailContains(),
+		Limit:         int(req.GetPageSize()),
+		Offset:        offset,
+	}
+	if req.CreatedAfter != nil {
+		t := req.CreatedAfter.AsTime()
+		filter.CreatedAfter = &t
+	}
+	if req.CreatedBefore != nil {
+		t := req.CreatedBefore.AsTime()
+		filter.CreatedBefore = &t
+	}
+
+	users, err := s.service(ctx).ListUsers(filter)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &usersv1.ListUsersResponse{Users: make([]*usersv1.User, len(users))}
+	for i, user := range users {
+		resp.Users[i] = toProtoUser(user)
+	}
+	// The service applies the default and maximum page size, so a short page
+	// is the only reliable sign that the listing is exhausted.
+	if len(users) > 0 && (req.GetPageSize() <= 0 || len(users) >= int(req.GetPageSize())) {
+		resp.NextPageToken = encodePageToken(offset + len(users))
+	}
+	return resp, nil
+}
+
+func (s *UserServer) WatchUsers(req *usersv1.WatchUsersRequest, stream usersv1.UserService_WatchUsersServer) error {
+	events, cancel := s.service(stream.Context()).Subscribe()
+	defer cancel()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "event stream fell behind, resubscribe")
+			}
+			if req.GetUserId() != 0 && event.UserID != req.GetUserId() {
+				continue
+			}
+			if err := stream.Send(&usersv1.WatchUsersResponse{Event: toProtoEvent(event)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toProtoUser(user *domain.User) *usersv1.User {
+	return &usersv1.User{
+		Id:              user.ID,
+		Name:            user.Name,
+		Email:           user.Email,
+		EmailVerifiedAt: toProtoTimestamp(user.EmailVerifiedAt),
+		MfaEnabled:      user.MFAEnabled,
+		LockedUntil:     toProtoTimestamp(user.LockedUntil),
+		CreateTime:      toProtoTimestamp(&user.CreatedAt),
+		UpdateTime:      toProtoTimestamp(&user.UpdatedAt),
+		Status:          user.Status,
+		StatusReason:    user.StatusReason,
+		StatusChangedAt: toProtoTimestamp(user.StatusChangedAt),
+		Attributes:      toProtoAttributes(user.Attributes),
+	}
+}
+
+// fromProtoAttributes returns nil for an unset struct, so an update without
+// attributes leaves the stored ones alone.
+func fromProtoAttributes(attributes *structpb.Struct) domain.Attributes {
+	if attributes == nil {
+		return nil
+	}
+	return attributes.AsMap()
+}
+
+func toProtoAttributes(attributes domain.Attributes) *structpb.Struct {
+	if len(attributes) == 0 {
+		return nil
+	}
+	pb, err := structpb.NewStruct(attributes)
+	if err != nil {
+		return nil
+	}
+	return pb
+}
+
+func toProtoTimestamp(value *string) *timestamppb.Timestamp {
+	if value == nil || *value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, *value)
+	if err != nil {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+var eventTypes = map[string]usersv1.UserEvent_Type{
+	domain.UserEventCreated: usersv1.UserEvent_TYPE_CREATED,
+	domain.UserEventUpdated: usersv1.UserEvent_TYPE_UPDATED,
+	domain.UserEventDeleted: usersv1.UserEvent_TYPE_DELETED,
+}
+
+func toProtoEvent(event domain.UserEvent) *usersv1.UserEvent {
+	pb := &usersv1.UserEvent{
+		Type:      eventTypes[event.Type],
+		UserId:    event.UserID,
+		OccurTime: timestamppb.New(event.OccurredAt),
+	}
+	if event.User != nil {
+		pb.User = toProtoUser(event.User)
+	}
+	return pb
+}
+
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return offset, nil
+}
@@ -0,0 +1,261 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	usersv1 "users-api/src/api/gen/users/v1"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type MockUserService struct {
+	mock.Mock
+	events chan domain.UserEvent
+}
+
+func (m *MockUserService) CreateUser(user *domain.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserService) GetUser(id int64) (*domain.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserService) UpdateUser(user *domain.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserService) DeleteUser(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ListUsers(filter domain.UserFilter) ([]*domain.User, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockUserService) Subscribe() (<-chan domain.UserEvent, func()) {
+	return m.events, func() {}
+}
+
+func newTestClient(t *testing.T, svc UserService, opts ...grpc.ServerOption) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(svc, opts...)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestCreateUser(t *testing.T) {
+	mockService := new(MockUserService)
+	client := usersv1.NewUserServiceClient(newTestClient(t, mockService))
+
+	t.Run("created", func(t *testing.T) {
+		mockService.On("CreateUser", &domain.User{Name: "John Doe", Email: "john@example.com"}).
+			Run(func(args mock.Arguments) {
+				user := args.Get(0).(*domain.User)
+				user.ID = 1
+				user.CreatedAt = "2024-01-01T10:00:00Z"
+			}).
+			Return(nil).Once()
+
+		resp, err := client.CreateUser(context.Background(), &usersv1.CreateUserRequest{Name: "John Doe", Email: "john@example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), resp.User.Id)
+		assert.Equal(t, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), resp.User.CreateTime.AsTime())
+		assert.Nil(t, resp.User.EmailVerifiedAt)
+	})
+
+	t.Run("status and attributes", func(t *testing.T) {
+		mockService.On("CreateUser", &domain.User{Name: "Jane Doe", Email: "jane@example.com", Status: domain.UserStatusPending, Attributes: domain.Attributes{"department": "IT", "level": float64(3)}}).
+			Run(func(args mock.Arguments) {
+				user := args.Get(0).(*domain.User)
+				user.ID = 2
+				user.Attributes["level"] = int64(3)
+			}).
+			Return(nil).Once()
+
+		attributes, err := structpb.NewStruct(map[string]interface{}{"department": "IT", "level": 3})
+		require.NoError(t, err)
+		resp, err := client.CreateUser(context.Background(), &usersv1.CreateUserRequest{Name: "Jane Doe", Email: "jane@example.com", Status: domain.UserStatusPending, Attributes: attributes})
+		require.NoError(t, err)
+		assert.Equal(t, domain.UserStatusPending, resp.User.Status)
+		assert.Equal(t, map[string]interface{}{"department": "IT", "level": float64(3)}, resp.User.Attributes.AsMap())
+	})
+
+	t.Run("missing required attribute", func(t *testing.T) {
+		mockService.On("CreateUser", mock.Anything).Return(&errors.AttributeError{Name: "department", Reason: "is required"}).Once()
+
+		_, err := client.CreateUser(context.Background(), &usersv1.CreateUserRequest{Name: "John Doe", Email: "john@example.com"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("email taken", func(t *testing.T) {
+		mockService.On("CreateUser", mock.Anything).Return(errors.ErrEmailTaken).Once()
+
+		_, err := client.CreateUser(context.Background(), &usersv1.CreateUserRequest{Name: "John Doe", Email: "john@example.com"})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	})
+}
+
+func TestUpdateUser(t *testing.T) {
+	mockService := new(MockUserService)
+	client := usersv1.NewUserServiceClient(newTestClient(t, mockService))
+
+	changedAt := "2025-03-21T13:45:30Z"
+	mockService.On("UpdateUser", &domain.User{ID: 1, Status: domain.UserStatusSuspended, StatusReason: "chargeback", Attributes: domain.Attributes{"department": nil}}).
+		Run(func(args mock.Arguments) {
+			user := args.Get(0).(*domain.User)
+			user.StatusChangedAt = &changedAt
+			user.Attributes = nil
+		}).
+		Return(nil)
+
+	attributes, err := structpb.NewStruct(map[string]interface{}{"department": nil})
+	require.NoError(t, err)
+	resp, err := client.UpdateUser(context.Background(), &usersv1.UpdateUserRequest{Id: 1, Status: domain.UserStatusSuspended, StatusReason: "chargeback", Attributes: attributes})
+	require.NoError(t, err)
+	assert.Equal(t, "chargeback", resp.User.StatusReason)
+	assert.Equal(t, time.Date(2025, 3, 21, 13, 45, 30, 0, time.UTC), resp.User.StatusChangedAt.AsTime())
+	assert.Nil(t, resp.User.Attributes)
+}
+
+func TestGetUser(t *testing.T) {
+	mockService := new(MockUserService)
+	client := usersv1.NewUserServiceClient(newTestClient(t, mockService))
+
+	mockService.On("GetUser", int64(999)).Return(nil, errors.ErrUserNotFound)
+
+	_, err := client.GetUser(context.Background(), &usersv1.GetUserRequest{Id: 999})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestListUsersPagination(t *testing.T) {
+	mockService := new(MockUserService)
+	client := usersv1.NewUserServiceClient(newTestClient(t, mockService))
+
+	mockService.On("ListUsers", domain.UserFilter{EmailContains: "example", Limit: 2}).
+		Return([]*domain.User{{ID: 1}, {ID: 2}}, nil)
+	mockService.On("ListUsers", domain.UserFilter{EmailContains: "example", Limit: 2, Offset: 2}).
+		Return([]*domain.User{{ID: 3}}, nil)
+
+	first, err := client.ListUsers(context.Background(), &usersv1.ListUsersRequest{EmailContains: "example", PageSize: 2})
+	require.NoError(t, err)
+	assert.Len(t, first.Users, 2)
+	require.NotEmpty(t, first.NextPageToken)
+
+	second, err := client.ListUsers(context.Background(), &usersv1.ListUsersRequest{EmailContains: "example", PageSize: 2, PageToken: first.NextPageToken})
+	require.NoError(t, err)
+	assert.Len(t, second.Users, 1)
+	assert.Empty(t, second.NextPageToken)
+
+	_, err = client.ListUsers(context.Background(), &usersv1.ListUsersRequest{PageToken: "!!"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestWatchUsers(t *testing.T) {
+	mockService := &MockUserService{events: make(chan domain.UserEvent, 2)}
+	client := usersv1.NewUserServiceClient(newTestClient(t, mockService))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.WatchUsers(ctx, &usersv1.WatchUsersRequest{UserId: 2})
+	require.NoError(t, err)
+
+	mockService.events <- domain.UserEvent{Type: domain.UserEventUpdated, UserID: 1, User: &domain.User{ID: 1}}
+	mockService.events <- domain.UserEvent{Type: domain.UserEventDeleted, UserID: 2}
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, usersv1.UserEvent_TYPE_DELETED, resp.Event.Type)
+	assert.Equal(t, int64(2), resp.Event.UserId)
+	assert.Nil(t, resp.Event.User)
+
+	close(mockService.events)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestHealth(t *testing.T) {
+	client := healthpb.NewHealthClient(newTestClient(t, new(MockUserService)))
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "users.v1.UserService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+}
+
+type tenantsByKey map[string]*domain.Tenant
+
+func (t tenantsByKey) Authenticate(apiKey string) (*domain.Tenant, error) {
+	if tenant, ok := t[apiKey]; ok {
+		return tenant, nil
+	}
+	return nil, errors.ErrInvalidAPIKey
+}
+
+func TestTenantInterceptors(t *testing.T) {
+	acmeService := new(MockUserService)
+	released := 0
+	services := func(tenantID int64) (UserService, func(), error) {
+		if tenantID == 2 {
+			return acmeService, func() { released++ }, nil
+		}
+		return nil, nil, errors.ErrTenantNotFound
+	}
+	conn := newTestClient(t, new(MockUserService), TenantInterceptors(tenantsByKey{"acme-key": {ID: 2}}, true, services)...)
+	client := usersv1.NewUserServiceClient(conn)
+
+	acmeService.On("GetUser", int64(1)).Return(&domain.User{ID: 1, Name: "John Doe"}, nil)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer acme-key")
+	resp, err := client.GetUser(ctx, &usersv1.GetUserRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", resp.User.Name)
+	assert.Equal(t, 1, released)
+
+	_, err = client.GetUser(context.Background(), &usersv1.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "other-key")
+	_, err = client.GetUser(ctx, &usersv1.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Health checks need no key.
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+}
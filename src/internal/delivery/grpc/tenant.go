@@ -0,0 +1,116 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	usersv1 "users-api/src/api/gen/users/v1"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type TenantAuthenticator interface {
+	Authenticate(apiKey string) (*domain.Tenant, error)
+}
+
+// TenantServices returns the UserService scoped to a tenant and a release
+// func, called once the call or stream it serves has returned.
+type TenantServices func(tenantID int64) (UserService, func(), error)
+
+type serviceKey struct{}
+
+// TenantInterceptors authenticate UserService calls by the API key in their
+// x-api-key or authorization metadata, like the REST API does with headers,
+// and serve each call with the UserService of the caller's tenant. Calls
+// without a key act as the default tenant unless requireKey is set. Health
+// and reflection calls are not authenticated.
+func TenantInterceptors(auth TenantAuthenticator, requireKey bool, services TenantServices) []grpc.ServerOption {
+	resolve := func(ctx context.Context, method string) (context.Context, func(), error) {
+		if !strings.HasPrefix(method, "/"+usersv1.UserService_ServiceDesc.ServiceName+"/") {
+			return ctx, func() {}, nil
+		}
+		tenantID, err := authenticate(ctx, auth, requireKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		svc, release, err := services(tenantID)
+		if err != nil {
+			return nil, nil, status.Error(codes.Internal, "internal error")
+		}
+		return context.WithValue(ctx, serviceKey{}, svc), release, nil
+	}
+
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, release, err := resolve(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, release, err := resolve(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return handler(srv, &tenantStream{ServerStream: ss, ctx: ctx})
+	}
+
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(unary), grpc.ChainStreamInterceptor(stream)}
+}
+
+func authenticate(ctx context.Context, auth TenantAuthenticator, requireKey bool) (int64, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := firstValue(md, "x-api-key")
+	if key == "" {
+		if bearer := firstValue(md, "authorization"); len(bearer) > 7 && strings.EqualFold(bearer[:7], "bearer ") {
+			key = strings.TrimSpace(bearer[7:])
+		}
+	}
+	if key == "" {
+		if requireKey {
+			return 0, status.Error(codes.Unauthenticated, errors.ErrUnauthenticated.Error())
+		}
+		return domain.DefaultTenantID, nil
+	}
+
+	tenant, err := auth.Authenticate(key)
+	if err == errors.ErrInvalidAPIKey {
+		return 0, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		return 0, status.Error(codes.Internal, "internal error")
+	}
+	return tenant.ID, nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantStream) Context() context.Context {
+	return s.ctx
+}
+
+// service returns the UserService the tenant interceptors chose for the
+// call, or the server's own one without them.
+func (s *UserServer) service(ctx context.Context) UserService {
+	if svc, ok := ctx.Value(serviceKey{}).(UserService); ok {
+		return svc
+	}
+	return s.userService
+}
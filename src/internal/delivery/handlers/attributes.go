@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+type AttributeService interface {
+	CreateDefinition(def *domain.AttributeDefinition) error
+	GetDefinition(name string) (*domain.AttributeDefinition, error)
+	ListDefinitions() ([]*domain.AttributeDefinition, error)
+	UpdateDefinition(def *domain.AttributeDefinition) error
+	DeleteDefinition(name string) error
+}
+
+// AttributeHandler manages the definitions of custom user attributes.
+type AttributeHandler struct {
+	attributeService AttributeService
+}
+
+func NewAttributeHandler(attributeService AttributeService) *AttributeHandler {
+	return &AttributeHandler{attributeService: attributeService}
+}
+
+type attributeListResponse struct {
+	XMLName    xml.Name                      `json:"-" xml:"attribute_definitions"`
+	Attributes []*domain.AttributeDefinition `json:"attributes" xml:"attribute_definition"`
+}
+
+func (h *AttributeHandler) CreateDefinition(w http.ResponseWriter, r *http.Request) {
+	var def domain.AttributeDefinition
+	if !decodeRequest(w, r, &def) {
+		return
+	}
+
+	if err := h.attributeService.CreateDefinition(&def); err != nil {
+		writeAttributeDefinitionError(w, r, err, "Failed to create attribute")
+		return
+	}
+
+	writeResponse(w, r, http.StatusCreated, def)
+}
+
+func (h *AttributeHandler) GetDefinition(w http.ResponseWriter, r *http.Request) {
+	def, err := h.attributeService.GetDefinition(r.URL.Query().Get("name"))
+	if err != nil {
+		writeAttributeDefinitionError(w, r, err, "Failed to get attribute")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, def)
+}
+
+func (h *AttributeHandler) ListDefinitions(w http.ResponseWriter, r *http.Request) {
+	defs, err := h.attributeService.ListDefinitions()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err, "Failed to list attributes")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, attributeListResponse{Attributes: defs})
+}
+
+func (h *AttributeHandler) UpdateDefinition(w http.ResponseWriter, r *http.Request) {
+	var def domain.AttributeDefinition
+	if !decodeRequest(w, r, &def) {
+		return
+	}
+
+	if err := h.attributeService.UpdateDefinition(&def); err != nil {
+		writeAttributeDefinitionError(w, r, err, "Failed to update attribute")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, def)
+}
+
+func (h *AttributeHandler) DeleteDefinition(w http.ResponseWriter, r *http.Request) {
+	if err := h.attributeService.DeleteDefinition(r.URL.Query().Get("name")); err != nil {
+		writeAttributeDefinitionError(w, r, err, "Failed to delete attribute")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeAttributeDefinitionError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch err {
+	case errors.ErrInvalidInput:
+		writeError(w, r, http.StatusBadRequest, err, "Invalid attribute definition")
+	case errors.ErrAttributeNotFound:
+		writeError(w, r, http.StatusNotFound, err, "Attribute not found")
+	case errors.ErrAttributeExists:
+		writeError(w, r, http.StatusConflict, err, "Attribute already defined")
+	default:
+		writeError(w, r, http.StatusInternalServerError, err, message)
+	}
+}
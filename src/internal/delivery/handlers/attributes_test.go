@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAttributeService struct {
+	mock.Mock
+}
+
+func (m *MockAttributeService) CreateDefinition(def *domain.AttributeDefinition) error {
+	args := m.Called(def)
+	return args.Error(0)
+}
+
+func (m *MockAttributeService) GetDefinition(name string) (*domain.AttributeDefinition, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeService) ListDefinitions() ([]*domain.AttributeDefinition, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeService) UpdateDefinition(def *domain.AttributeDefinition) error {
+	args := m.Called(def)
+	return args.Error(0)
+}
+
+func (m *MockAttributeService) DeleteDefinition(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+func TestCreateAttributeDefinition(t *testing.T) {
+	mockService := new(MockAttributeService)
+	handler := NewAttributeHandler(mockService)
+
+	mockService.On("CreateDefinition", &domain.AttributeDefinition{Name: "department", Type: "string", Enum: []string{"Sales", "IT"}}).Return(nil).Once()
+	mockService.On("CreateDefinition", mock.Anything).Return(errors.ErrAttributeExists).Once()
+
+	body := `{"name":"department","type":"string","enum":["Sales","IT"]}`
+	w := httptest.NewRecorder()
+	handler.CreateDefinition(w, httptest.NewRequest(http.MethodPost, "/users/attributes", bytes.NewBufferString(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.CreateDefinition(w, httptest.NewRequest(http.MethodPost, "/users/attributes", bytes.NewBufferString(body)))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteAttributeDefinition(t *testing.T) {
+	mockService := new(MockAttributeService)
+	handler := NewAttributeHandler(mockService)
+
+	mockService.On("DeleteDefinition", "department").Return(nil)
+	mockService.On("DeleteDefinition", "missing").Return(errors.ErrAttributeNotFound)
+
+	w := httptest.NewRecorder()
+	handler.DeleteDefinition(w, httptest.NewRequest(http.MethodDelete, "/users/attributes?name=department", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.DeleteDefinition(w, httptest.NewRequest(http.MethodDelete, "/users/attributes?name=missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateUserWithInvalidAttribute(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	mockService.On("CreateUser", mock.Anything).Return(&errors.AttributeError{Name: "department", Reason: "is required"})
+
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"name":"Ivan","email":"ivan@example.com"}`))
+	w := httptest.NewRecorder()
+	handler.CreateUser(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp ErrorResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "attribute department: is required", resp.Message)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+const (
+	batchModeAtomic     = "atomic"
+	batchModeBestEffort = "best_effort"
+)
+
+type batchRequest struct {
+	Mode       string                  `json:"mode" xml:"mode"`
+	Operations []domain.BatchOperation `json:"operations" xml:"operations>operation"`
+}
+
+type batchResponse struct {
+	XMLName   xml.Name             `json:"-" xml:"batch"`
+	Mode      string               `json:"mode" xml:"mode"`
+	Committed bool                 `json:"committed" xml:"committed"`
+	Results   []domain.BatchResult `json:"results" xml:"results>result"`
+}
+
+func (h *UserHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = batchModeBestEffort
+	}
+	if req.Mode != batchModeAtomic && req.Mode != batchModeBestEffort {
+		writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, "Mode must be atomic or best_effort")
+		return
+	}
+
+	results, err := h.userService.Batch(req.Operations, req.Mode == batchModeAtomic)
+	if err != nil {
+		switch err {
+		case errors.ErrBatchAborted:
+			writeResponse(w, r, http.StatusUnprocessableEntity, batchResponse{Mode: req.Mode, Results: results})
+		case errors.ErrInvalidInput:
+			writeError(w, r, http.StatusBadRequest, err, "Batch must contain at least one operation")
+		case errors.ErrBatchTooLarge:
+			writeError(w, r, http.StatusRequestEntityTooLarge, err, "Too many operations in batch")
+		case errors.ErrEmailTaken:
+			writeError(w, r, http.StatusConflict, err, "Email already in use")
+		case errors.ErrTxNotSupported:
+			writeError(w, r, http.StatusNotImplemented, err, "Atomic batches are not supported")
+		default:
+			writeError(w, r, http.StatusInternalServerError, err, "Failed to process batch")
+		}
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, batchResponse{Mode: req.Mode, Committed: true, Results: results})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBatch(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	t.Run("best effort by default", func(t *testing.T) {
+		results := []domain.BatchResult{
+			{Index: 0, Op: domain.BatchOpCreate, Status: domain.BatchStatusCreated, ID: 1},
+			{Index: 1, Op: domain.BatchOpDelete, Status: domain.BatchStatusFailed, ID: 9, Error: "user not found"},
+		}
+		mockService.On("Batch", mock.AnythingOfType("[]domain.BatchOperation"), false).Return(results, nil).Once()
+
+		body := `{"operations":[{"op":"create","user":{"name":"John","email":"john@example.com"}},{"op":"delete","id":9}]}`
+		req := httptest.NewRequest(http.MethodPost, "/users:batch", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+
+		handler.Batch(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response batchResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, batchModeBestEffort, response.Mode)
+		assert.True(t, response.Committed)
+		assert.Len(t, response.Results, 2)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("atomic batch rolled back", func(t *testing.T) {
+		results := []domain.BatchResult{
+			{Index: 0, Op: domain.BatchOpCreate, Status: domain.BatchStatusRolledBack},
+			{Index: 1, Op: domain.BatchOpCreate, Status: domain.BatchStatusFailed, Error: "invalid email format"},
+		}
+		mockService.On("Batch", mock.AnythingOfType("[]domain.BatchOperation"), true).Return(results, errors.ErrBatchAborted).Once()
+
+		body := `{"mode":"atomic","operations":[{"op":"create","user":{"name":"John","email":"john@example.com"}},{"op":"create","user":{"name":"Jane","email":"bad"}}]}`
+		req := httptest.NewRequest(http.MethodPost, "/users:batch", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+
+		handler.Batch(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response batchResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.False(t, response.Committed)
+		assert.Equal(t, domain.BatchStatusRolledBack, response.Results[0].Status)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users:batch", bytes.NewBufferString(`{"mode":"sometimes","operations":[]}`))
+		w := httptest.NewRecorder()
+
+		handler.Batch(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
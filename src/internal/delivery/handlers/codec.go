@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"users-api/src/internal/delivery/codec"
+	"users-api/src/internal/errors"
+)
+
+// decodeRequest decodes the request body into v, writing a 415 or 400 response
+// and returning false when it cannot.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	c, ok := codec.ForRequest(r)
+	if !ok {
+		writeError(w, r, http.StatusUnsupportedMediaType, errors.ErrUnsupportedMediaType, "Unsupported Content-Type")
+		return false
+	}
+	if err := c.Decode(r.Body, v); err != nil {
+		writeError(w, r, http.StatusBadRequest, err, "Invalid request body")
+		return false
+	}
+	return true
+}
+
+// Negotiate rejects requests whose Accept header cannot be satisfied before
+// the wrapped handler has any side effects.
+func Negotiate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := codec.ForResponse(r); !ok {
+			writeError(w, r, http.StatusNotAcceptable, errors.ErrNotAcceptable, "Supported types: application/json, application/xml, application/msgpack, application/cbor")
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"users-api/src/internal/domain"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestGetUserNegotiatedFormats(t *testing.T) {
+	user := &domain.User{ID: 1, Name: "John Doe", Email: "john@example.com"}
+
+	t.Run("xml", func(t *testing.T) {
+		mockService := new(MockUserService)
+		mockService.On("GetUser", int64(1)).Return(user, nil)
+		handler := Negotiate(NewUserHandler(mockService).GetUser)
+
+		req := httptest.NewRequest(http.MethodGet, "/users?id=1", nil)
+		req.Header.Set("Accept", "application/xml")
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/xml", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "<user><id>1</id><name>John Doe</name><email>john@example.com</email>")
+
+		var got domain.User
+		assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, "john@example.com", got.Email)
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		mockService := new(MockUserService)
+		mockService.On("GetUser", int64(1)).Return(user, nil)
+		handler := Negotiate(NewUserHandler(mockService).GetUser)
+
+		req := httptest.NewRequest(http.MethodGet, "/users?id=1", nil)
+		req.Header.Set("Accept", "application/msgpack")
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got map[string]interface{}
+		assert.NoError(t, msgpack.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, "John Doe", got["name"])
+		assert.NotContains(t, got, "XMLName")
+	})
+
+	t.Run("cbor error response", func(t *testing.T) {
+		handler := Negotiate(NewUserHandler(new(MockUserService)).GetUser)
+
+		req := httptest.NewRequest(http.MethodGet, "/users?id=abc", nil)
+		req.Header.Set("Accept", "application/cbor")
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "application/cbor", w.Header().Get("Content-Type"))
+		var got ErrorResponse
+		assert.NoError(t, cbor.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, "Invalid user ID", got.Message)
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := Negotiate(NewUserHandler(mockService).GetUser)
+
+		req := httptest.NewRequest(http.MethodGet, "/users?id=1", nil)
+		req.Header.Set("Accept", "text/html")
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		mockService.AssertNotCalled(t, "GetUser", mock.Anything)
+	})
+}
+
+func TestCreateUserDecodesRequestFormats(t *testing.T) {
+	t.Run("xml body", func(t *testing.T) {
+		mockService := new(MockUserService)
+		mockService.On("CreateUser", mock.MatchedBy(func(u *domain.User) bool {
+			return u.Name == "John Doe" && u.Email == "john@example.com"
+		})).Return(nil)
+		handler := NewUserHandler(mockService)
+
+		body := `<user><name>John Doe</name><email>john@example.com</email></user>`
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+		w := httptest.NewRecorder()
+		handler.CreateUser(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("msgpack body", func(t *testing.T) {
+		mockService := new(MockUserService)
+		mockService.On("CreateUser", mock.MatchedBy(func(u *domain.User) bool {
+			return u.Name == "John Doe" && u.Email == "john@example.com"
+		})).Return(nil)
+		handler := NewUserHandler(mockService)
+
+		body, err := msgpack.Marshal(map[string]string{"name": "John Doe", "email": "john@example.com"})
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/msgpack")
+		w := httptest.NewRecorder()
+		handler.CreateUser(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("name=John"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.CreateUser(w, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		mockService.AssertNotCalled(t, "CreateUser", mock.Anything)
+	})
+}
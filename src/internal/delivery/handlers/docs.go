@@ -0,0 +1,24 @@
+package handlers
+
+import "net/http"
+
+// DocsHandler serves the OpenAPI document and a page that renders it.
+type DocsHandler struct {
+	spec []byte
+	page []byte
+}
+
+func NewDocsHandler(spec, page []byte) *DocsHandler {
+	return &DocsHandler{spec: spec, page: page}
+}
+
+func (h *DocsHandler) Spec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(h.spec)
+}
+
+func (h *DocsHandler) Page(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(h.page)
+}
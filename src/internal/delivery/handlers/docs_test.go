@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"users-api/src/api/openapi"
+	"users-api/src/internal/delivery/middleware"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocsHandler(t *testing.T) {
+	handler := NewDocsHandler(openapi.Spec, openapi.DocsPage)
+
+	w := httptest.NewRecorder()
+	handler.Spec(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var doc map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&doc))
+	assert.Equal(t, "3.1.0", doc["openapi"])
+
+	w = httptest.NewRecorder()
+	handler.Page(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `spec-url="/openapi.json"`)
+}
+
+// TestResponsesMatchOpenAPI runs handlers behind the validator with response
+// validation on, so that the document and the handlers cannot drift apart
+// unnoticed.
+func TestResponsesMatchOpenAPI(t *testing.T) {
+	validator, err := middleware.NewOpenAPIValidator(openapi.Spec, true)
+	require.NoError(t, err)
+
+	userService := new(MockUserService)
+	userHandler := NewUserHandler(userService)
+	mfaService := new(MockMFAService)
+	mfaHandler := NewMFAHandler(mfaService)
+	webhookService := new(MockWebhookService)
+	webhookHandler := NewWebhookHandler(webhookService)
+	attributeService := new(MockAttributeService)
+	attributeHandler := NewAttributeHandler(attributeService)
+	groupService := new(MockGroupService)
+	groupHandler := NewGroupHandler(groupService)
+
+	verifiedAt := "2025-03-21T13:46:00Z"
+	user := &domain.User{ID: 1, Name: "Ivan", Email: "ivan@example.com", Status: domain.UserStatusActive, EmailVerifiedAt: &verifiedAt, CreatedAt: "2025-03-21T13:45:30Z", UpdatedAt: "2025-03-21T13:45:30Z", Attributes: domain.Attributes{"department": "Sales", "level": int64(3)}}
+	now := time.Date(2025, 3, 21, 13, 45, 30, 0, time.UTC)
+
+	userService.On("CreateUser", mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*domain.User) = *user
+	}).Return(nil)
+	userService.On("GetUser", int64(1)).Return(user, nil)
+	userService.On("GetUser", int64(2)).Return(nil, errors.ErrUserNotFound)
+	userService.On("GetUserByEmail", "Ivan@Example.com").Return(user, nil)
+	userService.On("ListUsers", mock.Anything).Return(nil, nil)
+	userService.On("SearchUsers", "ivan", 0).Return([]*domain.UserSearchResult{{User: user, Score: 1, NameHighlight: "<mark>Ivan</mark>", EmailHighlight: "<mark>ivan</mark>@example.com"}}, nil)
+	userService.On("Batch", mock.Anything, true).Return([]domain.BatchResult{{Index: 0, Op: domain.BatchOpDelete, Status: domain.BatchStatusRolledBack, ID: 1}}, errors.ErrBatchAborted)
+	mfaService.On("Enroll", int64(1)).Return(&domain.MFAEnrollment{Secret: "JBSWY3DPEHPK3PXP", URI: "otpauth://totp/x"}, nil)
+	webhookService.On("CreateSubscription", mock.Anything).Run(func(args mock.Arguments) {
+		sub := args.Get(0).(*domain.WebhookSubscription)
+		sub.ID, sub.Secret, sub.CreatedAt, sub.UpdatedAt = 1, "whsec_x", now, now
+	}).Return(nil)
+	attributeService.On("ListDefinitions").Return([]*domain.AttributeDefinition{{Name: "department", Type: domain.AttributeTypeString, Enum: []string{"Sales", "IT"}, CreatedAt: now, UpdatedAt: now}}, nil)
+	changedAt := "2025-03-21T14:00:00Z"
+	userService.On("ChangeStatus", int64(1), domain.UserStatusSuspended, "chargeback").Return(&domain.User{ID: 1, Name: "Ivan", Email: "ivan@example.com", Status: domain.UserStatusSuspended, StatusReason: "chargeback", StatusChangedAt: &changedAt, CreatedAt: "2025-03-21T13:45:30Z", UpdatedAt: "2025-03-21T14:00:00Z"}, nil)
+	userService.On("ChangeStatus", int64(1), domain.UserStatusActive, "").Return(nil, errors.ErrInvalidStatusTransition)
+	parentID := int64(1)
+	group := &domain.Group{ID: 2, Name: "Backend", ParentID: &parentID, CreatedAt: now, UpdatedAt: now}
+	groupService.On("CreateGroup", mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*domain.Group) = *group
+	}).Return(nil)
+	groupService.On("UpdateGroup", mock.Anything).Return(errors.ErrGroupCycle)
+	groupService.On("ListGroups", mock.Anything).Return([]*domain.Group{group}, nil)
+	groupService.On("GetMember", int64(1), int64(1)).Return(&domain.GroupMember{GroupID: 1, UserID: 1}, nil)
+	groupService.On("AddMember", int64(2), int64(1)).Return(&domain.GroupMember{GroupID: 2, UserID: 1, Direct: true, CreatedAt: &now}, true, nil)
+	groupService.On("ListUserGroups", int64(1)).Return([]*domain.UserGroup{{Group: domain.Group{ID: 1, Name: "Engineering", CreatedAt: now, UpdatedAt: now}}, {Group: *group, Direct: true}}, nil)
+	webhookService.On("ListDeliveries", mock.Anything).Return([]*domain.WebhookDelivery{{ID: 1, SubscriptionID: 1, EventID: 1, EventType: domain.UserEventCreated, Status: domain.WebhookDeliveryPending, NextAttemptAt: &now, CreatedAt: now}}, nil)
+
+	// The status endpoints read the user ID from the path.
+	statusMux := http.NewServeMux()
+	statusMux.HandleFunc("POST /users/{id}/suspend", userHandler.SuspendUser)
+	statusMux.HandleFunc("POST /users/{id}/activate", userHandler.ActivateUser)
+	groupMux := http.NewServeMux()
+	groupMux.HandleFunc("PUT /groups/{id}", groupHandler.UpdateGroup)
+	groupMux.HandleFunc("GET /groups/{id}/members/{userId}", groupHandler.GetMember)
+	groupMux.HandleFunc("PUT /groups/{id}/members/{userId}", groupHandler.AddMember)
+	groupMux.HandleFunc("GET /users/{id}/groups", groupHandler.ListUserGroups)
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		handler http.HandlerFunc
+		status  int
+	}{
+		{"create user", http.MethodPost, "/users", `{"name":"Ivan","email":"ivan@example.com","attributes":{"department":"Sales","level":3}}`, userHandler.CreateUser, http.StatusCreated},
+		{"get user", http.MethodGet, "/users?id=1", "", userHandler.GetUser, http.StatusOK},
+		{"get user by email", http.MethodGet, "/users?email=Ivan@Example.com", "", userHandler.GetUserByEmail, http.StatusOK},
+		{"user not found", http.MethodGet, "/users?id=2", "", userHandler.GetUser, http.StatusNotFound},
+		{"empty user list", http.MethodGet, "/users", "", userHandler.ListUsers, http.StatusOK},
+		{"search users", http.MethodGet, "/users/search?q=ivan", "", userHandler.SearchUsers, http.StatusOK},
+		{"suspend user", http.MethodPost, "/users/1/suspend", `{"reason":"chargeback"}`, statusMux.ServeHTTP, http.StatusOK},
+		{"activate active user", http.MethodPost, "/users/1/activate", "", statusMux.ServeHTTP, http.StatusConflict},
+		{"list attributes", http.MethodGet, "/users/attributes", "", attributeHandler.ListDefinitions, http.StatusOK},
+		{"aborted batch", http.MethodPost, "/users:batch", `{"mode":"atomic","operations":[{"op":"delete","id":1}]}`, userHandler.Batch, http.StatusUnprocessableEntity},
+		{"mfa enroll", http.MethodPost, "/users/mfa/enroll", `{"user_id":1}`, mfaHandler.Enroll, http.StatusCreated},
+		{"create group", http.MethodPost, "/groups", `{"name":"Backend","parent_id":1}`, groupHandler.CreateGroup, http.StatusCreated},
+		{"list groups", http.MethodGet, "/groups?limit=10", "", groupHandler.ListGroups, http.StatusOK},
+		{"nest group in itself", http.MethodPut, "/groups/1", `{"name":"Engineering","parent_id":2}`, groupMux.ServeHTTP, http.StatusConflict},
+		{"indirect group member", http.MethodGet, "/groups/1/members/1", "", groupMux.ServeHTTP, http.StatusOK},
+		{"add group member", http.MethodPut, "/groups/2/members/1", "", groupMux.ServeHTTP, http.StatusCreated},
+		{"user groups", http.MethodGet, "/users/1/groups", "", groupMux.ServeHTTP, http.StatusOK},
+		{"create webhook", http.MethodPost, "/webhooks", `{"url":"https://example.com/hook"}`, webhookHandler.CreateSubscription, http.StatusCreated},
+		{"list deliveries", http.MethodGet, "/webhooks/deliveries", "", webhookHandler.ListDeliveries, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			validator.Handler(tt.handler).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+const (
+	eventsReplayPageSize = 500
+	eventsHeartbeat      = 15 * time.Second
+	eventsRetry          = 3 * time.Second
+	// Event IDs are taken when the event is written, not when its
+	// transaction commits, so an event with an ID below Last-Event-ID may
+	// have become visible after the client received Last-Event-ID. Resuming
+	// replays this many IDs before it as well.
+	eventsResumeWindow = 100
+)
+
+type EventService interface {
+	Subscribe() (<-chan domain.UserEvent, func())
+	EventsSince(afterID, userID int64, limit int) ([]*domain.UserEvent, error)
+}
+
+type EventHandler struct {
+	eventService EventService
+	heartbeat    time.Duration
+}
+
+func NewEventHandler(eventService EventService) *EventHandler {
+	return &EventHandler{eventService: eventService, heartbeat: eventsHeartbeat}
+}
+
+// Stream sends user changes as Server-Sent Events. A client that reconnects
+// with Last-Event-ID (or ?last_event_id=) first receives the persisted events
+// it missed and then continues with live ones. The live subscription is
+// opened before the replay so nothing committed in between is lost; events
+// delivered by both are sent once. Live events are broadcast in commit order,
+// which may differ from ID order, so duplicates are detected by ID rather
+// than by comparing against the last replayed one. The replay starts
+// eventsResumeWindow IDs before Last-Event-ID, so clients receive again some
+// events they already have and should skip IDs they have seen.
+func (h *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+
+	var userID int64
+	if v := query.Get("user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, "Invalid user_id parameter")
+			return
+		}
+		userID = id
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = query.Get("last_event_id")
+	}
+	var afterID int64
+	resume := lastID != ""
+	if resume {
+		id, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || id < 0 {
+			writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, "Invalid Last-Event-ID")
+			return
+		}
+		afterID = max(id-eventsResumeWindow, 0)
+	}
+
+	live, cancel := h.eventService.Subscribe()
+	defer cancel()
+
+	var backlog []*domain.UserEvent
+	if resume {
+		var err error
+		backlog, err = h.eventService.EventsSince(afterID, userID, eventsReplayPageSize)
+		if err != nil {
+			if err == errors.ErrEventLogDisabled {
+				writeError(w, r, http.StatusNotImplemented, err, "Resuming is not supported")
+				return
+			}
+			writeError(w, r, http.StatusInternalServerError, err, "Failed to load events")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", eventsRetry.Milliseconds())
+	flusher.Flush()
+
+	replayed := make(map[int64]bool)
+	for len(backlog) > 0 {
+		for _, event := range backlog {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			replayed[event.ID] = true
+			afterID = event.ID
+		}
+		flusher.Flush()
+
+		if len(backlog) < eventsReplayPageSize {
+			break
+		}
+		var err error
+		if backlog, err = h.eventService.EventsSince(afterID, userID, eventsReplayPageSize); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-live:
+			if !ok {
+				// Dropped for falling behind; the client reconnects with
+				// Last-Event-ID and catches up from the log.
+				return
+			}
+			if userID != 0 && event.UserID != userID {
+				continue
+			}
+			if replayed[event.ID] {
+				continue
+			}
+			if err := writeEvent(w, &event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w io.Writer, event *domain.UserEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if event.ID != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockEventService struct {
+	mock.Mock
+	live chan domain.UserEvent
+}
+
+func (m *MockEventService) Subscribe() (<-chan domain.UserEvent, func()) {
+	return m.live, func() {}
+}
+
+func (m *MockEventService) EventsSince(afterID, userID int64, limit int) ([]*domain.UserEvent, error) {
+	args := m.Called(afterID, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UserEvent), args.Error(1)
+}
+
+// readEvents reads n SSE events from the stream, ignoring comments and the
+// retry hint.
+func readEvents(t *testing.T, scanner *bufio.Scanner, n int) []map[string]string {
+	var events []map[string]string
+	current := map[string]string{}
+	for len(events) < n && scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if current["event"] != "" {
+				events = append(events, current)
+			}
+			current = map[string]string{}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		current[field] = value
+	}
+	require.Len(t, events, n)
+	return events
+}
+
+func TestEventStream(t *testing.T) {
+	t.Run("resume then live", func(t *testing.T) {
+		mockService := &MockEventService{live: make(chan domain.UserEvent, 4)}
+		// Event 108 was committed after the client received event 110, so
+		// the replay starts eventsResumeWindow IDs earlier.
+		mockService.On("EventsSince", int64(10), int64(0), eventsReplayPageSize).Return([]*domain.UserEvent{
+			{ID: 108, Type: domain.UserEventUpdated, UserID: 3},
+			{ID: 111, Type: domain.UserEventCreated, UserID: 1, User: &domain.User{ID: 1}},
+			{ID: 112, Type: domain.UserEventDeleted, UserID: 2},
+		}, nil)
+
+		// Event 112 was committed after the subscription was opened, so it
+		// arrives both from the log and live and must be sent only once.
+		mockService.live <- domain.UserEvent{ID: 112, Type: domain.UserEventDeleted, UserID: 2}
+		mockService.live <- domain.UserEvent{ID: 113, Type: domain.UserEventUpdated, UserID: 1, User: &domain.User{ID: 1}}
+
+		server := httptest.NewServer(http.HandlerFunc(NewEventHandler(mockService).Stream))
+		defer server.Close()
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req.Header.Set("Last-Event-ID", "110")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		events := readEvents(t, bufio.NewScanner(resp.Body), 4)
+		assert.Equal(t, []string{"108", "111", "112", "113"}, []string{events[0]["id"], events[1]["id"], events[2]["id"], events[3]["id"]})
+		assert.Equal(t, domain.UserEventUpdated, events[3]["event"])
+		assert.Contains(t, events[3]["data"], `"user_id":1`)
+	})
+
+	t.Run("filter by user", func(t *testing.T) {
+		mockService := &MockEventService{live: make(chan domain.UserEvent, 4)}
+		mockService.live <- domain.UserEvent{ID: 1, Type: domain.UserEventCreated, UserID: 1}
+		mockService.live <- domain.UserEvent{ID: 2, Type: domain.UserEventCreated, UserID: 2}
+
+		server := httptest.NewServer(http.HandlerFunc(NewEventHandler(mockService).Stream))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "?user_id=2")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		events := readEvents(t, bufio.NewScanner(resp.Body), 1)
+		assert.Equal(t, "2", events[0]["id"])
+		mockService.AssertNotCalled(t, "EventsSince", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("heartbeat", func(t *testing.T) {
+		mockService := &MockEventService{live: make(chan domain.UserEvent)}
+		handler := NewEventHandler(mockService)
+		handler.heartbeat = 10 * time.Millisecond
+
+		server := httptest.NewServer(http.HandlerFunc(handler.Stream))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if scanner.Text() == ": keepalive" {
+				return
+			}
+		}
+		t.Fatal("no heartbeat received")
+	})
+
+	t.Run("resume without event log", func(t *testing.T) {
+		mockService := &MockEventService{live: make(chan domain.UserEvent)}
+		mockService.On("EventsSince", int64(0), int64(0), eventsReplayPageSize).Return(nil, errors.ErrEventLogDisabled)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/events?last_event_id=5", nil)
+		w := httptest.NewRecorder()
+		NewEventHandler(mockService).Stream(w, req)
+
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+	})
+
+	t.Run("invalid last event id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users/events", nil)
+		req.Header.Set("Last-Event-ID", "abc")
+		w := httptest.NewRecorder()
+		NewEventHandler(&MockEventService{}).Stream(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
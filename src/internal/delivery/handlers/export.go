@@ -0,0 +1,419 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+	exportFormatJSON   = "json"
+
+	exportFlushEvery = 500
+)
+
+type exportField struct {
+	name  string
+	value func(user *domain.User) interface{}
+}
+
+var exportFields = []exportField{
+	{"id", func(u *domain.User) interface{} { return u.ID }},
+	{"name", func(u *domain.User) interface{} { return u.Name }},
+	{"email", func(u *domain.User) interface{} { return u.Email }},
+	{"status", func(u *domain.User) interface{} { return u.Status }},
+	{"status_reason", func(u *domain.User) interface{} { return u.StatusReason }},
+	{"status_changed_at", func(u *domain.User) interface{} { return u.StatusChangedAt }},
+	{"email_verified_at", func(u *domain.User) interface{} { return u.EmailVerifiedAt }},
+	{"mfa_enabled", func(u *domain.User) interface{} { return u.MFAEnabled }},
+	{"locked_until", func(u *domain.User) interface{} { return u.LockedUntil }},
+	{"created_at", func(u *domain.User) interface{} { return u.CreatedAt }},
+	{"updated_at", func(u *domain.User) interface{} { return u.UpdatedAt }},
+	{"attributes", func(u *domain.User) interface{} { return u.Attributes }},
+}
+
+type listUsersResponse struct {
+	XMLName xml.Name       `json:"-" xml:"user_list"`
+	Users   []*domain.User `json:"users" xml:"users>user"`
+	Limit   int            `json:"limit" xml:"limit"`
+	Offset  int            `json:"offset" xml:"offset"`
+}
+
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseUserFilter(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, err.Error())
+		return
+	}
+
+	users, err := h.userService.ListUsers(filter)
+	if err != nil {
+		if writeAttributeError(w, r, err) {
+			return
+		}
+		if err == errors.ErrInvalidInput {
+			writeError(w, r, http.StatusBadRequest, err, "Invalid filter")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, err, "Failed to list users")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, listUsersResponse{Users: users, Limit: filter.Limit, Offset: filter.Offset})
+}
+
+// ExportUsers streams every user matching the listing filters as CSV, NDJSON
+// or a JSON array. Nothing is written until the first row arrives, so errors
+// raised before that still get a proper status code; a failure mid-stream can
+// only truncate the body.
+func (h *UserHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := parseUserFilter(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, err.Error())
+		return
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = exportFormatCSV
+	}
+	if format != exportFormatCSV && format != exportFormatNDJSON && format != exportFormatJSON {
+		writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, "Format must be csv, ndjson or json")
+		return
+	}
+
+	fields, err := parseExportFields(query.Get("fields"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, err.Error())
+		return
+	}
+
+	useGzip := query.Get("gzip") == "true" || acceptsGzip(r)
+
+	var (
+		out     io.Writer
+		gz      *gzip.Writer
+		enc     exportEncoder
+		rows    int
+		started bool
+	)
+	flusher, _ := w.(http.Flusher)
+
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", exportContentType(format))
+		w.Header().Set("Content-Disposition", `attachment; filename="users.`+format+`"`)
+		w.Header().Add("Vary", "Accept-Encoding")
+		out = w
+		if useGzip {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz = gzip.NewWriter(w)
+			out = gz
+		}
+		w.WriteHeader(http.StatusOK)
+		enc = newExportEncoder(format, out, fields)
+		return enc.begin()
+	}
+
+	err = h.userService.ExportUsers(filter, func(user *domain.User) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := enc.write(user); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushEvery == 0 {
+			if err := enc.flush(); err != nil {
+				return err
+			}
+			if gz != nil {
+				gz.Flush()
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+
+	if err != nil && !started {
+		if writeAttributeError(w, r, err) {
+			return
+		}
+		if err == errors.ErrInvalidInput {
+			writeError(w, r, http.StatusBadRequest, err, "Invalid filter")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, err, "Failed to export users")
+		return
+	}
+	if err != nil {
+		log.Printf("User export aborted after %d rows: %v", rows, err)
+		return
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			return
+		}
+	}
+	if err := enc.end(); err != nil {
+		return
+	}
+	if gz != nil {
+		gz.Close()
+	}
+}
+
+// attributeParamPrefix marks the query parameters that filter on custom
+// attributes, as in ?attr.department=Sales.
+const attributeParamPrefix = "attr."
+
+type invalidParamError string
+
+func (e invalidParamError) Error() string {
+	return "Invalid " + string(e) + " parameter"
+}
+
+func parseUserFilter(r *http.Request) (domain.UserFilter, error) {
+	query := r.URL.Query()
+
+	filter := domain.UserFilter{
+		NameContains:  query.Get("name_contains"),
+		EmailContains: query.Get("email_contains"),
+	}
+
+	// status=suspended,deactivated matches users in either status.
+	if statuses := strings.Split(query.Get("status"), ","); len(statuses) > 1 {
+		filter.Statuses = statuses
+	} else {
+		filter.Status = statuses[0]
+	}
+
+	for param, dst := range map[string]**time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+	} {
+		if v := query.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, invalidParamError(param)
+			}
+			*dst = &t
+		}
+	}
+
+	for param, values := range query {
+		if name, ok := strings.CutPrefix(param, attributeParamPrefix); ok {
+			if filter.Attributes == nil {
+				filter.Attributes = domain.Attributes{}
+			}
+			filter.Attributes[name] = values[0]
+		}
+	}
+
+	for param, dst := range map[string]*int{
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	} {
+		if v := query.Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return filter, invalidParamError(param)
+			}
+			*dst = n
+		}
+	}
+
+	return filter, nil
+}
+
+func parseExportFields(value string) ([]exportField, error) {
+	if value == "" {
+		return exportFields, nil
+	}
+
+	var fields []exportField
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, f := range exportFields {
+			if f.name == name {
+				fields = append(fields, f)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, invalidParamError("fields")
+		}
+	}
+	return fields, nil
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		if strings.TrimSpace(coding) != "gzip" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			weight, err := strconv.ParseFloat(q, 64)
+			return err == nil && weight > 0
+		}
+		return true
+	}
+	return false
+}
+
+func exportContentType(format string) string {
+	switch format {
+	case exportFormatNDJSON:
+		return "application/x-ndjson"
+	case exportFormatJSON:
+		return "application/json"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+type exportEncoder interface {
+	begin() error
+	write(user *domain.User) error
+	flush() error
+	end() error
+}
+
+func newExportEncoder(format string, w io.Writer, fields []exportField) exportEncoder {
+	switch format {
+	case exportFormatNDJSON:
+		return &jsonExportEncoder{w: w, fields: fields}
+	case exportFormatJSON:
+		return &jsonExportEncoder{w: w, fields: fields, array: true}
+	}
+	return &csvExportEncoder{w: csv.NewWriter(w), fields: fields}
+}
+
+type csvExportEncoder struct {
+	w      *csv.Writer
+	fields []exportField
+}
+
+func (e *csvExportEncoder) begin() error {
+	header := make([]string, len(e.fields))
+	for i, f := range e.fields {
+		header[i] = f.name
+	}
+	return e.w.Write(header)
+}
+
+func (e *csvExportEncoder) write(user *domain.User) error {
+	record := make([]string, len(e.fields))
+	for i, f := range e.fields {
+		switch v := f.value(user).(type) {
+		case string:
+			record[i] = v
+		case *string:
+			if v != nil {
+				record[i] = *v
+			}
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case bool:
+			record[i] = strconv.FormatBool(v)
+		case domain.Attributes:
+			// CSV has no nesting, so attributes go into one cell as JSON.
+			if len(v) > 0 {
+				raw, err := json.Marshal(v)
+				if err != nil {
+					return err
+				}
+				record[i] = string(raw)
+			}
+		}
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExportEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExportEncoder) end() error {
+	return e.flush()
+}
+
+// jsonExportEncoder writes objects with keys in field order, either one per
+// line or as the elements of a single array.
+type jsonExportEncoder struct {
+	w      io.Writer
+	fields []exportField
+	array  bool
+	buf    bytes.Buffer
+	count  int
+}
+
+func (e *jsonExportEncoder) begin() error {
+	if e.array {
+		_, err := io.WriteString(e.w, "[")
+		return err
+	}
+	return nil
+}
+
+func (e *jsonExportEncoder) write(user *domain.User) error {
+	e.buf.Reset()
+	if e.array && e.count > 0 {
+		e.buf.WriteByte(',')
+	}
+	e.buf.WriteByte('{')
+	for i, f := range e.fields {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.name)
+		value, err := json.Marshal(f.value(user))
+		if err != nil {
+			return err
+		}
+		e.buf.Write(key)
+		e.buf.WriteByte(':')
+		e.buf.Write(value)
+	}
+	e.buf.WriteByte('}')
+	if !e.array {
+		e.buf.WriteByte('\n')
+	}
+	e.count++
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+func (e *jsonExportEncoder) flush() error {
+	return nil
+}
+
+func (e *jsonExportEncoder) end() error {
+	if e.array {
+		_, err := io.WriteString(e.w, "]\n")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func exportTestUsers() []*domain.User {
+	verified := "2024-01-02T00:00:00Z"
+	return []*domain.User{
+		{ID: 1, Name: "John Doe", Email: "john@example.com", EmailVerifiedAt: &verified, CreatedAt: "2024-01-01T00:00:00Z", UpdatedAt: "2024-01-01T00:00:00Z"},
+		{ID: 2, Name: "Jane, Jr.", Email: "jane@example.com", MFAEnabled: true, CreatedAt: "2024-01-03T00:00:00Z", UpdatedAt: "2024-01-03T00:00:00Z",
+			Status: domain.UserStatusSuspended, StatusReason: "chargeback", StatusChangedAt: &verified, Attributes: domain.Attributes{"department": "IT", "level": int64(3)}},
+	}
+}
+
+func TestListUsersHandler(t *testing.T) {
+	t.Run("filters", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		filter := domain.UserFilter{EmailContains: "example", CreatedAfter: &after, Limit: 10, Offset: 20}
+		mockService.On("ListUsers", filter).Return(exportTestUsers(), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/users?email_contains=example&created_after=2024-01-01T00:00:00Z&limit=10&offset=20", nil)
+		w := httptest.NewRecorder()
+		handler.ListUsers(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp listUsersResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Len(t, resp.Users, 2)
+		assert.Equal(t, 10, resp.Limit)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("attribute filters", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		mockService.On("ListUsers", domain.UserFilter{Attributes: domain.Attributes{"department": "Sales", "level": "3"}}).
+			Return(nil, &errors.AttributeError{Name: "level", Reason: "must be an integer"})
+
+		req := httptest.NewRequest(http.MethodGet, "/users?attr.department=Sales&attr.level=3", nil)
+		w := httptest.NewRecorder()
+		handler.ListUsers(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var resp ErrorResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, errors.ErrInvalidAttribute.Error(), resp.Error)
+		assert.Equal(t, "attribute level: must be an integer", resp.Message)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		handler := NewUserHandler(new(MockUserService))
+
+		req := httptest.NewRequest(http.MethodGet, "/users?limit=-1", nil)
+		w := httptest.NewRecorder()
+		handler.ListUsers(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestExportUsersHandler(t *testing.T) {
+	t.Run("csv with selected fields", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+		mockService.On("ExportUsers", domain.UserFilter{}).Return(exportTestUsers(), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/export?fields=id,name,email_verified_at", nil)
+		w := httptest.NewRecorder()
+		handler.ExportUsers(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "id,name,email_verified_at\n1,John Doe,2024-01-02T00:00:00Z\n2,\"Jane, Jr.\",\n", w.Body.String())
+	})
+
+	t.Run("csv status and attributes", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+		mockService.On("ExportUsers", domain.UserFilter{}).Return(exportTestUsers(), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/export?fields=id,status_reason,status_changed_at,attributes", nil)
+		w := httptest.NewRecorder()
+		handler.ExportUsers(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "id,status_reason,status_changed_at,attributes\n1,,,\n2,chargeback,2024-01-02T00:00:00Z,\"{\"\"department\"\":\"\"IT\"\",\"\"level\"\":3}\"\n", w.Body.String())
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+		mockService.On("ExportUsers", domain.UserFilter{}).Return(exportTestUsers(), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/export?format=ndjson&fields=email,mfa_enabled,attributes", nil)
+		w := httptest.NewRecorder()
+		handler.ExportUsers(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "{\"email\":\"john@example.com\",\"mfa_enabled\":false,\"attributes\":null}\n{\"email\":\"jane@example.com\",\"mfa_enabled\":true,\"attributes\":{\"department\":\"IT\",\"level\":3}}\n", w.Body.String())
+	})
+
+	t.Run("gzipped json", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+		mockService.On("ExportUsers", domain.UserFilter{}).Return(exportTestUsers(), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/export?format=json&fields=id", nil)
+		req.Header.Set("Accept-Encoding", "br;q=1.0, gzip;q=0.5")
+		w := httptest.NewRecorder()
+		handler.ExportUsers(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		gz, err := gzip.NewReader(w.Body)
+		assert.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		assert.NoError(t, err)
+		assert.Equal(t, "[{\"id\":1},{\"id\":2}]\n", string(body))
+	})
+
+	t.Run("empty json export", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+		mockService.On("ExportUsers", mock.Anything).Return(nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/export?format=json&name_contains=nobody", nil)
+		w := httptest.NewRecorder()
+		handler.ExportUsers(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "[]\n", w.Body.String())
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		handler := NewUserHandler(new(MockUserService))
+
+		req := httptest.NewRequest(http.MethodGet, "/users/export?fields=id,password", nil)
+		w := httptest.NewRecorder()
+		handler.ExportUsers(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		handler := NewUserHandler(new(MockUserService))
+
+		req := httptest.NewRequest(http.MethodGet, "/users/export?format=xlsx", nil)
+		w := httptest.NewRecorder()
+		handler.ExportUsers(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+type GroupService interface {
+	CreateGroup(group *domain.Group) error
+	GetGroup(id int64) (*domain.Group, error)
+	ListGroups(filter domain.GroupFilter) ([]*domain.Group, error)
+	UpdateGroup(group *domain.Group) error
+	DeleteGroup(id int64) error
+	GetMember(groupID, userID int64) (*domain.GroupMember, error)
+	AddMember(groupID, userID int64) (*domain.GroupMember, bool, error)
+	RemoveMember(groupID, userID int64) error
+	ListMembers(groupID int64, filter domain.GroupFilter) ([]*domain.User, error)
+	ListUserGroups(userID int64) ([]*domain.UserGroup, error)
+}
+
+type GroupHandler struct {
+	groupService GroupService
+}
+
+func NewGroupHandler(groupService GroupService) *GroupHandler {
+	return &GroupHandler{groupService: groupService}
+}
+
+// groupRequest mirrors the writable group fields.
+type groupRequest struct {
+	Name        string `json:"name" xml:"name"`
+	Description string `json:"description" xml:"description"`
+	ParentID    *int64 `json:"parent_id" xml:"parent_id"`
+}
+
+type groupListResponse struct {
+	XMLName xml.Name        `json:"-" xml:"group_list"`
+	Groups  []*domain.Group `json:"groups" xml:"groups>group"`
+	Limit   int             `json:"limit" xml:"limit"`
+	Offset  int             `json:"offset" xml:"offset"`
+}
+
+type userGroupListResponse struct {
+	XMLName xml.Name            `json:"-" xml:"user_group_list"`
+	Groups  []*domain.UserGroup `json:"groups" xml:"groups>group"`
+}
+
+func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req groupRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	group := &domain.Group{Name: req.Name, Description: req.Description, ParentID: req.ParentID}
+	if err := h.groupService.CreateGroup(group); err != nil {
+		writeGroupError(w, r, err, "Failed to create group")
+		return
+	}
+
+	writeResponse(w, r, http.StatusCreated, group)
+}
+
+func (h *GroupHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	group, err := h.groupService.GetGroup(id)
+	if err != nil {
+		writeGroupError(w, r, err, "Failed to get group")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, group)
+}
+
+func (h *GroupHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseGroupFilter(w, r)
+	if !ok {
+		return
+	}
+
+	groups, err := h.groupService.ListGroups(filter)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err, "Failed to list groups")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, groupListResponse{Groups: groups, Limit: filter.Limit, Offset: filter.Offset})
+}
+
+func (h *GroupHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	var req groupRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	group := &domain.Group{ID: id, Name: req.Name, Description: req.Description, ParentID: req.ParentID}
+	if err := h.groupService.UpdateGroup(group); err != nil {
+		writeGroupError(w, r, err, "Failed to update group")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, group)
+}
+
+func (h *GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+
+	if err := h.groupService.DeleteGroup(id); err != nil {
+		writeGroupError(w, r, err, "Failed to delete group")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *GroupHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid group ID")
+	if !ok {
+		return
+	}
+	filter, ok := parseGroupFilter(w, r)
+	if !ok {
+		return
+	}
+
+	users, err := h.groupService.ListMembers(id, filter)
+	if err != nil {
+		writeGroupError(w, r, err, "Failed to list group members")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, listUsersResponse{Users: users, Limit: filter.Limit, Offset: filter.Offset})
+}
+
+func (h *GroupHandler) GetMember(w http.ResponseWriter, r *http.Request) {
+	groupID, userID, ok := memberPath(w, r)
+	if !ok {
+		return
+	}
+
+	member, err := h.groupService.GetMember(groupID, userID)
+	if err != nil {
+		writeGroupError(w, r, err, "Failed to get group member")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, member)
+}
+
+// AddMember answers 201 when the user joins the group and 200 when they
+// already were a direct member.
+func (h *GroupHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	groupID, userID, ok := memberPath(w, r)
+	if !ok {
+		return
+	}
+
+	member, added, err := h.groupService.AddMember(groupID, userID)
+	if err != nil {
+		writeGroupError(w, r, err, "Failed to add group member")
+		return
+	}
+
+	status := http.StatusOK
+	if added {
+		status = http.StatusCreated
+	}
+	writeResponse(w, r, status, member)
+}
+
+func (h *GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	groupID, userID, ok := memberPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.groupService.RemoveMember(groupID, userID); err != nil {
+		writeGroupError(w, r, err, "Failed to remove group member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListUserGroups serves GET /users/{id}/groups.
+func (h *GroupHandler) ListUserGroups(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	groups, err := h.groupService.ListUserGroups(id)
+	if err != nil {
+		writeGroupError(w, r, err, "Failed to list user groups")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, userGroupListResponse{Groups: groups})
+}
+
+func pathID(w http.ResponseWriter, r *http.Request, name, message string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, message)
+		return 0, false
+	}
+	return id, true
+}
+
+func memberPath(w http.ResponseWriter, r *http.Request) (groupID, userID int64, ok bool) {
+	if groupID, ok = pathID(w, r, "id", "Invalid group ID"); !ok {
+		return 0, 0, false
+	}
+	if userID, ok = pathID(w, r, "userId", "Invalid user ID"); !ok {
+		return 0, 0, false
+	}
+	return groupID, userID, true
+}
+
+func parseGroupFilter(w http.ResponseWriter, r *http.Request) (domain.GroupFilter, bool) {
+	query := r.URL.Query()
+	var filter domain.GroupFilter
+	for param, dst := range map[string]*int{
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	} {
+		if v := query.Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, invalidParamError(param).Error())
+				return filter, false
+			}
+			*dst = n
+		}
+	}
+	return filter, true
+}
+
+func writeGroupError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch err {
+	case errors.ErrInvalidInput:
+		writeError(w, r, http.StatusBadRequest, err, "Name is required and limited to 255 characters, description to 1000")
+	case errors.ErrGroupNotFound:
+		writeError(w, r, http.StatusNotFound, err, "Group or parent group not found")
+	case errors.ErrUserNotFound:
+		writeError(w, r, http.StatusNotFound, err, "User not found")
+	case errors.ErrMemberNotFound:
+		writeError(w, r, http.StatusNotFound, err, "User is not a member of the group")
+	case errors.ErrGroupExists:
+		writeError(w, r, http.StatusConflict, err, "Group name already in use")
+	case errors.ErrGroupCycle:
+		writeError(w, r, http.StatusConflict, err, "A group cannot be nested in itself or its subgroups")
+	case errors.ErrGroupHasSubgroups:
+		writeError(w, r, http.StatusConflict, err, "Move or delete the subgroups first")
+	default:
+		writeError(w, r, http.StatusInternalServerError, err, message)
+	}
+}
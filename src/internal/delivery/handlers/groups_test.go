@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockGroupService struct {
+	mock.Mock
+}
+
+func (m *MockGroupService) CreateGroup(group *domain.Group) error {
+	args := m.Called(group)
+	return args.Error(0)
+}
+
+func (m *MockGroupService) GetGroup(id int64) (*domain.Group, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Group), args.Error(1)
+}
+
+func (m *MockGroupService) ListGroups(filter domain.GroupFilter) ([]*domain.Group, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Group), args.Error(1)
+}
+
+func (m *MockGroupService) UpdateGroup(group *domain.Group) error {
+	args := m.Called(group)
+	return args.Error(0)
+}
+
+func (m *MockGroupService) DeleteGroup(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockGroupService) GetMember(groupID, userID int64) (*domain.GroupMember, error) {
+	args := m.Called(groupID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.GroupMember), args.Error(1)
+}
+
+func (m *MockGroupService) AddMember(groupID, userID int64) (*domain.GroupMember, bool, error) {
+	args := m.Called(groupID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*domain.GroupMember), args.Bool(1), args.Error(2)
+}
+
+func (m *MockGroupService) RemoveMember(groupID, userID int64) error {
+	args := m.Called(groupID, userID)
+	return args.Error(0)
+}
+
+func (m *MockGroupService) ListMembers(groupID int64, filter domain.GroupFilter) ([]*domain.User, error) {
+	args := m.Called(groupID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockGroupService) ListUserGroups(userID int64) ([]*domain.UserGroup, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UserGroup), args.Error(1)
+}
+
+func TestGroupHandler(t *testing.T) {
+	mockService := new(MockGroupService)
+	handler := NewGroupHandler(mockService)
+
+	t.Run("create", func(t *testing.T) {
+		parentID := int64(1)
+		mockService.On("CreateGroup", &domain.Group{Name: "Backend", ParentID: &parentID}).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(0).(*domain.Group).ID = 2
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/groups", bytes.NewBufferString(`{"name":"Backend","parent_id":1}`))
+		w := httptest.NewRecorder()
+		handler.CreateGroup(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response domain.Group
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, int64(2), response.ID)
+		assert.Equal(t, &parentID, response.ParentID)
+	})
+
+	t.Run("nested in itself", func(t *testing.T) {
+		mockService.On("UpdateGroup", mock.MatchedBy(func(g *domain.Group) bool { return g.ID == 1 })).Return(errors.ErrGroupCycle)
+
+		req := httptest.NewRequest(http.MethodPut, "/groups/1", bytes.NewBufferString(`{"name":"Engineering","parent_id":2}`))
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+		handler.UpdateGroup(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("add member", func(t *testing.T) {
+		mockService.On("AddMember", int64(2), int64(7)).Return(&domain.GroupMember{GroupID: 2, UserID: 7, Direct: true}, true, nil).Once()
+		mockService.On("AddMember", int64(2), int64(7)).Return(&domain.GroupMember{GroupID: 2, UserID: 7, Direct: true}, false, nil).Once()
+
+		for _, want := range []int{http.StatusCreated, http.StatusOK} {
+			req := httptest.NewRequest(http.MethodPut, "/groups/2/members/7", nil)
+			req.SetPathValue("id", "2")
+			req.SetPathValue("userId", "7")
+			w := httptest.NewRecorder()
+			handler.AddMember(w, req)
+
+			assert.Equal(t, want, w.Code)
+		}
+	})
+
+	t.Run("remove indirect member", func(t *testing.T) {
+		mockService.On("RemoveMember", int64(1), int64(7)).Return(errors.ErrMemberNotFound)
+
+		req := httptest.NewRequest(http.MethodDelete, "/groups/1/members/7", nil)
+		req.SetPathValue("id", "1")
+		req.SetPathValue("userId", "7")
+		w := httptest.NewRecorder()
+		handler.RemoveMember(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("groups of a user", func(t *testing.T) {
+		mockService.On("ListUserGroups", int64(7)).Return([]*domain.UserGroup{
+			{Group: domain.Group{ID: 1, Name: "Engineering"}},
+			{Group: domain.Group{ID: 2, Name: "Backend"}, Direct: true},
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/7/groups", nil)
+		req.SetPathValue("id", "7")
+		w := httptest.NewRecorder()
+		handler.ListUserGroups(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Groups []struct {
+				ID     int64 `json:"id"`
+				Direct bool  `json:"direct"`
+			} `json:"groups"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if assert.Len(t, response.Groups, 2) {
+			assert.False(t, response.Groups[0].Direct)
+			assert.True(t, response.Groups[1].Direct)
+		}
+	})
+
+	t.Run("invalid member id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/groups/1/members/abc", nil)
+		req.SetPathValue("id", "1")
+		req.SetPathValue("userId", "abc")
+		w := httptest.NewRecorder()
+		handler.GetMember(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"encoding/json"
+	stdErrors "errors"
 	"net/http"
 	"strconv"
 	"users-api/src/internal/domain"
@@ -11,8 +11,15 @@ import (
 type UserService interface {
 	CreateUser(user *domain.User) error
 	GetUser(id int64) (*domain.User, error)
+	GetUserByEmail(address string) (*domain.User, error)
+	ListUsers(filter domain.UserFilter) ([]*domain.User, error)
+	ExportUsers(filter domain.UserFilter, fn func(user *domain.User) error) error
+	SearchUsers(query string, limit int) ([]*domain.UserSearchResult, error)
 	UpdateUser(user *domain.User) error
 	DeleteUser(id int64) error
+	ChangeStatus(id int64, status, reason string) (*domain.User, error)
+	VerifyEmail(token string) (*domain.User, error)
+	Batch(ops []domain.BatchOperation, atomic bool) ([]domain.BatchResult, error)
 }
 
 type UserHandler struct {
@@ -25,67 +32,142 @@ func NewUserHandler(userService UserService) *UserHandler {
 
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var user domain.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		writeError(w, http.StatusBadRequest, err, "Invalid request body")
+	if !decodeRequest(w, r, &user) {
 		return
 	}
 
 	if err := h.userService.CreateUser(&user); err != nil {
+		if writeAttributeError(w, r, err) {
+			return
+		}
 		switch err {
 		case errors.ErrInvalidInput:
-			writeError(w, http.StatusBadRequest, err, "Invalid input data")
+			writeError(w, r, http.StatusBadRequest, err, "Invalid input data")
 		case errors.ErrInvalidEmail:
-			writeError(w, http.StatusBadRequest, err, "Invalid email format")
+			writeError(w, r, http.StatusBadRequest, err, "Invalid email format")
+		case errors.ErrEmailRejected:
+			writeError(w, r, http.StatusUnprocessableEntity, err, "Email address not accepted")
+		case errors.ErrEmailTaken:
+			writeError(w, r, http.StatusConflict, err, "Email already in use")
 		default:
-			writeError(w, http.StatusInternalServerError, err, "Failed to create user")
+			writeError(w, r, http.StatusInternalServerError, err, "Failed to create user")
 		}
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, user)
+	writeResponse(w, r, http.StatusCreated, user)
 }
 
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err, "Invalid user ID")
+		writeError(w, r, http.StatusBadRequest, err, "Invalid user ID")
 		return
 	}
 
 	user, err := h.userService.GetUser(id)
 	if err != nil {
 		if err == errors.ErrUserNotFound {
-			writeError(w, http.StatusNotFound, err, "User not found")
+			writeError(w, r, http.StatusNotFound, err, "User not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err, "Failed to get user")
+		writeError(w, r, http.StatusInternalServerError, err, "Failed to get user")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, user)
+	writeResponse(w, r, http.StatusOK, user)
+}
+
+// GetUserByEmail looks a user up by ?email=, ignoring case and the other
+// differences UserService normalizes away.
+func (h *UserHandler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userService.GetUserByEmail(r.URL.Query().Get("email"))
+	if err != nil {
+		switch err {
+		case errors.ErrInvalidEmail:
+			writeError(w, r, http.StatusBadRequest, err, "Invalid email format")
+		case errors.ErrUserNotFound:
+			writeError(w, r, http.StatusNotFound, err, "User not found")
+		default:
+			writeError(w, r, http.StatusInternalServerError, err, "Failed to get user")
+		}
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, user)
 }
 
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	var user domain.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		writeError(w, http.StatusBadRequest, err, "Invalid request body")
+	if !decodeRequest(w, r, &user) {
 		return
 	}
 
 	if err := h.userService.UpdateUser(&user); err != nil {
+		if writeAttributeError(w, r, err) {
+			return
+		}
 		switch err {
 		case errors.ErrInvalidInput:
-			writeError(w, http.StatusBadRequest, err, "Invalid input data")
+			writeError(w, r, http.StatusBadRequest, err, "Invalid input data")
 		case errors.ErrUserNotFound:
-			writeError(w, http.StatusNotFound, err, "User not found")
+			writeError(w, r, http.StatusNotFound, err, "User not found")
 		case errors.ErrInvalidEmail:
-			writeError(w, http.StatusBadRequest, err, "Invalid email format")
+			writeError(w, r, http.StatusBadRequest, err, "Invalid email format")
+		case errors.ErrEmailRejected:
+			writeError(w, r, http.StatusUnprocessableEntity, err, "Email address not accepted")
+		case errors.ErrEmailTaken:
+			writeError(w, r, http.StatusConflict, err, "Email already in use")
+		case errors.ErrInvalidStatusTransition:
+			writeError(w, r, http.StatusConflict, err, "User cannot move from its current status to "+user.Status)
+		default:
+			writeError(w, r, http.StatusInternalServerError, err, "Failed to update user")
+		}
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, user)
+}
+
+// writeAttributeError reports a custom attribute that failed validation,
+// naming it in the message. It returns false for any other error.
+func writeAttributeError(w http.ResponseWriter, r *http.Request, err error) bool {
+	if !stdErrors.Is(err, errors.ErrInvalidAttribute) {
+		return false
+	}
+	writeError(w, r, http.StatusBadRequest, errors.ErrInvalidAttribute, err.Error())
+	return true
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" xml:"token"`
+}
+
+// VerifyEmail takes the token from the body of a POST or, for the link in
+// the verification email, from the query of a GET.
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if r.Method == http.MethodGet {
+		req.Token = r.URL.Query().Get("token")
+	} else if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	user, err := h.userService.VerifyEmail(req.Token)
+	if err != nil {
+		switch err {
+		case errors.ErrInvalidToken:
+			writeError(w, r, http.StatusBadRequest, err, "Invalid verification token")
+		case errors.ErrTokenExpired:
+			writeError(w, r, http.StatusGone, err, "Verification token expired")
+		case errors.ErrUserNotFound:
+			writeError(w, r, http.StatusNotFound, err, "User not found")
 		default:
-			writeError(w, http.StatusInternalServerError, err, "Failed to update user")
+			writeError(w, r, http.StatusInternalServerError, err, "Failed to verify email")
 		}
 		return
 	}
 
-	writeJSON(w, http.StatusOK, user)
+	writeResponse(w, r, http.StatusOK, user)
 }
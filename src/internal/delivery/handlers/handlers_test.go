@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"testing"
 	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
 	"users-api/src/internal/service"
 
 	"github.com/stretchr/testify/assert"
@@ -30,6 +31,42 @@ func (m *MockUserService) GetUser(id int64) (*domain.User, error) {
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *MockUserService) GetUserByEmail(address string) (*domain.User, error) {
+	args := m.Called(address)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserService) ListUsers(filter domain.UserFilter) ([]*domain.User, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockUserService) ExportUsers(filter domain.UserFilter, fn func(user *domain.User) error) error {
+	args := m.Called(filter)
+	if users, ok := args.Get(0).([]*domain.User); ok {
+		for _, user := range users {
+			if err := fn(user); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockUserService) SearchUsers(query string, limit int) ([]*domain.UserSearchResult, error) {
+	args := m.Called(query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UserSearchResult), args.Error(1)
+}
+
 func (m *MockUserService) UpdateUser(user *domain.User) error {
 	args := m.Called(user)
 	return args.Error(0)
@@ -40,6 +77,30 @@ func (m *MockUserService) DeleteUser(id int64) error {
 	return args.Error(0)
 }
 
+func (m *MockUserService) ChangeStatus(id int64, status, reason string) (*domain.User, error) {
+	args := m.Called(id, status, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserService) Batch(ops []domain.BatchOperation, atomic bool) ([]domain.BatchResult, error) {
+	args := m.Called(ops, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.BatchResult), args.Error(1)
+}
+
+func (m *MockUserService) VerifyEmail(token string) (*domain.User, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
 func TestCreateUser(t *testing.T) {
 	mockService := new(MockUserService)
 	handler := NewUserHandler(mockService)
@@ -70,6 +131,42 @@ func TestCreateUser(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		user := &domain.User{
+			Name:  "Jane Doe",
+			Email: "jane@example.com",
+		}
+
+		mockService.On("CreateUser", user).Return(errors.ErrEmailTaken)
+
+		body, _ := json.Marshal(user)
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.CreateUser(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rejected email", func(t *testing.T) {
+		user := &domain.User{
+			Name:  "Spam",
+			Email: "spam@mailinator.com",
+		}
+
+		mockService.On("CreateUser", user).Return(errors.ErrEmailRejected)
+
+		body, _ := json.Marshal(user)
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.CreateUser(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertExpectations(t)
+	})
 }
 
 func TestGetUser(t *testing.T) {
@@ -122,6 +219,34 @@ func TestGetUser(t *testing.T) {
 	})
 }
 
+func TestGetUserByEmail(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	user := &domain.User{ID: 1, Name: "John Doe", Email: "john@example.com"}
+	mockService.On("GetUserByEmail", "John@Example.com").Return(user, nil)
+	mockService.On("GetUserByEmail", "nobody@example.com").Return(nil, errors.ErrUserNotFound)
+	mockService.On("GetUserByEmail", "nope").Return(nil, errors.ErrInvalidEmail)
+
+	tests := []struct {
+		query  string
+		status int
+	}{
+		{"email=John%40Example.com", http.StatusOK},
+		{"email=nobody%40example.com", http.StatusNotFound},
+		{"email=nope", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/users?"+tt.query, nil)
+		w := httptest.NewRecorder()
+
+		handler.GetUserByEmail(w, req)
+
+		assert.Equal(t, tt.status, w.Code, tt.query)
+	}
+	mockService.AssertExpectations(t)
+}
+
 func TestUpdateUser(t *testing.T) {
 	mockService := new(MockUserService)
 	handler := NewUserHandler(mockService)
@@ -173,3 +298,67 @@ func TestUpdateUser(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 }
+
+func TestVerifyEmail(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	t.Run("valid token", func(t *testing.T) {
+		verifiedAt := "2025-03-21T13:45:30Z"
+		user := &domain.User{
+			ID:              1,
+			Name:            "John Doe",
+			Email:           "john@example.com",
+			EmailVerifiedAt: &verifiedAt,
+		}
+
+		mockService.On("VerifyEmail", "good-token").Return(user, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/verify-email", bytes.NewBufferString(`{"token":"good-token"}`))
+		w := httptest.NewRecorder()
+
+		handler.VerifyEmail(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+
+		var response domain.User
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, &verifiedAt, response.EmailVerifiedAt)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		mockService.On("VerifyEmail", "bad-token").Return(nil, errors.ErrInvalidToken)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/verify-email", bytes.NewBufferString(`{"token":"bad-token"}`))
+		w := httptest.NewRecorder()
+
+		handler.VerifyEmail(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		mockService.On("VerifyEmail", "old-token").Return(nil, errors.ErrTokenExpired)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/verify-email", bytes.NewBufferString(`{"token":"old-token"}`))
+		w := httptest.NewRecorder()
+
+		handler.VerifyEmail(w, req)
+
+		assert.Equal(t, http.StatusGone, w.Code)
+		mockService.AssertExpectations(t)
+	})
+	t.Run("link from the email", func(t *testing.T) {
+		mockService.On("VerifyEmail", "link-token").Return(&domain.User{ID: 1, Name: "John Doe", Email: "john@example.com"}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/verify-email?token=link-token", nil)
+		w := httptest.NewRecorder()
+
+		handler.VerifyEmail(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"users-api/src/internal/errors"
+	"users-api/src/internal/importer"
+)
+
+type UserImporter interface {
+	Run(r io.Reader, opts importer.Options, onRow func(importer.RowResult) error) (*importer.Summary, error)
+}
+
+type ImportHandler struct {
+	importer UserImporter
+}
+
+func NewImportHandler(importer UserImporter) *ImportHandler {
+	return &ImportHandler{importer: importer}
+}
+
+type importSummaryLine struct {
+	Summary *importer.Summary `json:"summary"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// Import streams the request body through the importer and writes one NDJSON
+// line per processed row followed by a summary line, so that clients can
+// follow progress and resume from summary.last_row after an interruption.
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	opts := importer.Options{
+		Format: importFormat(r),
+		DryRun: query.Get("dry_run") == "true",
+		Upsert: query.Get("upsert") == "true",
+	}
+
+	if skip := query.Get("skip"); skip != "" {
+		n, err := strconv.Atoi(skip)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, "Invalid skip parameter")
+			return
+		}
+		opts.Skip = n
+	}
+
+	if m := query.Get("map"); m != "" {
+		mapping, err := importer.ParseMapping(m)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err, "Invalid column mapping")
+			return
+		}
+		opts.Mapping = mapping
+	}
+
+	if opts.Format != importer.FormatCSV && opts.Format != importer.FormatNDJSON {
+		writeError(w, r, http.StatusUnsupportedMediaType, errors.ErrInvalidInput, "Format must be csv or ndjson")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	summary, err := h.importer.Run(r.Body, opts, func(row importer.RowResult) error {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	line := importSummaryLine{Summary: summary}
+	if err != nil {
+		line.Error = err.Error()
+	}
+	encoder.Encode(line)
+}
+
+func importFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return importer.FormatCSV
+	case "application/x-ndjson", "application/ndjson":
+		return importer.FormatNDJSON
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/importer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubUserImporter struct{}
+
+func (stubUserImporter) ImportUser(user *domain.User, opts domain.ImportOptions) (string, error) {
+	user.ID = 1
+	if opts.DryRun {
+		return domain.ImportStatusWouldCreate, nil
+	}
+	return domain.ImportStatusCreated, nil
+}
+
+func TestImport(t *testing.T) {
+	handler := NewImportHandler(importer.New(stubUserImporter{}))
+
+	t.Run("csv dry run streams per-row report", func(t *testing.T) {
+		body := "name,email\nJohn Doe,john@example.com\n"
+		req := httptest.NewRequest(http.MethodPost, "/users/import?dry_run=true", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+
+		handler.Import(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+		scanner := bufio.NewScanner(w.Body)
+		assert.True(t, scanner.Scan())
+		var row importer.RowResult
+		json.Unmarshal(scanner.Bytes(), &row)
+		assert.Equal(t, domain.ImportStatusWouldCreate, row.Status)
+
+		assert.True(t, scanner.Scan())
+		var summary importSummaryLine
+		json.Unmarshal(scanner.Bytes(), &summary)
+		assert.Equal(t, 1, summary.Summary.WouldCreate)
+		assert.Equal(t, 1, summary.Summary.LastRow)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users/import", bytes.NewBufferString("x"))
+		w := httptest.NewRecorder()
+
+		handler.Import(w, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("invalid skip", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users/import?format=csv&skip=-1", bytes.NewBufferString("x"))
+		w := httptest.NewRecorder()
+
+		handler.Import(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
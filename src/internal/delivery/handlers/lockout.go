@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"users-api/src/internal/errors"
+)
+
+type LockoutService interface {
+	Unlock(userID int64, ip string) error
+}
+
+type LockoutHandler struct {
+	lockoutService LockoutService
+}
+
+func NewLockoutHandler(lockoutService LockoutService) *LockoutHandler {
+	return &LockoutHandler{lockoutService: lockoutService}
+}
+
+type unlockRequest struct {
+	UserID int64  `json:"user_id,omitempty" xml:"user_id,omitempty"`
+	IP     string `json:"ip,omitempty" xml:"ip,omitempty"`
+}
+
+func (h *LockoutHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	var req unlockRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.lockoutService.Unlock(req.UserID, req.IP); err != nil {
+		if err == errors.ErrInvalidInput {
+			writeError(w, r, http.StatusBadRequest, err, "Specify a user ID, a client IP or both")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, err, "Failed to unlock user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/xml"
+	stdErrors "errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+	"users-api/src/internal/delivery/middleware"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+type MFAService interface {
+	Enroll(userID int64) (*domain.MFAEnrollment, error)
+	Confirm(userID int64, code, clientIP string) ([]string, error)
+	Verify(userID int64, code, clientIP string) error
+	Disable(userID int64, code, clientIP string) error
+	RegenerateRecoveryCodes(userID int64, code, clientIP string) ([]string, error)
+}
+
+type MFAHandler struct {
+	mfaService MFAService
+}
+
+func NewMFAHandler(mfaService MFAService) *MFAHandler {
+	return &MFAHandler{mfaService: mfaService}
+}
+
+type mfaRequest struct {
+	UserID int64  `json:"user_id" xml:"user_id"`
+	Code   string `json:"code" xml:"code"`
+}
+
+type recoveryCodesResponse struct {
+	XMLName       xml.Name `json:"-" xml:"recovery_codes"`
+	RecoveryCodes []string `json:"recovery_codes" xml:"code"`
+}
+
+type mfaVerifyResponse struct {
+	XMLName  xml.Name `json:"-" xml:"mfa_verification"`
+	Verified bool     `json:"verified" xml:"verified"`
+}
+
+func (h *MFAHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeMFARequest(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.mfaService.Enroll(req.UserID)
+	if err != nil {
+		writeMFAError(w, r, err, "Failed to start MFA enrollment")
+		return
+	}
+
+	writeResponse(w, r, http.StatusCreated, enrollment)
+}
+
+func (h *MFAHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeMFARequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfaService.Confirm(req.UserID, req.Code, clientIP(r))
+	if err != nil {
+		writeMFAError(w, r, err, "Failed to confirm MFA enrollment")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *MFAHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeMFARequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.mfaService.Verify(req.UserID, req.Code, clientIP(r)); err != nil {
+		writeMFAError(w, r, err, "Failed to verify MFA code")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, mfaVerifyResponse{Verified: true})
+}
+
+func (h *MFAHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeMFARequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.mfaService.Disable(req.UserID, req.Code, clientIP(r)); err != nil {
+		writeMFAError(w, r, err, "Failed to disable MFA")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeMFARequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(req.UserID, req.Code, clientIP(r))
+	if err != nil {
+		writeMFAError(w, r, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func decodeMFARequest(w http.ResponseWriter, r *http.Request) (mfaRequest, bool) {
+	var req mfaRequest
+	if !decodeRequest(w, r, &req) {
+		return req, false
+	}
+	if req.UserID == 0 {
+		writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, "Invalid user ID")
+		return req, false
+	}
+	return req, true
+}
+
+func writeMFAError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	var locked *errors.LockedError
+	if stdErrors.As(err, &locked) {
+		retryAfter := int(time.Until(locked.Until).Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		writeError(w, r, http.StatusTooManyRequests, err, "Too many failed attempts, try again later")
+		return
+	}
+
+	switch err {
+	case errors.ErrUserNotFound:
+		writeError(w, r, http.StatusNotFound, err, "User not found")
+	case errors.ErrInvalidMFACode:
+		writeError(w, r, http.StatusUnauthorized, err, "Invalid MFA code")
+	case errors.ErrMFAAlreadyEnabled, errors.ErrMFANotEnabled, errors.ErrMFANotEnrolled:
+		writeError(w, r, http.StatusConflict, err, "MFA is in the wrong state for this operation")
+	default:
+		writeError(w, r, http.StatusInternalServerError, err, message)
+	}
+}
+
+func clientIP(r *http.Request) string {
+	if ip, ok := middleware.ClientIPFromContext(r.Context()); ok {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMFAService struct {
+	mock.Mock
+}
+
+func (m *MockMFAService) Enroll(userID int64) (*domain.MFAEnrollment, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MFAEnrollment), args.Error(1)
+}
+
+func (m *MockMFAService) Confirm(userID int64, code, clientIP string) ([]string, error) {
+	args := m.Called(userID, code, clientIP)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMFAService) Verify(userID int64, code, clientIP string) error {
+	args := m.Called(userID, code, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) Disable(userID int64, code, clientIP string) error {
+	args := m.Called(userID, code, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) RegenerateRecoveryCodes(userID int64, code, clientIP string) ([]string, error) {
+	args := m.Called(userID, code, clientIP)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func TestMFAEnroll(t *testing.T) {
+	mockService := new(MockMFAService)
+	handler := NewMFAHandler(mockService)
+
+	t.Run("valid user", func(t *testing.T) {
+		enrollment := &domain.MFAEnrollment{Secret: "JBSWY3DPEHPK3PXP", URI: "otpauth://totp/users-api:john@example.com"}
+		mockService.On("Enroll", int64(1)).Return(enrollment, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/mfa/enroll", bytes.NewBufferString(`{"user_id":1}`))
+		w := httptest.NewRecorder()
+
+		handler.Enroll(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response domain.MFAEnrollment
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, enrollment.URI, response.URI)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("missing user id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users/mfa/enroll", bytes.NewBufferString(`{}`))
+		w := httptest.NewRecorder()
+
+		handler.Enroll(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestMFAVerify(t *testing.T) {
+	mockService := new(MockMFAService)
+	handler := NewMFAHandler(mockService)
+
+	t.Run("valid code", func(t *testing.T) {
+		mockService.On("Verify", int64(1), "123456", "192.0.2.1").Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/mfa/verify", bytes.NewBufferString(`{"user_id":1,"code":"123456"}`))
+		w := httptest.NewRecorder()
+
+		handler.Verify(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		mockService.On("Verify", int64(1), "000000", "192.0.2.1").Return(errors.ErrInvalidMFACode)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/mfa/verify", bytes.NewBufferString(`{"user_id":1,"code":"000000"}`))
+		w := httptest.NewRecorder()
+
+		handler.Verify(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestMFAVerifyLocked(t *testing.T) {
+	mockService := new(MockMFAService)
+	handler := NewMFAHandler(mockService)
+
+	until := time.Now().Add(time.Minute)
+	mockService.On("Verify", int64(1), "123456", "192.0.2.1").Return(&errors.LockedError{Until: until})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/mfa/verify", bytes.NewBufferString(`{"user_id":1,"code":"123456"}`))
+	w := httptest.NewRecorder()
+
+	handler.Verify(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	mockService.AssertExpectations(t)
+}
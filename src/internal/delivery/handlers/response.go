@@ -1,27 +1,16 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
+	"users-api/src/internal/delivery/codec"
 )
 
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-}
+type ErrorResponse = codec.ErrorResponse
 
-func writeJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	codec.WriteResponse(w, r, status, data)
 }
 
-func writeError(w http.ResponseWriter, status int, err error, message string) {
-	errResponse := ErrorResponse{
-		Error:   err.Error(),
-		Code:    status,
-		Message: message,
-	}
-	writeJSON(w, status, errResponse)
+func writeError(w http.ResponseWriter, r *http.Request, status int, err error, message string) {
+	codec.WriteError(w, r, status, err.Error(), message)
 }
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+type searchUsersResponse struct {
+	XMLName xml.Name                   `json:"-" xml:"search_results"`
+	Query   string                     `json:"query" xml:"query"`
+	Results []*domain.UserSearchResult `json:"results" xml:"results>result"`
+}
+
+// SearchUsers finds users by a partial or misspelled name or email, best
+// match first.
+func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, invalidParamError("limit").Error())
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.userService.SearchUsers(query.Get("q"), limit)
+	if err != nil {
+		if err == errors.ErrInvalidInput {
+			writeError(w, r, http.StatusBadRequest, err, "Search query must be 1 to 200 characters")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, err, "Failed to search users")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, searchUsersResponse{Query: query.Get("q"), Results: results})
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchUsers(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	t.Run("results", func(t *testing.T) {
+		mockService.On("SearchUsers", "petrvo", 5).Return([]*domain.UserSearchResult{{
+			User:          &domain.User{ID: 2, Name: "Ivan Petrov"},
+			Score:         0.4,
+			NameHighlight: "Ivan <mark>Petrov</mark>",
+		}}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/users/search?q=petrvo&limit=5", nil)
+		w := httptest.NewRecorder()
+
+		handler.SearchUsers(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp searchUsersResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, "petrvo", resp.Query)
+		if assert.Len(t, resp.Results, 1) {
+			assert.Equal(t, "Ivan <mark>Petrov</mark>", resp.Results[0].NameHighlight)
+		}
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		mockService.On("SearchUsers", "", 0).Return(nil, errors.ErrInvalidInput).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/users/search", nil)
+		w := httptest.NewRecorder()
+
+		handler.SearchUsers(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users/search?q=anna&limit=x", nil)
+		w := httptest.NewRecorder()
+
+		handler.SearchUsers(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
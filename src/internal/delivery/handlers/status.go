@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+type statusChangeRequest struct {
+	Reason string `json:"reason" xml:"reason"`
+}
+
+func (h *UserHandler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, domain.UserStatusSuspended)
+}
+
+func (h *UserHandler) ActivateUser(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, domain.UserStatusActive)
+}
+
+func (h *UserHandler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, domain.UserStatusDeactivated)
+}
+
+// changeStatus serves POST /users/{id}/<action>. The body, carrying an
+// optional reason, may be omitted.
+func (h *UserHandler) changeStatus(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, "Invalid user ID")
+		return
+	}
+
+	var req statusChangeRequest
+	if r.ContentLength != 0 && !decodeRequest(w, r, &req) {
+		return
+	}
+
+	user, err := h.userService.ChangeStatus(id, status, req.Reason)
+	if err != nil {
+		switch err {
+		case errors.ErrInvalidInput:
+			writeError(w, r, http.StatusBadRequest, err, "Reason must be at most 500 characters")
+		case errors.ErrUserNotFound:
+			writeError(w, r, http.StatusNotFound, err, "User not found")
+		case errors.ErrInvalidStatusTransition:
+			writeError(w, r, http.StatusConflict, err, "User cannot move from its current status to "+status)
+		default:
+			writeError(w, r, http.StatusInternalServerError, err, "Failed to change user status")
+		}
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, user)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeUserStatus(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	newRequest := func(id, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/users/"+id+"/suspend", bytes.NewBufferString(body))
+		req.SetPathValue("id", id)
+		return req
+	}
+
+	t.Run("with reason", func(t *testing.T) {
+		changedAt := "2025-03-21T13:45:30Z"
+		user := &domain.User{ID: 1, Status: domain.UserStatusSuspended, StatusReason: "chargeback", StatusChangedAt: &changedAt}
+		mockService.On("ChangeStatus", int64(1), domain.UserStatusSuspended, "chargeback").Return(user, nil)
+
+		w := httptest.NewRecorder()
+		handler.SuspendUser(w, newRequest("1", `{"reason":"chargeback"}`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response domain.User
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "chargeback", response.StatusReason)
+		assert.Equal(t, &changedAt, response.StatusChangedAt)
+	})
+
+	t.Run("without body", func(t *testing.T) {
+		mockService.On("ChangeStatus", int64(2), domain.UserStatusActive, "").Return(&domain.User{ID: 2, Status: domain.UserStatusActive}, nil)
+
+		req := newRequest("2", "")
+		w := httptest.NewRecorder()
+		handler.ActivateUser(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("transition not allowed", func(t *testing.T) {
+		mockService.On("ChangeStatus", int64(3), domain.UserStatusSuspended, "").Return(nil, errors.ErrInvalidStatusTransition)
+
+		w := httptest.NewRecorder()
+		handler.SuspendUser(w, newRequest("3", ""))
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockService.On("ChangeStatus", int64(999), domain.UserStatusDeactivated, "").Return(nil, errors.ErrUserNotFound)
+
+		w := httptest.NewRecorder()
+		handler.DeactivateUser(w, newRequest("999", ""))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.SuspendUser(w, newRequest("abc", ""))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+type WebhookService interface {
+	CreateSubscription(sub *domain.WebhookSubscription) error
+	GetSubscription(id int64) (*domain.WebhookSubscription, error)
+	ListSubscriptions() ([]*domain.WebhookSubscription, error)
+	UpdateSubscription(sub *domain.WebhookSubscription) error
+	DeleteSubscription(id int64) error
+	ListDeliveries(filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error)
+	RetryDelivery(id int64) error
+}
+
+type WebhookHandler struct {
+	webhookService WebhookService
+}
+
+func NewWebhookHandler(webhookService WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// webhookRequest mirrors the writable subscription fields. Active defaults to
+// true so that a subscription is only paused when asked to be.
+type webhookRequest struct {
+	ID         int64    `json:"id" xml:"id"`
+	URL        string   `json:"url" xml:"url"`
+	EventTypes []string `json:"event_types" xml:"event_types>event_type"`
+	Active     *bool    `json:"active" xml:"active"`
+}
+
+func (req webhookRequest) subscription() *domain.WebhookSubscription {
+	sub := &domain.WebhookSubscription{
+		ID:         req.ID,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Active:     true,
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	return sub
+}
+
+type webhookListResponse struct {
+	XMLName  xml.Name                      `json:"-" xml:"webhook_list"`
+	Webhooks []*domain.WebhookSubscription `json:"webhooks" xml:"webhook"`
+}
+
+type deliveryListResponse struct {
+	XMLName    xml.Name                  `json:"-" xml:"delivery_list"`
+	Deliveries []*domain.WebhookDelivery `json:"deliveries" xml:"delivery"`
+	Limit      int                       `json:"limit" xml:"limit"`
+	Offset     int                       `json:"offset" xml:"offset"`
+}
+
+type retryDeliveryRequest struct {
+	ID int64 `json:"id" xml:"id"`
+}
+
+func (h *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	sub := req.subscription()
+	if err := h.webhookService.CreateSubscription(sub); err != nil {
+		writeWebhookError(w, r, err, "Failed to create webhook")
+		return
+	}
+
+	writeResponse(w, r, http.StatusCreated, sub)
+}
+
+func (h *WebhookHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err, "Invalid webhook ID")
+		return
+	}
+
+	sub, err := h.webhookService.GetSubscription(id)
+	if err != nil {
+		writeWebhookError(w, r, err, "Failed to get webhook")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, sub)
+}
+
+func (h *WebhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.webhookService.ListSubscriptions()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err, "Failed to list webhooks")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, webhookListResponse{Webhooks: subs})
+}
+
+func (h *WebhookHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	sub := req.subscription()
+	if err := h.webhookService.UpdateSubscription(sub); err != nil {
+		writeWebhookError(w, r, err, "Failed to update webhook")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, sub)
+}
+
+func (h *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err, "Invalid webhook ID")
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(id); err != nil {
+		writeWebhookError(w, r, err, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.WebhookDeliveryFilter{Status: query.Get("status")}
+
+	for param, dst := range map[string]*int{
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	} {
+		if v := query.Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, invalidParamError(param).Error())
+				return
+			}
+			*dst = n
+		}
+	}
+	if v := query.Get("subscription_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(w, r, http.StatusBadRequest, errors.ErrInvalidInput, invalidParamError("subscription_id").Error())
+			return
+		}
+		filter.SubscriptionID = id
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(filter)
+	if err != nil {
+		if err == errors.ErrInvalidInput {
+			writeError(w, r, http.StatusBadRequest, err, "Status must be pending, succeeded or dead")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, err, "Failed to list webhook deliveries")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, deliveryListResponse{Deliveries: deliveries, Limit: filter.Limit, Offset: filter.Offset})
+}
+
+func (h *WebhookHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	var req retryDeliveryRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.webhookService.RetryDelivery(req.ID); err != nil {
+		switch err {
+		case errors.ErrInvalidInput:
+			writeError(w, r, http.StatusBadRequest, err, "Invalid delivery ID")
+		case errors.ErrWebhookDeliveryNotFound:
+			writeError(w, r, http.StatusNotFound, err, "No dead-lettered delivery with this ID")
+		default:
+			writeError(w, r, http.StatusInternalServerError, err, "Failed to retry webhook delivery")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func writeWebhookError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch err {
+	case errors.ErrInvalidInput:
+		writeError(w, r, http.StatusBadRequest, err, "Invalid input data")
+	case errors.ErrInvalidWebhookURL:
+		writeError(w, r, http.StatusBadRequest, err, "URL must be an absolute http or https URL")
+	case errors.ErrWebhookNotFound:
+		writeError(w, r, http.StatusNotFound, err, "Webhook not found")
+	default:
+		writeError(w, r, http.StatusInternalServerError, err, message)
+	}
+}
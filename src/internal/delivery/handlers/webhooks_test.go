@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) CreateSubscription(sub *domain.WebhookSubscription) error {
+	args := m.Called(sub)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) GetSubscription(id int64) (*domain.WebhookSubscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookService) ListSubscriptions() ([]*domain.WebhookSubscription, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookService) UpdateSubscription(sub *domain.WebhookSubscription) error {
+	args := m.Called(sub)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) DeleteSubscription(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) ListDeliveries(filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookService) RetryDelivery(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestCreateWebhook(t *testing.T) {
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	t.Run("active by default", func(t *testing.T) {
+		mockService.On("CreateSubscription", mock.MatchedBy(func(sub *domain.WebhookSubscription) bool {
+			return sub.URL == "https://example.com/hook" && sub.Active
+		})).Run(func(args mock.Arguments) {
+			args.Get(0).(*domain.WebhookSubscription).Secret = "whsec_x"
+		}).Return(nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(`{"url":"https://example.com/hook"}`))
+		w := httptest.NewRecorder()
+
+		handler.CreateSubscription(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var sub domain.WebhookSubscription
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&sub))
+		assert.Equal(t, "whsec_x", sub.Secret)
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		mockService.On("CreateSubscription", mock.Anything).Return(errors.ErrInvalidWebhookURL).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(`{"url":"nope"}`))
+		w := httptest.NewRecorder()
+
+		handler.CreateSubscription(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	mockService.On("ListDeliveries", domain.WebhookDeliveryFilter{SubscriptionID: 3, Status: domain.WebhookDeliveryDead}).
+		Return([]*domain.WebhookDelivery{{ID: 1, SubscriptionID: 3, Status: domain.WebhookDeliveryDead}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/webhooks/deliveries?subscription_id=3&status=dead", nil)
+	w := httptest.NewRecorder()
+
+	handler.ListDeliveries(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"dead"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestRetryWebhookDelivery(t *testing.T) {
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	mockService.On("RetryDelivery", int64(1)).Return(nil)
+	mockService.On("RetryDelivery", int64(2)).Return(errors.ErrWebhookDeliveryNotFound)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/deliveries/retry", bytes.NewBufferString(`{"id":1}`))
+	w := httptest.NewRecorder()
+	handler.RetryDelivery(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/webhooks/deliveries/retry", bytes.NewBufferString(`{"id":2}`))
+	w = httptest.NewRecorder()
+	handler.RetryDelivery(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -2,24 +2,133 @@ package http
 
 import (
 	"net/http"
+	"users-api/src/internal/delivery/codec"
 	"users-api/src/internal/delivery/handlers"
+	"users-api/src/internal/errors"
 )
 
-func NewRouter(userHandler *handlers.UserHandler) *http.ServeMux {
+func NewRouter(userHandler *handlers.UserHandler, mfaHandler *handlers.MFAHandler, lockoutHandler *handlers.LockoutHandler, importHandler *handlers.ImportHandler, eventHandler *handlers.EventHandler, webhookHandler *handlers.WebhookHandler, attributeHandler *handlers.AttributeHandler, groupHandler *handlers.GroupHandler, docsHandler *handlers.DocsHandler, graphqlHandler, scimHandler http.Handler) *http.ServeMux {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/users", handlers.Negotiate(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
 			userHandler.CreateUser(w, r)
 		case http.MethodGet:
-			userHandler.GetUser(w, r)
+			switch query := r.URL.Query(); {
+			case query.Has("id"):
+				userHandler.GetUser(w, r)
+			case query.Has("email"):
+				userHandler.GetUserByEmail(w, r)
+			default:
+				userHandler.ListUsers(w, r)
+			}
 		case http.MethodPut:
 			userHandler.UpdateUser(w, r)
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			codec.WriteError(w, r, http.StatusMethodNotAllowed, errors.ErrMethodNotAllowed.Error(), "Method not allowed")
 		}
-	})
+	}))
+
+	mux.HandleFunc("POST /users/{id}/suspend", handlers.Negotiate(userHandler.SuspendUser))
+	mux.HandleFunc("POST /users/{id}/activate", handlers.Negotiate(userHandler.ActivateUser))
+	mux.HandleFunc("POST /users/{id}/deactivate", handlers.Negotiate(userHandler.DeactivateUser))
+	mux.HandleFunc("/users/export", getOnly(userHandler.ExportUsers))
+	mux.HandleFunc("/users/search", handlers.Negotiate(getOnly(userHandler.SearchUsers)))
+	mux.HandleFunc("/users/events", getOnly(eventHandler.Stream))
+	mux.HandleFunc("/users:batch", handlers.Negotiate(postOnly(userHandler.Batch)))
+	mux.HandleFunc("/users/import", postOnly(importHandler.Import))
+	mux.HandleFunc("/users/attributes", handlers.Negotiate(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			attributeHandler.CreateDefinition(w, r)
+		case http.MethodGet:
+			if r.URL.Query().Has("name") {
+				attributeHandler.GetDefinition(w, r)
+			} else {
+				attributeHandler.ListDefinitions(w, r)
+			}
+		case http.MethodPut:
+			attributeHandler.UpdateDefinition(w, r)
+		case http.MethodDelete:
+			attributeHandler.DeleteDefinition(w, r)
+		default:
+			codec.WriteError(w, r, http.StatusMethodNotAllowed, errors.ErrMethodNotAllowed.Error(), "Method not allowed")
+		}
+	}))
+	mux.HandleFunc("/users/verify-email", handlers.Negotiate(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodPost:
+			userHandler.VerifyEmail(w, r)
+		default:
+			codec.WriteError(w, r, http.StatusMethodNotAllowed, errors.ErrMethodNotAllowed.Error(), "Method not allowed")
+		}
+	}))
+
+	mux.HandleFunc("/users/mfa/enroll", handlers.Negotiate(postOnly(mfaHandler.Enroll)))
+	mux.HandleFunc("/users/mfa/confirm", handlers.Negotiate(postOnly(mfaHandler.Confirm)))
+	mux.HandleFunc("/users/mfa/verify", handlers.Negotiate(postOnly(mfaHandler.Verify)))
+	mux.HandleFunc("/users/mfa/disable", handlers.Negotiate(postOnly(mfaHandler.Disable)))
+	mux.HandleFunc("/users/mfa/recovery-codes", handlers.Negotiate(postOnly(mfaHandler.RegenerateRecoveryCodes)))
+	mux.HandleFunc("/users/unlock", handlers.Negotiate(postOnly(lockoutHandler.Unlock)))
+
+	mux.HandleFunc("POST /groups", handlers.Negotiate(groupHandler.CreateGroup))
+	mux.HandleFunc("GET /groups", handlers.Negotiate(groupHandler.ListGroups))
+	mux.HandleFunc("GET /groups/{id}", handlers.Negotiate(groupHandler.GetGroup))
+	mux.HandleFunc("PUT /groups/{id}", handlers.Negotiate(groupHandler.UpdateGroup))
+	mux.HandleFunc("DELETE /groups/{id}", handlers.Negotiate(groupHandler.DeleteGroup))
+	mux.HandleFunc("GET /groups/{id}/members", handlers.Negotiate(groupHandler.ListMembers))
+	mux.HandleFunc("GET /groups/{id}/members/{userId}", handlers.Negotiate(groupHandler.GetMember))
+	mux.HandleFunc("PUT /groups/{id}/members/{userId}", handlers.Negotiate(groupHandler.AddMember))
+	mux.HandleFunc("DELETE /groups/{id}/members/{userId}", handlers.Negotiate(groupHandler.RemoveMember))
+	mux.HandleFunc("GET /users/{id}/groups", handlers.Negotiate(groupHandler.ListUserGroups))
+
+	mux.HandleFunc("/webhooks", handlers.Negotiate(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			webhookHandler.CreateSubscription(w, r)
+		case http.MethodGet:
+			if r.URL.Query().Has("id") {
+				webhookHandler.GetSubscription(w, r)
+			} else {
+				webhookHandler.ListSubscriptions(w, r)
+			}
+		case http.MethodPut:
+			webhookHandler.UpdateSubscription(w, r)
+		case http.MethodDelete:
+			webhookHandler.DeleteSubscription(w, r)
+		default:
+			codec.WriteError(w, r, http.StatusMethodNotAllowed, errors.ErrMethodNotAllowed.Error(), "Method not allowed")
+		}
+	}))
+	mux.HandleFunc("/webhooks/deliveries", handlers.Negotiate(getOnly(webhookHandler.ListDeliveries)))
+	mux.HandleFunc("/webhooks/deliveries/retry", handlers.Negotiate(postOnly(webhookHandler.RetryDelivery)))
+
+	mux.HandleFunc("/openapi.json", getOnly(docsHandler.Spec))
+	mux.HandleFunc("/docs", getOnly(docsHandler.Page))
+
+	mux.Handle("/graphql", graphqlHandler)
+	mux.Handle("/scim/v2/", scimHandler)
 
 	return mux
 }
+
+func postOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			codec.WriteError(w, r, http.StatusMethodNotAllowed, errors.ErrMethodNotAllowed.Error(), "Method not allowed")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func getOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			codec.WriteError(w, r, http.StatusMethodNotAllowed, errors.ErrMethodNotAllowed.Error(), "Method not allowed")
+			return
+		}
+		next(w, r)
+	}
+}
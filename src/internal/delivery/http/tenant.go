@@ -0,0 +1,40 @@
+package http
+
+import (
+	"log"
+	"net/http"
+
+	"users-api/src/internal/delivery/codec"
+	"users-api/src/internal/delivery/middleware"
+	"users-api/src/internal/errors"
+)
+
+// TenantRouter serves each request with the handler of the tenant the
+// request was authenticated as. acquire returns the handler and a release
+// func, called once the request has been served, so that the tenant is not
+// torn down while a request (or an event stream) still uses it.
+type TenantRouter struct {
+	acquire func(tenantID int64) (http.Handler, func(), error)
+}
+
+func NewTenantRouter(acquire func(tenantID int64) (http.Handler, func(), error)) *TenantRouter {
+	return &TenantRouter{acquire: acquire}
+}
+
+func (t *TenantRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := middleware.TenantFromContext(r.Context())
+	if !ok {
+		// Serving without a tenant would mean serving someone else's data.
+		codec.WriteError(w, r, http.StatusInternalServerError, errors.ErrTenantNotFound.Error(), "Internal server error")
+		return
+	}
+
+	handler, release, err := t.acquire(tenant.ID)
+	if err != nil {
+		log.Printf("Failed to build handler for tenant %d: %v", tenant.ID, err)
+		codec.WriteError(w, r, http.StatusInternalServerError, err.Error(), "Internal server error")
+		return
+	}
+	defer release()
+	handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"users-api/src/internal/delivery/codec"
+	"users-api/src/internal/domain"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+	idempotencyCleanup   = 10 * time.Minute
+)
+
+// Idempotency replays the stored response for requests that repeat an
+// Idempotency-Key, so that a client retrying after a timeout gets the
+// original outcome instead of executing the request twice.
+type Idempotency struct {
+	repo   domain.IdempotencyRepository
+	ttl    time.Duration
+	routes map[string]bool
+	now    func() time.Time
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+func NewIdempotency(repo domain.IdempotencyRepository, ttl time.Duration, routes ...string) *Idempotency {
+	m := &Idempotency{
+		repo:   repo,
+		ttl:    ttl,
+		routes: make(map[string]bool, len(routes)),
+		now:    time.Now,
+	}
+	for _, route := range routes {
+		m.routes[route] = true
+	}
+	return m
+}
+
+func (m *Idempotency) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || !m.routes[r.Method+" "+r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			codec.WriteError(w, r, http.StatusBadRequest, "invalid idempotency key", "Idempotency-Key must not exceed 255 characters")
+			return
+		}
+		// Clients choose their keys, so two tenants may well pick the same
+		// one. Every key is prefixed, so no key can pass for another tenant's.
+		tenantID := domain.DefaultTenantID
+		if tenant, ok := TenantFromContext(r.Context()); ok {
+			tenantID = tenant.ID
+		}
+		key = strconv.FormatInt(tenantID, 10) + ":" + key
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			codec.WriteError(w, r, http.StatusBadRequest, err.Error(), "Invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		m.cleanup()
+
+		now := m.now()
+		record := &domain.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: fingerprint(r, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(m.ttl),
+		}
+
+		reserved, err := m.repo.Reserve(record)
+		if err != nil {
+			codec.WriteError(w, r, http.StatusInternalServerError, err.Error(), "Failed to process idempotency key")
+			return
+		}
+		if !reserved {
+			m.replay(w, r, record)
+			return
+		}
+
+		rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.status >= http.StatusInternalServerError {
+			if err := m.repo.Release(key); err != nil {
+				log.Printf("Failed to release idempotency key %q: %v", key, err)
+			}
+			return
+		}
+		if err := m.repo.Complete(key, rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+			log.Printf("Failed to store response for idempotency key %q: %v", key, err)
+		}
+	})
+}
+
+func (m *Idempotency) replay(w http.ResponseWriter, r *http.Request, record *domain.IdempotencyRecord) {
+	stored, err := m.repo.Get(record.Key)
+	if err != nil {
+		codec.WriteError(w, r, http.StatusInternalServerError, err.Error(), "Failed to process idempotency key")
+		return
+	}
+	if stored == nil {
+		codec.WriteError(w, r, http.StatusConflict, "idempotency key in use", "A request with this Idempotency-Key is being processed, retry later")
+		return
+	}
+	if stored.Fingerprint != record.Fingerprint {
+		codec.WriteError(w, r, http.StatusUnprocessableEntity, "idempotency key reused", "Idempotency-Key was already used with a different request")
+		return
+	}
+	if stored.StatusCode == nil {
+		codec.WriteError(w, r, http.StatusConflict, "idempotency key in use", "A request with this Idempotency-Key is being processed, retry later")
+		return
+	}
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(*stored.StatusCode)
+	w.Write(stored.ResponseBody)
+}
+
+func (m *Idempotency) cleanup() {
+	m.mu.Lock()
+	now := m.now()
+	if now.Sub(m.lastCleanup) < idempotencyCleanup {
+		m.mu.Unlock()
+		return
+	}
+	m.lastCleanup = now
+	m.mu.Unlock()
+
+	go func() {
+		if _, err := m.repo.DeleteExpired(now); err != nil {
+			log.Printf("Failed to delete expired idempotency keys: %v", err)
+		}
+	}()
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]*domain.IdempotencyRecord
+}
+
+func newMemoryIdempotencyRepository() *memoryIdempotencyRepository {
+	return &memoryIdempotencyRepository{records: make(map[string]*domain.IdempotencyRecord)}
+}
+
+func (r *memoryIdempotencyRepository) Reserve(record *domain.IdempotencyRecord) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.records[record.Key]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		return false, nil
+	}
+	copied := *record
+	r.records[record.Key] = &copied
+	return true, nil
+}
+
+func (r *memoryIdempotencyRepository) Get(key string) (*domain.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.records[key], nil
+}
+
+func (r *memoryIdempotencyRepository) Complete(key string, statusCode int, contentType string, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[key].StatusCode = &statusCode
+	r.records[key].ContentType = contentType
+	r.records[key].ResponseBody = body
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) Release(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, key)
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) DeleteExpired(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotency(t *testing.T) {
+	repo := newMemoryIdempotencyRepository()
+	now := time.Unix(1700000000, 0)
+	m := NewIdempotency(repo, time.Hour, "POST /users")
+	m.now = func() time.Time { return now }
+	m.lastCleanup = now
+
+	calls := 0
+	status := http.StatusCreated
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":1}`))
+	}))
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("first request executes handler", func(t *testing.T) {
+		w := post("key-1", `{"name":"John"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("retry replays stored response", func(t *testing.T) {
+		w := post("key-1", `{"name":"John"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, `{"id":1}`, w.Body.String())
+		assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("reuse with different payload", func(t *testing.T) {
+		w := post("key-1", `{"name":"Jane"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("request still in progress", func(t *testing.T) {
+		repo.records["1:key-2"] = &domain.IdempotencyRecord{
+			Key:         "1:key-2",
+			Fingerprint: fingerprint(httptest.NewRequest(http.MethodPost, "/users", nil), []byte(`{}`)),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(time.Hour),
+		}
+
+		w := post("key-2", `{}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("server errors are not stored", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		post("key-3", `{}`)
+		status = http.StatusCreated
+		w := post("key-3", `{}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("expired key executes again", func(t *testing.T) {
+		before := calls
+		now = now.Add(2 * time.Hour)
+		w := post("key-1", `{"name":"Jane"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, before+1, calls)
+	})
+
+	t.Run("tenants do not share keys", func(t *testing.T) {
+		before := calls
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"name":"Jane"}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, withTenant(req, &domain.Tenant{ID: 2}))
+
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, before+1, calls)
+		assert.Contains(t, repo.records, "2:key-1")
+
+		// The default tenant's "2:key-1" is not tenant 2's "key-1".
+		w = post("2:key-1", `{"name":"Jane"}`)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, before+2, calls)
+		assert.Contains(t, repo.records, "1:2:key-1")
+	})
+
+	t.Run("requests without key pass through", func(t *testing.T) {
+		before := calls
+		post("", `{}`)
+		post("", `{}`)
+		assert.Equal(t, before+2, calls)
+	})
+}
@@ -0,0 +1,644 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"users-api/src/internal/delivery/codec"
+	"users-api/src/internal/errors"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+const (
+	specURL = "openapi.json"
+
+	maxValidatedBodyBytes = 10 << 20
+)
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// OpenAPIValidator rejects requests whose parameters or JSON body do not match
+// the operation described for them in an OpenAPI 3.1 document. Requests for
+// paths or methods the document does not describe are passed through
+// untouched, as are bodies in media types without a schema.
+//
+// With response validation on, JSON responses are buffered and checked too,
+// and a mismatch is replaced by a 500. This is meant for tests and staging,
+// not production: it costs a copy of every response.
+type OpenAPIValidator struct {
+	routes            []*openAPIRoute
+	validateResponses bool
+}
+
+type openAPIRoute struct {
+	segments   []string
+	params     int
+	operations map[string]*openAPIOperation
+}
+
+type openAPIOperation struct {
+	params       []*openAPIParam
+	bodyRequired bool
+	bodies       map[string]*jsonschema.Schema
+	responses    map[string]map[string]*jsonschema.Schema
+}
+
+type openAPIParam struct {
+	name     string
+	in       string
+	required bool
+	typ      string
+	schema   *jsonschema.Schema
+}
+
+// Only the parts of the document the validator needs are decoded.
+type openAPIDocument struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas    map[string]json.RawMessage `json:"schemas"`
+		Parameters map[string]json.RawMessage `json:"parameters"`
+		Responses  map[string]json.RawMessage `json:"responses"`
+	} `json:"components"`
+}
+
+type openAPIParameterObject struct {
+	Ref      string          `json:"$ref"`
+	Name     string          `json:"name"`
+	In       string          `json:"in"`
+	Required bool            `json:"required"`
+	Schema   json.RawMessage `json:"schema"`
+}
+
+type openAPIOperationObject struct {
+	Parameters  []json.RawMessage `json:"parameters"`
+	RequestBody *struct {
+		Required bool                       `json:"required"`
+		Content  map[string]json.RawMessage `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]json.RawMessage `json:"responses"`
+}
+
+type openAPIResponseObject struct {
+	Ref     string                     `json:"$ref"`
+	Content map[string]json.RawMessage `json:"content"`
+}
+
+type openAPIMediaTypeObject struct {
+	Schema json.RawMessage `json:"schema"`
+}
+
+func NewOpenAPIValidator(spec []byte, validateResponses bool) (*OpenAPIValidator, error) {
+	var doc openAPIDocument
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi document: %w", err)
+	}
+
+	raw, err := jsonschema.UnmarshalJSON(bytes.NewReader(spec))
+	if err != nil {
+		return nil, fmt.Errorf("parse openapi document: %w", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	if err := compiler.AddResource(specURL, raw); err != nil {
+		return nil, err
+	}
+
+	l := &openAPILoader{doc: &doc, compiler: compiler}
+	v := &OpenAPIValidator{validateResponses: validateResponses}
+
+	for path, item := range doc.Paths {
+		route := &openAPIRoute{
+			segments:   strings.Split(path, "/"),
+			operations: make(map[string]*openAPIOperation),
+		}
+		for _, s := range route.segments {
+			if isTemplate(s) {
+				route.params++
+			}
+		}
+
+		var shared []json.RawMessage
+		if p, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(p, &shared); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+
+		for _, method := range httpMethods {
+			rawOp, ok := item[method]
+			if !ok {
+				continue
+			}
+			op, err := l.operation(pointer("paths", path), shared, method, rawOp)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			route.operations[strings.ToUpper(method)] = op
+		}
+		v.routes = append(v.routes, route)
+	}
+
+	// Literal segments win over templates, as in http.ServeMux.
+	sort.Slice(v.routes, func(i, j int) bool {
+		return v.routes[i].params < v.routes[j].params
+	})
+
+	return v, nil
+}
+
+func (v *OpenAPIValidator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, pathParams := v.find(r)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := op.validateRequest(r, pathParams); err != nil {
+			codec.WriteError(w, r, http.StatusBadRequest, errors.ErrRequestInvalid.Error(), err.Error())
+			return
+		}
+
+		if !v.validateResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rw := &validatingResponseWriter{ResponseWriter: w, r: r, op: op}
+		next.ServeHTTP(rw, r)
+		rw.finish()
+	})
+}
+
+func (v *OpenAPIValidator) find(r *http.Request) (*openAPIOperation, map[string]string) {
+	segments := strings.Split(r.URL.Path, "/")
+
+	for _, route := range v.routes {
+		if len(route.segments) != len(segments) {
+			continue
+		}
+		params, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+		op, ok := route.operations[r.Method]
+		if !ok {
+			return nil, nil
+		}
+		return op, params
+	}
+	return nil, nil
+}
+
+func (route *openAPIRoute) match(segments []string) (map[string]string, bool) {
+	var params map[string]string
+	for i, s := range route.segments {
+		if isTemplate(s) {
+			if segments[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[s[1:len(s)-1]] = segments[i]
+			continue
+		}
+		if s != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (op *openAPIOperation) validateRequest(r *http.Request, pathParams map[string]string) error {
+	query := r.URL.Query()
+
+	for _, p := range op.params {
+		var value string
+		var present bool
+		switch p.in {
+		case "query":
+			present = query.Has(p.name)
+			value = query.Get(p.name)
+		case "header":
+			value = r.Header.Get(p.name)
+			present = value != ""
+		case "path":
+			value, present = pathParams[p.name]
+		default:
+			continue
+		}
+
+		if !present {
+			if p.required {
+				return fmt.Errorf("%s parameter %s is required", p.in, p.name)
+			}
+			continue
+		}
+
+		parsed, err := parseParam(value, p.typ)
+		if err != nil {
+			return fmt.Errorf("%s parameter %s: %v", p.in, p.name, err)
+		}
+		if err := p.schema.Validate(parsed); err != nil {
+			return fmt.Errorf("%s parameter %s: %s", p.in, p.name, describe(err))
+		}
+	}
+
+	if op.bodies == nil {
+		return nil
+	}
+
+	mediaType := "application/json"
+	if header := r.Header.Get("Content-Type"); header != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(header); err != nil {
+			return fmt.Errorf("invalid Content-Type")
+		}
+	}
+	schema, ok := op.bodies[mediaType]
+	if !ok || schema == nil || !isJSON(mediaType) {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxValidatedBodyBytes+1))
+	if err != nil {
+		return fmt.Errorf("read request body: %v", err)
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.bodyRequired {
+			return fmt.Errorf("request body is required")
+		}
+		return nil
+	}
+	if len(body) > maxValidatedBodyBytes {
+		return fmt.Errorf("request body is too large")
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("request body is not valid JSON")
+	}
+	if err := schema.Validate(instance); err != nil {
+		return fmt.Errorf("request body: %s", describe(err))
+	}
+	return nil
+}
+
+// responseSchema returns the schema for a response, looking up the status,
+// then its class (2XX), then default. ok is false when the document does not
+// describe the status at all.
+func (op *openAPIOperation) responseSchema(status int, mediaType string) (schema *jsonschema.Schema, ok bool) {
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", "default"} {
+		content, found := op.responses[key]
+		if !found {
+			continue
+		}
+		return content[mediaType], true
+	}
+	return nil, false
+}
+
+// validatingResponseWriter buffers responses that have a JSON schema so they
+// can be checked before they are sent. Everything else, including event
+// streams, is passed through as it is written.
+type validatingResponseWriter struct {
+	http.ResponseWriter
+	r  *http.Request
+	op *openAPIOperation
+
+	status   int
+	schema   *jsonschema.Schema
+	buffered bool
+	failed   bool
+	body     bytes.Buffer
+}
+
+func (w *validatingResponseWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	schema, ok := w.op.responseSchema(status, mediaType)
+	if !ok {
+		w.fail(fmt.Sprintf("status %d is not described", status))
+		return
+	}
+	if schema != nil && isJSON(mediaType) && w.Header().Get("Content-Encoding") == "" {
+		w.schema = schema
+		w.buffered = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *validatingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffered || w.failed {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *validatingResponseWriter) Flush() {
+	if w.buffered || w.failed {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *validatingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *validatingResponseWriter) finish() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.buffered {
+		return
+	}
+	w.buffered = false
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(w.body.Bytes()))
+	if err != nil {
+		w.fail("body is not valid JSON")
+		return
+	}
+	if err := w.schema.Validate(instance); err != nil {
+		w.fail(describe(err))
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.body.Bytes())
+}
+
+func (w *validatingResponseWriter) fail(reason string) {
+	log.Printf("openapi: response of %d does not match the document: %s", w.status, reason)
+
+	w.buffered = false
+	w.failed = true
+	w.Header().Del("Content-Length")
+	w.Header().Del("Content-Encoding")
+	codec.WriteError(w.ResponseWriter, w.r, http.StatusInternalServerError, errors.ErrResponseInvalid.Error(), fmt.Sprintf("Response with status %d: %s", w.status, reason))
+}
+
+type openAPILoader struct {
+	doc      *openAPIDocument
+	compiler *jsonschema.Compiler
+}
+
+func (l *openAPILoader) operation(itemPtr string, shared []json.RawMessage, method string, raw json.RawMessage) (*openAPIOperation, error) {
+	var obj openAPIOperationObject
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	opPtr := itemPtr + "/" + escape(method)
+	op := &openAPIOperation{responses: make(map[string]map[string]*jsonschema.Schema)}
+
+	// Operation parameters override path-level ones with the same name and
+	// location.
+	seen := make(map[string]bool)
+	for i, p := range obj.Parameters {
+		param, err := l.parameter(fmt.Sprintf("%s/parameters/%d", opPtr, i), p)
+		if err != nil {
+			return nil, err
+		}
+		seen[param.in+" "+param.name] = true
+		op.params = append(op.params, param)
+	}
+	for i, p := range shared {
+		param, err := l.parameter(fmt.Sprintf("%s/parameters/%d", itemPtr, i), p)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[param.in+" "+param.name] {
+			op.params = append(op.params, param)
+		}
+	}
+
+	if obj.RequestBody != nil {
+		op.bodyRequired = obj.RequestBody.Required
+		content, err := l.content(opPtr+"/requestBody/content", obj.RequestBody.Content)
+		if err != nil {
+			return nil, err
+		}
+		op.bodies = content
+	}
+
+	for status, raw := range obj.Responses {
+		ptr := opPtr + "/responses/" + escape(status)
+		var resp openAPIResponseObject
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, err
+		}
+		if resp.Ref != "" {
+			name, err := componentName(resp.Ref, "responses")
+			if err != nil {
+				return nil, err
+			}
+			target, ok := l.doc.Components.Responses[name]
+			if !ok {
+				return nil, fmt.Errorf("unresolved reference %s", resp.Ref)
+			}
+			ptr = pointer("components", "responses", name)
+			if err := json.Unmarshal(target, &resp); err != nil {
+				return nil, err
+			}
+		}
+		content, err := l.content(ptr+"/content", resp.Content)
+		if err != nil {
+			return nil, err
+		}
+		if status != "default" {
+			status = strings.ToUpper(status)
+		}
+		op.responses[status] = content
+	}
+
+	return op, nil
+}
+
+func (l *openAPILoader) parameter(ptr string, raw json.RawMessage) (*openAPIParam, error) {
+	var obj openAPIParameterObject
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	if obj.Ref != "" {
+		name, err := componentName(obj.Ref, "parameters")
+		if err != nil {
+			return nil, err
+		}
+		target, ok := l.doc.Components.Parameters[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", obj.Ref)
+		}
+		ptr = pointer("components", "parameters", name)
+		obj = openAPIParameterObject{}
+		if err := json.Unmarshal(target, &obj); err != nil {
+			return nil, err
+		}
+	}
+
+	param := &openAPIParam{name: obj.Name, in: obj.In, required: obj.Required, typ: "string"}
+	if obj.In == "header" {
+		param.name = http.CanonicalHeaderKey(obj.Name)
+	}
+	if obj.Schema == nil {
+		return nil, fmt.Errorf("parameter %s has no schema", obj.Name)
+	}
+	param.typ = l.schemaType(obj.Schema)
+
+	schema, err := l.compiler.Compile(specURL + "#" + ptr + "/schema")
+	if err != nil {
+		return nil, err
+	}
+	param.schema = schema
+	return param, nil
+}
+
+// content compiles the schema of every media type that has one; media types
+// without a schema map to nil.
+func (l *openAPILoader) content(ptr string, content map[string]json.RawMessage) (map[string]*jsonschema.Schema, error) {
+	schemas := make(map[string]*jsonschema.Schema, len(content))
+	for mediaType, raw := range content {
+		var obj openAPIMediaTypeObject
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		}
+		if obj.Schema == nil {
+			schemas[mediaType] = nil
+			continue
+		}
+		schema, err := l.compiler.Compile(specURL + "#" + ptr + "/" + escape(mediaType) + "/schema")
+		if err != nil {
+			return nil, err
+		}
+		schemas[mediaType] = schema
+	}
+	return schemas, nil
+}
+
+// schemaType returns the JSON type a parameter value is converted to before
+// validation, following a reference to a component schema if needed.
+func (l *openAPILoader) schemaType(raw json.RawMessage) string {
+	var s struct {
+		Ref  string `json:"$ref"`
+		Type string `json:"type"`
+	}
+	json.Unmarshal(raw, &s)
+	if s.Ref != "" {
+		if name, err := componentName(s.Ref, "schemas"); err == nil {
+			if target, ok := l.doc.Components.Schemas[name]; ok {
+				return l.schemaType(target)
+			}
+		}
+	}
+	if s.Type == "" {
+		return "string"
+	}
+	return s.Type
+}
+
+func parseParam(value, typ string) (interface{}, error) {
+	switch typ {
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return json.Number(strconv.FormatInt(n, 10)), nil
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return json.Number(value), nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return b, nil
+	}
+	return value, nil
+}
+
+var messagePrinter = message.NewPrinter(language.English)
+
+// describe flattens a validation error into its leaf causes, e.g.
+// "/email: got number, want string".
+func describe(err error) string {
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err.Error()
+	}
+
+	var msgs []string
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			msg := e.ErrorKind.LocalizedString(messagePrinter)
+			if len(e.InstanceLocation) > 0 {
+				msg = "/" + strings.Join(e.InstanceLocation, "/") + ": " + msg
+			}
+			msgs = append(msgs, msg)
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(ve)
+	return strings.Join(msgs, "; ")
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func isTemplate(segment string) bool {
+	return len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}'
+}
+
+func componentName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %s", ref)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+func pointer(tokens ...string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString("/")
+		b.WriteString(escape(t))
+	}
+	return b.String()
+}
+
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"users-api/src/api/openapi"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newValidator(t *testing.T, validateResponses bool) *OpenAPIValidator {
+	v, err := NewOpenAPIValidator(openapi.Spec, validateResponses)
+	require.NoError(t, err)
+	return v
+}
+
+func TestOpenAPIValidatorRequests(t *testing.T) {
+	var gotBody string
+	handler := newValidator(t, false).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		header      http.Header
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "valid body", method: http.MethodPost, target: "/users", body: `{"name":"Ivan","email":"ivan@example.com"}`, wantStatus: http.StatusOK},
+		{name: "missing required field", method: http.MethodPost, target: "/users", body: `{"name":"Ivan"}`, wantStatus: http.StatusBadRequest, wantMessage: "request body: missing property 'email'"},
+		{name: "wrong type", method: http.MethodPost, target: "/users", body: `{"name":"Ivan","email":1}`, wantStatus: http.StatusBadRequest, wantMessage: "request body: /email: got number, want string"},
+		{name: "unknown status", method: http.MethodPut, target: "/users", body: `{"id":1,"status":"banned"}`, wantStatus: http.StatusBadRequest},
+		{name: "empty body", method: http.MethodPost, target: "/users", wantStatus: http.StatusBadRequest, wantMessage: "request body is required"},
+		{name: "malformed body", method: http.MethodPost, target: "/users", body: `{`, wantStatus: http.StatusBadRequest, wantMessage: "request body is not valid JSON"},
+		{name: "body without schema", method: http.MethodPost, target: "/users", contentType: "application/xml", body: `<user/>`, wantStatus: http.StatusOK},
+		{name: "valid query", method: http.MethodGet, target: "/users?limit=10&status=active&created_after=2025-01-01T00:00:00Z", wantStatus: http.StatusOK},
+		{name: "non-integer query", method: http.MethodGet, target: "/users?limit=ten", wantStatus: http.StatusBadRequest, wantMessage: "query parameter limit: must be an integer"},
+		{name: "negative query", method: http.MethodGet, target: "/users?offset=-1", wantStatus: http.StatusBadRequest},
+		{name: "enum query through reference", method: http.MethodGet, target: "/users?status=banned", wantStatus: http.StatusBadRequest},
+		{name: "missing required query", method: http.MethodDelete, target: "/webhooks", wantStatus: http.StatusBadRequest, wantMessage: "query parameter id is required"},
+		{name: "invalid header", method: http.MethodGet, target: "/users/events", header: http.Header{"Last-Event-Id": {"abc"}}, wantStatus: http.StatusBadRequest},
+		{name: "templated path", method: http.MethodPatch, target: "/scim/v2/Users/5", contentType: "application/scim+json", body: `{"schemas":[]}`, wantStatus: http.StatusBadRequest},
+		{name: "undocumented method", method: http.MethodDelete, target: "/users", wantStatus: http.StatusOK},
+		{name: "undocumented path", method: http.MethodGet, target: "/unknown?limit=ten", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBody = ""
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.body, gotBody)
+				return
+			}
+
+			var resp map[string]interface{}
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, errors.ErrRequestInvalid.Error(), resp["error"])
+			if tt.wantMessage != "" {
+				assert.Equal(t, tt.wantMessage, resp["message"])
+			}
+		})
+	}
+}
+
+func TestOpenAPIValidatorResponses(t *testing.T) {
+	validator := newValidator(t, true)
+
+	serve := func(target string, h http.HandlerFunc) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		validator.Handler(h).ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	writeJSON := func(status int, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			io.WriteString(w, body)
+		}
+	}
+
+	t.Run("valid response is passed through", func(t *testing.T) {
+		body := `{"id":1,"name":"Ivan","email":"ivan@example.com","status":"active","email_verified_at":null,"mfa_enabled":false,"locked_until":null,"created_at":"2025-03-21T13:45:30Z","updated_at":"2025-03-21T13:45:30Z"}`
+		w := serve("/users?id=1", writeJSON(http.StatusOK, body))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, body, w.Body.String())
+	})
+
+	t.Run("documented error response", func(t *testing.T) {
+		w := serve("/users?id=1", writeJSON(http.StatusNotFound, `{"error":"user not found","code":404,"message":"User not found"}`))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid response is replaced", func(t *testing.T) {
+		w := serve("/users?id=1", writeJSON(http.StatusOK, `{"id":"1"}`))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), errors.ErrResponseInvalid.Error())
+	})
+
+	t.Run("undocumented status", func(t *testing.T) {
+		w := serve("/docs", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("streams are not buffered", func(t *testing.T) {
+		w := serve("/users/events", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, "retry: 3000\n\n")
+			flusher, ok := w.(http.Flusher)
+			require.True(t, ok)
+			flusher.Flush()
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, w.Flushed)
+		assert.Equal(t, "retry: 3000\n\n", w.Body.String())
+	})
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"users-api/src/internal/config"
+	"users-api/src/internal/delivery/codec"
+	"users-api/src/internal/errors"
+)
+
+const bucketIdleTimeout = 10 * time.Minute
+
+// RateLimiter is a token-bucket limiter keyed by tenant or client IP. It runs
+// after Tenant, so that only requests with a verified API key are counted per
+// tenant; the rest are counted per client IP. Each route may have its own
+// limit; routes without one use the default limit.
+type RateLimiter struct {
+	defaultLimit config.RateLimit
+	routes       map[string]config.RateLimit
+	byIP         bool
+	now          func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(defaultLimit config.RateLimit, routes map[string]config.RateLimit) *RateLimiter {
+	return &RateLimiter{
+		defaultLimit: defaultLimit,
+		routes:       routes,
+		now:          time.Now,
+		buckets:      make(map[string]*bucket),
+	}
+}
+
+// NewIPRateLimiter returns a limiter that counts every request per client IP.
+// It runs in front of Tenant, so that requests with invalid API keys, which
+// never reach the tenant limiter, cannot hammer the key lookup.
+func NewIPRateLimiter(limit config.RateLimit) *RateLimiter {
+	l := NewRateLimiter(limit, nil)
+	l.byIP = true
+	return l
+}
+
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, limit := l.limitFor(r)
+		if limit.Rate <= 0 || limit.Burst <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, remaining, reset, retryAfter := l.take(route+"|"+l.clientKey(r), limit)
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+			codec.WriteError(w, r, http.StatusTooManyRequests, errors.ErrRateLimited.Error(), "Too many requests")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l *RateLimiter) limitFor(r *http.Request) (string, config.RateLimit) {
+	if limit, ok := l.routes[r.Method+" "+r.URL.Path]; ok {
+		return r.Method + " " + r.URL.Path, limit
+	}
+	if limit, ok := l.routes[r.URL.Path]; ok {
+		return r.URL.Path, limit
+	}
+	return "", l.defaultLimit
+}
+
+func (l *RateLimiter) take(key string, limit config.RateLimit) (bool, int, time.Duration, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	reset := secondsToDuration((float64(limit.Burst) - b.tokens) / limit.Rate)
+	var retryAfter time.Duration
+	if !allowed {
+		retryAfter = secondsToDuration((1 - b.tokens) / limit.Rate)
+	}
+
+	return allowed, int(b.tokens), reset, retryAfter
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketIdleTimeout {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > bucketIdleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func (l *RateLimiter) clientKey(r *http.Request) string {
+	if tenant, ok := AuthenticatedTenant(r.Context()); ok && !l.byIP {
+		return "tenant:" + strconv.FormatInt(tenant.ID, 10)
+	}
+	if ip, ok := ClientIPFromContext(r.Context()); ok {
+		return "ip:" + ip
+	}
+	return "ip:" + remoteHost(r.RemoteAddr)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
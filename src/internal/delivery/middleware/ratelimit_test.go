@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"users-api/src/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewRateLimiter(config.RateLimit{Rate: 10, Burst: 10}, map[string]config.RateLimit{
+		"POST /users": {Rate: 1, Burst: 2},
+	})
+	limiter.now = func() time.Time { return now }
+	handler := Tenant(tenantsByKey{"acme-key": {ID: 2}}, false)(limiter.Handler(okHandler()))
+
+	post := func(remoteAddr, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users", nil)
+		req.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("route limit exhausted", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, post("192.0.2.1:1234", "").Code)
+		w := post("192.0.2.1:1234", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+		w = post("192.0.2.1:1234", "")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("other clients are not affected", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, post("192.0.2.2:1234", "").Code)
+		assert.Equal(t, http.StatusOK, post("192.0.2.1:1234", "acme-key").Code)
+	})
+
+	t.Run("bucket refills over time", func(t *testing.T) {
+		now = now.Add(time.Second)
+		assert.Equal(t, http.StatusOK, post("192.0.2.1:1234", "").Code)
+		assert.Equal(t, http.StatusTooManyRequests, post("192.0.2.1:1234", "").Code)
+	})
+
+	t.Run("default limit for other routes", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users?id=1", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "10", w.Header().Get("RateLimit-Limit"))
+	})
+}
+
+func TestRateLimiterUnverifiedKeys(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewRateLimiter(config.RateLimit{Rate: 1, Burst: 2}, nil)
+	limiter.now = func() time.Time { return now }
+	handler := limiter.Handler(okHandler())
+
+	// Without Tenant in front nothing vouches for the keys, so a fresh key
+	// per request shares the bucket of the client IP.
+	codes := make([]int, 3)
+	for i := range codes {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-API-Key", fmt.Sprintf("random-key-%d", i))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		codes[i] = w.Code
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+	assert.Len(t, limiter.buckets, 1)
+}
+
+func TestIPRateLimiter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewIPRateLimiter(config.RateLimit{Rate: 1, Burst: 2})
+	limiter.now = func() time.Time { return now }
+	handler := limiter.Handler(Tenant(tenantsByKey{"acme-key": {ID: 2}}, false)(okHandler()))
+
+	get := func(remoteAddr, apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Invalid keys are counted before Tenant rejects them.
+	assert.Equal(t, http.StatusUnauthorized, get("192.0.2.1:1234", "guess-1"))
+	assert.Equal(t, http.StatusUnauthorized, get("192.0.2.1:1234", "guess-2"))
+	assert.Equal(t, http.StatusTooManyRequests, get("192.0.2.1:1234", "acme-key"))
+	assert.Equal(t, http.StatusOK, get("192.0.2.2:1234", "acme-key"))
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	assert.NoError(t, err)
+
+	resolve := func(remoteAddr string, forwardedFor string) string {
+		var ip string
+		handler := ClientIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, _ = ClientIPFromContext(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return ip
+	}
+
+	t.Run("untrusted peer ignores header", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.7", resolve("203.0.113.7:1234", "198.51.100.1"))
+	})
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		assert.Equal(t, "198.51.100.1", resolve("10.0.0.2:1234", "198.51.100.1"))
+	})
+
+	t.Run("spoofed entries before the real client", func(t *testing.T) {
+		assert.Equal(t, "198.51.100.1", resolve("10.0.0.2:1234", "1.2.3.4, 198.51.100.1, 10.0.0.3"))
+	})
+}
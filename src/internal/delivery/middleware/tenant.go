@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"users-api/src/internal/delivery/codec"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+type (
+	tenantKey    struct{}
+	anonymousKey struct{}
+)
+
+type TenantAuthenticator interface {
+	Authenticate(apiKey string) (*domain.Tenant, error)
+}
+
+// Tenant authenticates requests by their API key and stores the tenant it
+// belongs to in the request context. Requests without a key act as the
+// default tenant unless requireKey is set; paths listed in public, such as
+// the API documentation, never need one.
+func Tenant(auth TenantAuthenticator, requireKey bool, public ...string) func(http.Handler) http.Handler {
+	publicPaths := make(map[string]bool, len(public))
+	for _, path := range public {
+		publicPaths[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := apiKey(r)
+			if key == "" {
+				if requireKey && !publicPaths[r.URL.Path] {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					codec.WriteError(w, r, http.StatusUnauthorized, errors.ErrUnauthenticated.Error(), "An API key is required")
+					return
+				}
+				r = withTenant(r, &domain.Tenant{ID: domain.DefaultTenantID})
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), anonymousKey{}, true)))
+				return
+			}
+
+			tenant, err := auth.Authenticate(key)
+			switch {
+			case err == errors.ErrInvalidAPIKey:
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				codec.WriteError(w, r, http.StatusUnauthorized, err.Error(), "The API key is not valid")
+				return
+			case err != nil:
+				codec.WriteError(w, r, http.StatusInternalServerError, err.Error(), "Failed to authenticate request")
+				return
+			}
+			next.ServeHTTP(w, withTenant(r, tenant))
+		})
+	}
+}
+
+func withTenant(r *http.Request, tenant *domain.Tenant) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), tenantKey{}, tenant))
+}
+
+func TenantFromContext(ctx context.Context) (*domain.Tenant, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(*domain.Tenant)
+	return tenant, ok
+}
+
+// AuthenticatedTenant is TenantFromContext for requests that presented a
+// valid API key; requests without one get false.
+func AuthenticatedTenant(ctx context.Context) (*domain.Tenant, bool) {
+	if anonymous, _ := ctx.Value(anonymousKey{}).(bool); anonymous {
+		return nil, false
+	}
+	return TenantFromContext(ctx)
+}
+
+// apiKey reads the key from X-API-Key or, as SCIM clients send it, from an
+// Authorization: Bearer header.
+func apiKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// KeyRequired rejects requests without an API key to routes that act on
+// behalf of a single user, such as MFA, whether or not keys are required
+// elsewhere. Only a tenant's backend, which authenticated the user itself,
+// may call them. Routes are "METHOD /path" or "/path" for any method.
+func KeyRequired(routes ...string) func(http.Handler) http.Handler {
+	restricted := routeSet(routes)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if restricted.has(r) {
+				if _, ok := AuthenticatedTenant(r.Context()); !ok {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					codec.WriteError(w, r, http.StatusUnauthorized, errors.ErrUnauthenticated.Error(), "An API key is required")
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DefaultTenantOnly rejects requests from other tenants to routes that
+// manage deployment-wide state. Requests without an API key act as the
+// default tenant elsewhere but are rejected here, whether or not keys are
+// required. Routes are "METHOD /path" or "/path" for any method.
+func DefaultTenantOnly(routes ...string) func(http.Handler) http.Handler {
+	restricted := routeSet(routes)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if restricted.has(r) {
+				tenant, ok := AuthenticatedTenant(r.Context())
+				if !ok {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					codec.WriteError(w, r, http.StatusUnauthorized, errors.ErrUnauthenticated.Error(), "An API key of the default tenant is required")
+					return
+				}
+				if tenant.ID != domain.DefaultTenantID {
+					codec.WriteError(w, r, http.StatusForbidden, errors.ErrForbidden.Error(), "Only the default tenant may use this endpoint")
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type routeList map[string]bool
+
+func routeSet(list []string) routeList {
+	set := make(routeList, len(list))
+	for _, route := range list {
+		set[route] = true
+	}
+	return set
+}
+
+func (set routeList) has(r *http.Request) bool {
+	return set[r.URL.Path] || set[r.Method+" "+r.URL.Path]
+}
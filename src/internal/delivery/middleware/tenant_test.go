@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantsByKey map[string]*domain.Tenant
+
+func (t tenantsByKey) Authenticate(apiKey string) (*domain.Tenant, error) {
+	if tenant, ok := t[apiKey]; ok {
+		return tenant, nil
+	}
+	return nil, errors.ErrInvalidAPIKey
+}
+
+func TestTenant(t *testing.T) {
+	auth := tenantsByKey{"acme-key": {ID: 2, Name: "Acme"}}
+
+	var got *domain.Tenant
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = TenantFromContext(r.Context())
+	})
+
+	serve := func(requireKey bool, path string, header ...string) *httptest.ResponseRecorder {
+		got = nil
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if len(header) == 2 {
+			req.Header.Set(header[0], header[1])
+		}
+		w := httptest.NewRecorder()
+		Tenant(auth, requireKey, "/docs")(next).ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("api key header", func(t *testing.T) {
+		w := serve(true, "/users", "X-API-Key", "acme-key")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(2), got.ID)
+	})
+
+	t.Run("bearer token", func(t *testing.T) {
+		w := serve(true, "/scim/v2/Users", "Authorization", "Bearer acme-key")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(2), got.ID)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		w := serve(false, "/users", "X-API-Key", "other-key")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Nil(t, got)
+	})
+
+	t.Run("errors use the negotiated format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("X-API-Key", "other-key")
+		req.Header.Set("Accept", "application/xml")
+		w := httptest.NewRecorder()
+		Tenant(auth, false)(next).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "application/xml", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "<error><error>invalid api key</error><code>401</code>")
+	})
+
+	t.Run("no key acts as the default tenant", func(t *testing.T) {
+		w := serve(false, "/users")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, domain.DefaultTenantID, got.ID)
+	})
+
+	t.Run("no key when keys are required", func(t *testing.T) {
+		w := serve(true, "/users")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+		assert.Nil(t, got)
+
+		assert.Equal(t, http.StatusOK, serve(true, "/docs").Code)
+	})
+}
+
+func TestDefaultTenantOnly(t *testing.T) {
+	handler := DefaultTenantOnly("/webhooks", "POST /users/attributes")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(tenantID int64, method, path string) int {
+		req := withTenant(httptest.NewRequest(method, path, nil), &domain.Tenant{ID: tenantID})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(domain.DefaultTenantID, http.MethodPost, "/webhooks"))
+	assert.Equal(t, http.StatusForbidden, serve(2, http.MethodGet, "/webhooks"))
+	assert.Equal(t, http.StatusForbidden, serve(2, http.MethodPost, "/users/attributes"))
+	assert.Equal(t, http.StatusOK, serve(2, http.MethodGet, "/users/attributes"))
+	assert.Equal(t, http.StatusOK, serve(2, http.MethodGet, "/users"))
+
+	t.Run("request without a key", func(t *testing.T) {
+		anonymous := Tenant(tenantsByKey{}, false)(handler)
+		serve := func(method, path string) int {
+			w := httptest.NewRecorder()
+			anonymous.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+			return w.Code
+		}
+
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "/webhooks"))
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "/users/attributes"))
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/users/attributes"))
+	})
+}
+
+func TestKeyRequired(t *testing.T) {
+	handler := Tenant(tenantsByKey{"acme-key": {ID: 2}}, false)(
+		KeyRequired("/users/mfa/enroll")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	serve := func(key, path string) int {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("", "/users/mfa/enroll"))
+	assert.Equal(t, http.StatusOK, serve("acme-key", "/users/mfa/enroll"))
+	assert.Equal(t, http.StatusOK, serve("", "/users"))
+}
@@ -0,0 +1,133 @@
+package scim
+
+const (
+	serviceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	resourceTypeSchema          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	schemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+)
+
+type supported struct {
+	Supported bool `json:"supported"`
+}
+
+type filterSupport struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type bulkSupport struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+func serviceProviderConfig(baseURL string) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":        []string{serviceProviderConfigSchema},
+		"patch":          supported{true},
+		"bulk":           bulkSupport{},
+		"filter":         filterSupport{Supported: true, MaxResults: maxCount},
+		"changePassword": supported{false},
+		"sort":           supported{false},
+		"etag":           supported{false},
+		"authenticationSchemes": []map[string]interface{}{{
+			"type":        "oauthbearertoken",
+			"name":        "API key",
+			"description": "A tenant API key sent as an Authorization: Bearer token",
+			"primary":     true,
+		}},
+		"meta": meta{
+			ResourceType: "ServiceProviderConfig",
+			Location:     baseURL + "/ServiceProviderConfig",
+		},
+	}
+}
+
+func userResourceType(baseURL string) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":     []string{resourceTypeSchema},
+		"id":          "User",
+		"name":        "User",
+		"endpoint":    "/Users",
+		"description": "User Account",
+		"schema":      userSchema,
+		"meta": meta{
+			ResourceType: "ResourceType",
+			Location:     baseURL + "/ResourceTypes/User",
+		},
+	}
+}
+
+type attribute struct {
+	Name          string      `json:"name"`
+	Type          string      `json:"type"`
+	MultiValued   bool        `json:"multiValued"`
+	Description   string      `json:"description,omitempty"`
+	Required      bool        `json:"required"`
+	CaseExact     bool        `json:"caseExact"`
+	Mutability    string      `json:"mutability"`
+	Returned      string      `json:"returned"`
+	Uniqueness    string      `json:"uniqueness"`
+	SubAttributes []attribute `json:"subAttributes,omitempty"`
+}
+
+func stringAttribute(name, description string, required bool, uniqueness string) attribute {
+	return attribute{
+		Name:        name,
+		Type:        "string",
+		Description: description,
+		Required:    required,
+		Mutability:  "readWrite",
+		Returned:    "default",
+		Uniqueness:  uniqueness,
+	}
+}
+
+// userSchemaResource describes the attributes of the core User schema that
+// this service stores.
+func userSchemaResource(baseURL string) map[string]interface{} {
+	primary := attribute{Name: "primary", Type: "boolean", Mutability: "readWrite", Returned: "default", Uniqueness: "none"}
+	active := attribute{Name: "active", Type: "boolean", Description: "Whether the user can use the service.", Mutability: "readWrite", Returned: "default", Uniqueness: "none"}
+
+	return map[string]interface{}{
+		"schemas":     []string{schemaSchema},
+		"id":          userSchema,
+		"name":        "User",
+		"description": "User Account",
+		"attributes": []attribute{
+			stringAttribute("userName", "The user's email address.", true, "server"),
+			{
+				Name:       "name",
+				Type:       "complex",
+				Mutability: "readWrite",
+				Returned:   "default",
+				Uniqueness: "none",
+				SubAttributes: []attribute{
+					stringAttribute("formatted", "The full name.", false, "none"),
+					stringAttribute("givenName", "Combined with familyName when formatted is absent.", false, "none"),
+					stringAttribute("familyName", "Combined with givenName when formatted is absent.", false, "none"),
+				},
+			},
+			stringAttribute("displayName", "The full name.", false, "none"),
+			{
+				Name:        "emails",
+				Type:        "complex",
+				MultiValued: true,
+				Description: "Only the primary email is stored; it is the same as userName.",
+				Mutability:  "readWrite",
+				Returned:    "default",
+				Uniqueness:  "none",
+				SubAttributes: []attribute{
+					stringAttribute("value", "", false, "server"),
+					stringAttribute("type", "", false, "none"),
+					primary,
+				},
+			},
+			active,
+		},
+		"meta": meta{
+			ResourceType: "Schema",
+			Location:     baseURL + "/Schemas/" + userSchema,
+		},
+	}
+}
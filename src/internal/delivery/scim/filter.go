@@ -0,0 +1,123 @@
+package scim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"users-api/src/internal/domain"
+)
+
+// parseFilter translates the subset of RFC 7644 filter expressions that maps
+// onto domain.UserFilter: comparisons on userName, emails[.value],
+// displayName, name.formatted and active joined with "and". Attribute names
+// and operators are case-insensitive.
+func parseFilter(expr string) (domain.UserFilter, error) {
+	var filter domain.UserFilter
+	if strings.TrimSpace(expr) == "" {
+		return filter, nil
+	}
+
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return filter, err
+	}
+
+	for len(tokens) > 0 {
+		if len(tokens) < 3 {
+			return filter, fmt.Errorf("incomplete filter expression")
+		}
+		attr, op, value := strings.ToLower(tokens[0].text), strings.ToLower(tokens[1].text), tokens[2]
+		if err := applyComparison(&filter, attr, op, value); err != nil {
+			return filter, err
+		}
+		tokens = tokens[3:]
+
+		if len(tokens) > 0 {
+			if strings.ToLower(tokens[0].text) != "and" || tokens[0].quoted {
+				return filter, fmt.Errorf("only \"and\" is supported between comparisons")
+			}
+			tokens = tokens[1:]
+			if len(tokens) == 0 {
+				return filter, fmt.Errorf("incomplete filter expression")
+			}
+		}
+	}
+	return filter, nil
+}
+
+func applyComparison(filter *domain.UserFilter, attr, op string, value filterToken) error {
+	switch attr {
+	case "username", "emails", "emails.value":
+		if !value.quoted {
+			return fmt.Errorf("%s must be compared with a string", attr)
+		}
+		switch op {
+		case "eq":
+			filter.Email = value.text
+		case "co":
+			filter.EmailContains = value.text
+		default:
+			return fmt.Errorf("operator %q is not supported for %s", op, attr)
+		}
+	case "displayname", "name.formatted":
+		if !value.quoted || op != "co" {
+			return fmt.Errorf("%s supports only co with a string", attr)
+		}
+		filter.NameContains = value.text
+	case "active":
+		active, err := strconv.ParseBool(value.text)
+		if err != nil || value.quoted || op != "eq" {
+			return fmt.Errorf("active supports only eq with true or false")
+		}
+		if active {
+			filter.Status = domain.UserStatusActive
+		} else {
+			filter.Statuses = inactiveStatuses
+		}
+	default:
+		return fmt.Errorf("filtering on %q is not supported", attr)
+	}
+	return nil
+}
+
+type filterToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		switch {
+		case unicode.IsSpace(runes[i]):
+			i++
+		case runes[i] == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			text, err := strconv.Unquote(string(runes[i : j+1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid string in filter")
+			}
+			tokens = append(tokens, filterToken{text: text, quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && runes[j] != '"' {
+				j++
+			}
+			tokens = append(tokens, filterToken{text: string(runes[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,49 @@
+package scim
+
+import (
+	"testing"
+
+	"users-api/src/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    domain.UserFilter
+		wantErr bool
+	}{
+		{name: "Empty", expr: "", want: domain.UserFilter{}},
+		{name: "UserNameEq", expr: `userName eq "bjensen@example.com"`, want: domain.UserFilter{Email: "bjensen@example.com"}},
+		{name: "CaseInsensitiveAttribute", expr: `USERNAME EQ "bjensen@example.com"`, want: domain.UserFilter{Email: "bjensen@example.com"}},
+		{name: "EmailsCo", expr: `emails co "example.com"`, want: domain.UserFilter{EmailContains: "example.com"}},
+		{name: "EmailsValueCo", expr: `emails.value co "example.com"`, want: domain.UserFilter{EmailContains: "example.com"}},
+		{name: "DisplayNameCo", expr: `displayName co "Jen"`, want: domain.UserFilter{NameContains: "Jen"}},
+		{name: "ActiveFalse", expr: `active eq false`, want: domain.UserFilter{Statuses: inactiveStatuses}},
+		{
+			name: "And",
+			expr: `emails co "example.com" and active eq true`,
+			want: domain.UserFilter{EmailContains: "example.com", Status: domain.UserStatusActive},
+		},
+		{name: "EscapedQuote", expr: `displayName co "a\"b"`, want: domain.UserFilter{NameContains: `a"b`}},
+		{name: "UnsupportedOperator", expr: `userName sw "b"`, wantErr: true},
+		{name: "UnsupportedAttribute", expr: `title eq "Boss"`, wantErr: true},
+		{name: "Or", expr: `userName eq "a" or userName eq "b"`, wantErr: true},
+		{name: "Unterminated", expr: `userName eq "a`, wantErr: true},
+		{name: "Incomplete", expr: `userName eq`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFilter(tt.expr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
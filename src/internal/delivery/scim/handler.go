@@ -0,0 +1,342 @@
+package scim
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"strconv"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+const (
+	contentType = "application/scim+json"
+
+	// Prefix is the path the handler must be mounted on.
+	Prefix = "/scim/v2"
+
+	defaultCount    = 100
+	maxCount        = 1000
+	maxRequestBytes = 1 << 20
+)
+
+type UserService interface {
+	CreateUser(user *domain.User) error
+	GetUser(id int64) (*domain.User, error)
+	ListUsers(filter domain.UserFilter) ([]*domain.User, error)
+	CountUsers(filter domain.UserFilter) (int, error)
+	UpdateUser(user *domain.User) error
+	DeleteUser(id int64) error
+}
+
+// Handler serves the SCIM 2.0 (RFC 7643/7644) Users endpoint and the
+// discovery endpoints under Prefix.
+type Handler struct {
+	userService UserService
+	mux         *http.ServeMux
+}
+
+func NewHandler(userService UserService) *Handler {
+	h := &Handler{userService: userService, mux: http.NewServeMux()}
+
+	h.mux.HandleFunc("GET "+Prefix+"/Users", h.listUsers)
+	h.mux.HandleFunc("POST "+Prefix+"/Users", h.createUser)
+	h.mux.HandleFunc("GET "+Prefix+"/Users/{id}", h.getUser)
+	h.mux.HandleFunc("PUT "+Prefix+"/Users/{id}", h.replaceUser)
+	h.mux.HandleFunc("PATCH "+Prefix+"/Users/{id}", h.patchUser)
+	h.mux.HandleFunc("DELETE "+Prefix+"/Users/{id}", h.deleteUser)
+
+	h.mux.HandleFunc("GET "+Prefix+"/ServiceProviderConfig", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, serviceProviderConfig(baseURL(r)))
+	})
+	h.mux.HandleFunc("GET "+Prefix+"/ResourceTypes", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, singleton(userResourceType(baseURL(r))))
+	})
+	h.mux.HandleFunc("GET "+Prefix+"/ResourceTypes/User", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, userResourceType(baseURL(r)))
+	})
+	h.mux.HandleFunc("GET "+Prefix+"/Schemas", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, singleton(userSchemaResource(baseURL(r))))
+	})
+	h.mux.HandleFunc("GET "+Prefix+"/Schemas/"+userSchema, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, userSchemaResource(baseURL(r)))
+	})
+	h.mux.HandleFunc(Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, &scimError{status: http.StatusNotFound, detail: "Resource not found"})
+	})
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// listUsers pages with the 1-based startIndex and count parameters. A count
+// of 0 only reports totalResults.
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := parseFilter(query.Get("filter"))
+	if err != nil {
+		writeError(w, badRequest("invalidFilter", err.Error()))
+		return
+	}
+
+	startIndex, count := 1, defaultCount
+	if v := query.Get("startIndex"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, badRequest("invalidValue", "startIndex must be an integer"))
+			return
+		}
+		if n > 1 {
+			startIndex = n
+		}
+	}
+	if v := query.Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, badRequest("invalidValue", "count must be an integer"))
+			return
+		}
+		count = min(max(n, 0), maxCount)
+	}
+
+	total, err := h.userService.CountUsers(filter)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resources := []*userResource{}
+	if count > 0 && startIndex <= total {
+		filter.Limit, filter.Offset = count, startIndex-1
+		users, err := h.userService.ListUsers(filter)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		base := baseURL(r)
+		for _, user := range users {
+			resources = append(resources, toResource(user, base))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, listResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
+	var res userResource
+	if err := decode(w, r, &res); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Users cannot be created deactivated; an inactive one starts pending.
+	user := res.toUser()
+	if user.Status == domain.UserStatusDeactivated {
+		user.Status = domain.UserStatusPending
+	}
+	if err := h.userService.CreateUser(user); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resource := toResource(user, baseURL(r))
+	w.Header().Set("Location", resource.Meta.Location)
+	writeJSON(w, http.StatusCreated, resource)
+}
+
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetUser(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toResource(user, baseURL(r)))
+}
+
+// replaceUser implements PUT. Name and email are required by the service
+// anyway, so replacing them is the same as updating them.
+func (h *Handler) replaceUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var res userResource
+	if err := decode(w, r, &res); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	user := res.toUser()
+	if user.Email == "" || user.Name == "" {
+		writeError(w, badRequest("invalidValue", "userName and a name are required"))
+		return
+	}
+	user.ID = id
+	if err := h.keepInactive(user); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if err := h.userService.UpdateUser(user); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toResource(user, baseURL(r)))
+}
+
+func (h *Handler) patchUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var req patchRequest
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	user := &domain.User{ID: id}
+	if err := applyPatch(user, req.Operations); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := h.keepInactive(user); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if err := h.userService.UpdateUser(user); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toResource(user, baseURL(r)))
+}
+
+func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.DeleteUser(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// keepInactive drops a request to deactivate a user that is already inactive,
+// so that identity providers sending active=false on every sync do not turn
+// a pending or suspended user into a deactivated one.
+func (h *Handler) keepInactive(user *domain.User) error {
+	if user.Status != domain.UserStatusDeactivated {
+		return nil
+	}
+	current, err := h.userService.GetUser(user.ID)
+	if err != nil {
+		return err
+	}
+	if current.Status != domain.UserStatusActive {
+		user.Status = ""
+	}
+	return nil
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, &scimError{status: http.StatusNotFound, detail: "Resource not found"})
+		return 0, false
+	}
+	return id, true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) *scimError {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(v); err != nil {
+		return badRequest("invalidSyntax", "Request body is not valid JSON")
+	}
+	return nil
+}
+
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + Prefix
+}
+
+func singleton(resource interface{}) listResponse {
+	return listResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: 1,
+		StartIndex:   1,
+		ItemsPerPage: 1,
+		Resources:    []interface{}{resource},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+type errorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, err *scimError) {
+	writeJSON(w, err.status, errorResponse{
+		Schemas:  []string{errorSchema},
+		Status:   strconv.Itoa(err.status),
+		ScimType: err.scimType,
+		Detail:   err.detail,
+	})
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	if stdErrors.Is(err, errors.ErrInvalidAttribute) {
+		writeError(w, badRequest("invalidValue", err.Error()))
+		return
+	}
+	switch err {
+	case errors.ErrUserNotFound:
+		writeError(w, &scimError{status: http.StatusNotFound, detail: "Resource not found"})
+	case errors.ErrInvalidInput:
+		writeError(w, badRequest("invalidValue", "Invalid attribute value"))
+	case errors.ErrInvalidEmail:
+		writeError(w, badRequest("invalidValue", "userName must be a valid email address"))
+	case errors.ErrEmailRejected:
+		writeError(w, badRequest("invalidValue", "userName is not an accepted email address"))
+	case errors.ErrEmailTaken:
+		writeError(w, &scimError{status: http.StatusConflict, scimType: "uniqueness", detail: "userName is already in use"})
+	case errors.ErrInvalidStatusTransition:
+		writeError(w, badRequest("invalidValue", "active cannot be changed from the user's current status"))
+	default:
+		writeError(w, &scimError{status: http.StatusInternalServerError, detail: "Internal server error"})
+	}
+}
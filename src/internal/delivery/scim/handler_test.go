@@ -0,0 +1,275 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockUserService struct {
+	mock.Mock
+}
+
+func (m *MockUserService) CreateUser(user *domain.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserService) GetUser(id int64) (*domain.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserService) ListUsers(filter domain.UserFilter) ([]*domain.User, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockUserService) CountUsers(filter domain.UserFilter) (int, error) {
+	args := m.Called(filter)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserService) UpdateUser(user *domain.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserService) DeleteUser(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	return body
+}
+
+func TestCreateUser(t *testing.T) {
+	service := new(MockUserService)
+	h := NewHandler(service)
+
+	service.On("CreateUser", &domain.User{Name: "Barbara Jensen", Email: "bjensen@example.com", Status: domain.UserStatusPending}).
+		Run(func(args mock.Arguments) {
+			user := args.Get(0).(*domain.User)
+			user.ID = 7
+			user.CreatedAt = "2024-01-01T00:00:00Z"
+		}).Return(nil)
+
+	w := serve(h, http.MethodPost, "/scim/v2/Users", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "bjensen",
+		"name": {"givenName": "Barbara", "familyName": "Jensen"},
+		"emails": [{"value": "other@example.com"}, {"value": "bjensen@example.com", "primary": true}],
+		"active": false
+	}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, contentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "http://example.com/scim/v2/Users/7", w.Header().Get("Location"))
+	body := decodeBody(t, w)
+	assert.Equal(t, "7", body["id"])
+	assert.Equal(t, "bjensen@example.com", body["userName"])
+	assert.Equal(t, false, body["active"])
+	service.AssertExpectations(t)
+}
+
+func TestCreateUserConflict(t *testing.T) {
+	service := new(MockUserService)
+	h := NewHandler(service)
+
+	service.On("CreateUser", mock.Anything).Return(errors.ErrEmailTaken)
+
+	w := serve(h, http.MethodPost, "/scim/v2/Users", `{"userName": "bjensen@example.com", "displayName": "Babs"}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	body := decodeBody(t, w)
+	assert.Equal(t, []interface{}{errorSchema}, body["schemas"])
+	assert.Equal(t, "409", body["status"])
+	assert.Equal(t, "uniqueness", body["scimType"])
+}
+
+func TestGetUserNotFound(t *testing.T) {
+	service := new(MockUserService)
+	h := NewHandler(service)
+
+	service.On("GetUser", int64(5)).Return(nil, errors.ErrUserNotFound)
+
+	w := serve(h, http.MethodGet, "/scim/v2/Users/5", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "404", decodeBody(t, w)["status"])
+
+	w = serve(h, http.MethodGet, "/scim/v2/Users/abc", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListUsers(t *testing.T) {
+	service := new(MockUserService)
+	h := NewHandler(service)
+
+	filter := domain.UserFilter{EmailContains: "example.com"}
+	service.On("CountUsers", filter).Return(12, nil)
+	service.On("ListUsers", domain.UserFilter{EmailContains: "example.com", Limit: 5, Offset: 10}).
+		Return([]*domain.User{{ID: 11, Name: "A", Email: "a@example.com", Status: domain.UserStatusActive}}, nil)
+
+	w := serve(h, http.MethodGet, `/scim/v2/Users?filter=emails+co+%22example.com%22&startIndex=11&count=5`, "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := decodeBody(t, w)
+	assert.Equal(t, []interface{}{listResponseSchema}, body["schemas"])
+	assert.Equal(t, float64(12), body["totalResults"])
+	assert.Equal(t, float64(11), body["startIndex"])
+	assert.Equal(t, float64(1), body["itemsPerPage"])
+	assert.Len(t, body["Resources"], 1)
+	service.AssertExpectations(t)
+}
+
+func TestListUsersCountOnly(t *testing.T) {
+	service := new(MockUserService)
+	h := NewHandler(service)
+
+	service.On("CountUsers", domain.UserFilter{Email: "a@example.com"}).Return(1, nil)
+
+	w := serve(h, http.MethodGet, `/scim/v2/Users?filter=userName+eq+%22a@example.com%22&count=0`, "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := decodeBody(t, w)
+	assert.Equal(t, float64(1), body["totalResults"])
+	assert.Empty(t, body["Resources"])
+	service.AssertNotCalled(t, "ListUsers", mock.Anything)
+}
+
+func TestListUsersInvalidFilter(t *testing.T) {
+	h := NewHandler(new(MockUserService))
+
+	w := serve(h, http.MethodGet, `/scim/v2/Users?filter=title+pr`, "")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalidFilter", decodeBody(t, w)["scimType"])
+}
+
+func TestReplaceUser(t *testing.T) {
+	service := new(MockUserService)
+	h := NewHandler(service)
+
+	service.On("UpdateUser", &domain.User{ID: 3, Name: "New Name", Email: "new@example.com", Status: domain.UserStatusActive}).Return(nil)
+
+	w := serve(h, http.MethodPut, "/scim/v2/Users/3", `{"userName": "new@example.com", "name": {"formatted": "New Name"}}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	service.AssertExpectations(t)
+}
+
+func TestPatchUser(t *testing.T) {
+	service := new(MockUserService)
+	h := NewHandler(service)
+
+	service.On("GetUser", int64(3)).Return(&domain.User{ID: 3, Status: domain.UserStatusActive}, nil)
+	service.On("UpdateUser", &domain.User{ID: 3, Name: "Renamed", Status: domain.UserStatusDeactivated}).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*domain.User).Email = "a@example.com"
+		}).Return(nil)
+
+	w := serve(h, http.MethodPatch, "/scim/v2/Users/3", `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "replace", "path": "active", "value": false},
+			{"op": "Replace", "value": {"displayName": "Renamed"}}
+		]
+	}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := decodeBody(t, w)
+	assert.Equal(t, false, body["active"])
+	assert.Equal(t, "Renamed", body["displayName"])
+	service.AssertExpectations(t)
+}
+
+func TestDeactivateInactiveUser(t *testing.T) {
+	service := new(MockUserService)
+	h := NewHandler(service)
+
+	// A suspended user stays suspended rather than being deactivated.
+	service.On("GetUser", int64(4)).Return(&domain.User{ID: 4, Status: domain.UserStatusSuspended}, nil)
+	service.On("UpdateUser", &domain.User{ID: 4}).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*domain.User).Status = domain.UserStatusSuspended
+		}).Return(nil)
+
+	w := serve(h, http.MethodPatch, "/scim/v2/Users/4", `{"Operations": [{"op": "replace", "path": "active", "value": false}]}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, false, decodeBody(t, w)["active"])
+	service.AssertExpectations(t)
+}
+
+func TestPatchUserInvalid(t *testing.T) {
+	h := NewHandler(new(MockUserService))
+
+	tests := []struct {
+		name     string
+		body     string
+		scimType string
+	}{
+		{name: "UnknownPath", body: `{"Operations": [{"op": "replace", "path": "title", "value": "x"}]}`, scimType: "invalidPath"},
+		{name: "Remove", body: `{"Operations": [{"op": "remove", "path": "displayName"}]}`, scimType: "mutability"},
+		{name: "BadJSON", body: `{`, scimType: "invalidSyntax"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, http.MethodPatch, "/scim/v2/Users/3", tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, tt.scimType, decodeBody(t, w)["scimType"])
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	service := new(MockUserService)
+	h := NewHandler(service)
+
+	service.On("DeleteUser", int64(3)).Return(nil)
+
+	w := serve(h, http.MethodDelete, "/scim/v2/Users/3", "")
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	service.AssertExpectations(t)
+}
+
+func TestDiscovery(t *testing.T) {
+	h := NewHandler(new(MockUserService))
+
+	for _, path := range []string{"/scim/v2/ServiceProviderConfig", "/scim/v2/ResourceTypes", "/scim/v2/Schemas", "/scim/v2/Schemas/" + userSchema} {
+		w := serve(h, http.MethodGet, path, "")
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, contentType, w.Header().Get("Content-Type"), path)
+	}
+
+	w := serve(h, http.MethodGet, "/scim/v2/Groups", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
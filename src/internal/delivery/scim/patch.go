@@ -0,0 +1,128 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"users-api/src/internal/domain"
+)
+
+type patchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations"`
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// applyPatch folds add and replace operations into update, which is then
+// passed to UpdateUser; attributes left empty there keep their value. Since
+// every supported attribute is required, remove is rejected.
+func applyPatch(update *domain.User, ops []patchOperation) *scimError {
+	if len(ops) == 0 {
+		return badRequest("invalidSyntax", "Operations must not be empty")
+	}
+	for _, op := range ops {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		case "remove":
+			return badRequest("mutability", "Attributes of this resource cannot be removed")
+		default:
+			return badRequest("invalidSyntax", "Unsupported operation "+strconv.Quote(op.Op))
+		}
+
+		if op.Path != "" {
+			if err := applyPath(update, op.Path, op.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attrs); err != nil {
+			return badRequest("invalidValue", "Operation without a path needs an object value")
+		}
+		for path, value := range attrs {
+			if err := applyPath(update, path, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func applyPath(update *domain.User, path string, value json.RawMessage) *scimError {
+	lower := strings.ToLower(path)
+	switch {
+	case lower == "username", strings.HasPrefix(lower, "emails[") && strings.HasSuffix(lower, "].value"):
+		return decodeString(value, path, &update.Email)
+	case lower == "displayname", lower == "name.formatted":
+		return decodeString(value, path, &update.Name)
+	case lower == "name":
+		var n name
+		if err := json.Unmarshal(value, &n); err != nil {
+			return badRequest("invalidValue", "name must be an object")
+		}
+		update.Name = n.full()
+	case lower == "emails":
+		var emails []email
+		if err := json.Unmarshal(value, &emails); err != nil {
+			return badRequest("invalidValue", "emails must be an array")
+		}
+		update.Email = (&userResource{Emails: emails}).primaryEmail()
+	case lower == "active":
+		active, err := decodeBool(value)
+		if err != nil {
+			return badRequest("invalidValue", "active must be a boolean")
+		}
+		update.Status = domain.UserStatusActive
+		if !active {
+			update.Status = domain.UserStatusDeactivated
+		}
+	case lower == "externalid":
+		// Not stored; accepted so identity providers that always send it work.
+	default:
+		return badRequest("invalidPath", "Unsupported path "+strconv.Quote(path))
+	}
+	return nil
+}
+
+func decodeString(value json.RawMessage, path string, dst *string) *scimError {
+	if err := json.Unmarshal(value, dst); err != nil {
+		return badRequest("invalidValue", path+" must be a string")
+	}
+	return nil
+}
+
+// decodeBool also accepts "True" and "False" strings, which some identity
+// providers send for boolean attributes.
+func decodeBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(strings.ToLower(s))
+}
+
+type scimError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func (e *scimError) Error() string {
+	return e.detail
+}
+
+func badRequest(scimType, detail string) *scimError {
+	return &scimError{status: http.StatusBadRequest, scimType: scimType, detail: detail}
+}
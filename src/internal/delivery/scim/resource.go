@@ -0,0 +1,124 @@
+package scim
+
+import (
+	"strconv"
+	"strings"
+
+	"users-api/src/internal/domain"
+)
+
+const (
+	userSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	listResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	patchOpSchema      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	errorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// inactiveStatuses are the statuses SCIM reports as active=false.
+var inactiveStatuses = []string{domain.UserStatusPending, domain.UserStatusSuspended, domain.UserStatusDeactivated}
+
+type userResource struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	UserName    string   `json:"userName"`
+	Name        *name    `json:"name,omitempty"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Emails      []email  `json:"emails,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+	Meta        *meta    `json:"meta,omitempty"`
+}
+
+type name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type listResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex,omitempty"`
+	ItemsPerPage int         `json:"itemsPerPage,omitempty"`
+	Resources    interface{} `json:"Resources"`
+}
+
+// toResource maps a user to the SCIM core schema. The email doubles as the
+// userName, and the single name field is reported as both displayName and
+// name.formatted.
+func toResource(user *domain.User, baseURL string) *userResource {
+	active := user.Status == domain.UserStatusActive
+	id := strconv.FormatInt(user.ID, 10)
+	return &userResource{
+		Schemas:     []string{userSchema},
+		ID:          id,
+		UserName:    user.Email,
+		Name:        &name{Formatted: user.Name},
+		DisplayName: user.Name,
+		Emails:      []email{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &meta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+			Location:     baseURL + "/Users/" + id,
+		},
+	}
+}
+
+// toUser maps a SCIM resource to a user. The email is the primary email,
+// falling back to the first one and then to userName; the name is taken from
+// displayName, name.formatted or the given and family names, in that order.
+// A missing active attribute means active.
+func (res *userResource) toUser() *domain.User {
+	user := &domain.User{
+		Email:  res.primaryEmail(),
+		Name:   res.displayName(),
+		Status: domain.UserStatusActive,
+	}
+	if res.Active != nil && !*res.Active {
+		user.Status = domain.UserStatusDeactivated
+	}
+	return user
+}
+
+func (res *userResource) primaryEmail() string {
+	for _, e := range res.Emails {
+		if e.Primary && e.Value != "" {
+			return e.Value
+		}
+	}
+	if len(res.Emails) > 0 && res.Emails[0].Value != "" {
+		return res.Emails[0].Value
+	}
+	return res.UserName
+}
+
+func (res *userResource) displayName() string {
+	if res.DisplayName != "" {
+		return res.DisplayName
+	}
+	if res.Name != nil {
+		return res.Name.full()
+	}
+	return ""
+}
+
+func (n *name) full() string {
+	if n.Formatted != "" {
+		return n.Formatted
+	}
+	return strings.TrimSpace(n.GivenName + " " + n.FamilyName)
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeInteger = "integer"
+	AttributeTypeBoolean = "boolean"
+)
+
+// Attributes holds a user's custom attribute values by name: strings,
+// numbers or booleans, as declared by their AttributeDefinition.
+type Attributes map[string]interface{}
+
+type xmlAttribute struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// MarshalXML writes the attributes as <attribute name="...">value</attribute>
+// elements, sorted by name.
+func (a Attributes) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	items := make([]xmlAttribute, len(names))
+	for i, name := range names {
+		items[i] = xmlAttribute{Name: name, Value: fmt.Sprint(a[name])}
+	}
+	return e.EncodeElement(struct {
+		Items []xmlAttribute `xml:"attribute"`
+	}{items}, start)
+}
+
+// UnmarshalXML reads the elements written by MarshalXML. Every value is read
+// as a string; UserService converts it to the attribute's type.
+func (a *Attributes) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v struct {
+		Items []xmlAttribute `xml:"attribute"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*a = make(Attributes, len(v.Items))
+	for _, item := range v.Items {
+		(*a)[item.Name] = item.Value
+	}
+	return nil
+}
+
+// AttributeDefinition declares a custom user attribute. Pattern and Enum only
+// apply to string attributes.
+type AttributeDefinition struct {
+	XMLName     xml.Name  `json:"-" xml:"attribute_definition"`
+	Name        string    `json:"name" xml:"name"`
+	Type        string    `json:"type" xml:"type"`
+	Required    bool      `json:"required" xml:"required"`
+	Pattern     string    `json:"pattern,omitempty" xml:"pattern,omitempty"`
+	Enum        []string  `json:"enum,omitempty" xml:"enum>value,omitempty"`
+	Description string    `json:"description,omitempty" xml:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at" xml:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" xml:"updated_at"`
+}
+
+type AttributeDefinitionRepository interface {
+	Create(def *AttributeDefinition) error
+	Get(name string) (*AttributeDefinition, error)
+	List() ([]*AttributeDefinition, error)
+	Update(def *AttributeDefinition) error
+	Delete(name string) error
+}
@@ -0,0 +1,36 @@
+package domain
+
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+const (
+	BatchStatusCreated    = "created"
+	BatchStatusUpdated    = "updated"
+	BatchStatusDeleted    = "deleted"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back"
+)
+
+type BatchOperation struct {
+	Op   string `json:"op" xml:"op"`
+	ID   int64  `json:"id,omitempty" xml:"id,omitempty"`
+	User *User  `json:"user,omitempty" xml:"user,omitempty"`
+}
+
+type BatchResult struct {
+	Index  int    `json:"index" xml:"index"`
+	Op     string `json:"op" xml:"op"`
+	Status string `json:"status" xml:"status"`
+	ID     int64  `json:"id,omitempty" xml:"id,omitempty"`
+	Error  string `json:"error,omitempty" xml:"error,omitempty"`
+	User   *User  `json:"user,omitempty" xml:"user,omitempty"`
+}
+
+// UserBulkCreator is implemented by repositories that can insert many users
+// more efficiently than one Create call per user.
+type UserBulkCreator interface {
+	BulkCreate(users []*User) error
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+type EmailVerificationToken struct {
+	ID        int64
+	UserID    int64
+	TenantID  int64
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type EmailVerificationRepository interface {
+	Create(token *EmailVerificationToken) error
+	GetByHash(hash string) (*EmailVerificationToken, error)
+	MarkUsed(id int64) (bool, error)
+}
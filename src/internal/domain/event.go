@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+const (
+	UserEventCreated = "user.created"
+	UserEventUpdated = "user.updated"
+	UserEventDeleted = "user.deleted"
+)
+
+// UserEvent describes a committed change to a user. User holds the state
+// after the change and is nil for deletions. ID is assigned by the change log
+// and increases monotonically; it is zero if the event was not persisted.
+type UserEvent struct {
+	ID         int64     `json:"id"`
+	Type       string    `json:"type"`
+	UserID     int64     `json:"user_id"`
+	User       *User     `json:"user,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type UserEventRepository interface {
+	Append(event *UserEvent) error
+	// ListAfter returns up to limit events with an ID greater than afterID in
+	// ascending order, restricted to one user when userID is not zero.
+	ListAfter(afterID, userID int64, limit int) ([]*UserEvent, error)
+}
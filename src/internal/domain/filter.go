@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+type UserFilter struct {
+	NameContains  string
+	EmailContains string
+	// Email matches the whole address, ignoring case.
+	Email  string
+	Status string
+	// Statuses matches users in any of these statuses.
+	Statuses      []string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Attributes matches users whose custom attributes have all of these
+	// values.
+	Attributes Attributes
+	Limit      int
+	Offset     int
+}
+
+// UserStreamer is implemented by repositories that can iterate over a large
+// result set without loading it into memory.
+type UserStreamer interface {
+	Stream(filter UserFilter, fn func(user *User) error) error
+}
+
+// UserCounter is implemented by repositories that can count the users
+// matching a filter without loading them.
+type UserCounter interface {
+	Count(filter UserFilter) (int, error)
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Group is a named set of users of a tenant. A group nested in a parent
+// group passes its members on to the parent and every group above it.
+type Group struct {
+	XMLName     xml.Name  `json:"-" xml:"group"`
+	ID          int64     `json:"id" xml:"id"`
+	Name        string    `json:"name" xml:"name"`
+	Description string    `json:"description,omitempty" xml:"description,omitempty"`
+	ParentID    *int64    `json:"parent_id" xml:"parent_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at" xml:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" xml:"updated_at"`
+}
+
+// GroupMember reports that a user belongs to a group. Direct is false when
+// the user only belongs to it through a subgroup; CreatedAt is then nil.
+type GroupMember struct {
+	XMLName   xml.Name   `json:"-" xml:"group_member"`
+	GroupID   int64      `json:"group_id" xml:"group_id"`
+	UserID    int64      `json:"user_id" xml:"user_id"`
+	Direct    bool       `json:"direct" xml:"direct"`
+	CreatedAt *time.Time `json:"created_at,omitempty" xml:"created_at,omitempty"`
+}
+
+// UserGroup is a group a user belongs to, directly or through a subgroup.
+type UserGroup struct {
+	Group
+	Direct bool `json:"direct" xml:"direct"`
+}
+
+type GroupFilter struct {
+	Limit  int
+	Offset int
+}
+
+type GroupRepository interface {
+	Create(group *Group) error
+	GetByID(id int64) (*Group, error)
+	List(filter GroupFilter) ([]*Group, error)
+	// Update fails with ErrGroupCycle when the new parent is the group
+	// itself or one of its subgroups.
+	Update(group *Group) error
+	// Delete fails with ErrGroupHasSubgroups while other groups are nested
+	// in the group.
+	Delete(id int64) error
+
+	// GetMember returns the direct membership of a user in a group, or nil.
+	GetMember(groupID, userID int64) (*GroupMember, error)
+	// AddMember reports false when the user already was a direct member,
+	// filling in the existing membership.
+	AddMember(member *GroupMember) (bool, error)
+	RemoveMember(groupID, userID int64) error
+	// ListMembers returns the direct members of a group ordered by ID.
+	ListMembers(groupID int64, filter GroupFilter) ([]*User, error)
+	// ListUserGroups returns the groups a user belongs to directly and the
+	// groups above them, ordered by ID.
+	ListUserGroups(userID int64) ([]*UserGroup, error)
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+type IdempotencyRecord struct {
+	Key          string
+	Fingerprint  string
+	StatusCode   *int
+	ContentType  string
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+type IdempotencyRepository interface {
+	Reserve(record *IdempotencyRecord) (bool, error)
+	Get(key string) (*IdempotencyRecord, error)
+	Complete(key string, statusCode int, contentType string, body []byte) error
+	Release(key string) error
+	DeleteExpired(before time.Time) (int64, error)
+}
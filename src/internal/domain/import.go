@@ -0,0 +1,15 @@
+package domain
+
+const (
+	ImportStatusCreated     = "created"
+	ImportStatusUpdated     = "updated"
+	ImportStatusUnchanged   = "unchanged"
+	ImportStatusWouldCreate = "would_create"
+	ImportStatusWouldUpdate = "would_update"
+	ImportStatusFailed      = "failed"
+)
+
+type ImportOptions struct {
+	DryRun bool
+	Upsert bool
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+const (
+	LockoutScopeUser = "user"
+	LockoutScopeIP   = "ip"
+)
+
+type LockoutState struct {
+	Scope         string
+	Subject       string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+type LockoutRepository interface {
+	// Reserve counts an attempt as a failure before its outcome is known and
+	// returns the state after it. While the subject is locked at at nothing
+	// is counted, and LockedUntil says until when.
+	Reserve(scope, subject string, at time.Time, window time.Duration) (*LockoutState, error)
+	Lock(scope, subject string, until time.Time) error
+	// Refund takes back the reserved attempt that brought the count to
+	// failures, lifting the lock as well unless a later attempt was reserved.
+	Refund(scope, subject string, failures int) error
+	Reset(scope, subject string) error
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type UserMFA struct {
+	UserID       int64
+	Secret       string
+	EnabledAt    *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+type MFAEnrollment struct {
+	XMLName xml.Name `json:"-" xml:"mfa_enrollment"`
+	Secret  string   `json:"secret" xml:"secret"`
+	URI     string   `json:"otpauth_uri" xml:"otpauth_uri"`
+}
+
+type MFARepository interface {
+	Get(userID int64) (*UserMFA, error)
+	Enroll(mfa *UserMFA) (bool, error)
+	Enable(userID int64, secret string, enabledAt time.Time, step int64) (bool, error)
+	Delete(userID int64) error
+	AdvanceStep(userID int64, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID int64, hashes []string) error
+	UseRecoveryCode(userID int64, hash string) (bool, error)
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// UserEventOutbox holds logged user events that still have to be published to
+// the message broker.
+type UserEventOutbox interface {
+	Add(event *UserEvent) error
+	// Relay passes up to limit of the oldest pending events to publish in ID
+	// order and removes the ones whose IDs publish returns, holding a lock so
+	// that only one relay runs at a time. Users with an event publish did not
+	// return are left out of the following batches for retryAfter. It returns
+	// how many events were published, which is zero when another relay holds
+	// the lock.
+	Relay(limit int, retryAfter time.Duration, publish func(events []*UserEvent) []int64) (int, error)
+}
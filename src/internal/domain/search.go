@@ -0,0 +1,20 @@
+package domain
+
+import "encoding/xml"
+
+// UserSearchResult is a user matching a free-text search. Score ranks the
+// results, from 0 to 1; the highlights hold the name and email, HTML-escaped,
+// with the matching parts wrapped in <mark>.
+type UserSearchResult struct {
+	XMLName        xml.Name `json:"-" xml:"result"`
+	User           *User    `json:"user" xml:"user"`
+	Score          float64  `json:"score" xml:"score"`
+	NameHighlight  string   `json:"name_highlight,omitempty" xml:"name_highlight,omitempty"`
+	EmailHighlight string   `json:"email_highlight,omitempty" xml:"email_highlight,omitempty"`
+}
+
+// UserSearcher is implemented by repositories that can search users by
+// partial or misspelled names and emails, best match first.
+type UserSearcher interface {
+	Search(query string, limit int) ([]*UserSearchResult, error)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// DefaultTenantID is the tenant that owned every user before tenants were
+// introduced. Requests without an API key act as it unless keys are
+// required, and it alone manages deployment-wide settings such as webhooks
+// and attribute definitions.
+const DefaultTenantID int64 = 1
+
+type Tenant struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+}
+
+type TenantRepository interface {
+	Create(tenant *Tenant) error
+	GetByID(id int64) (*Tenant, error)
+	// AddAPIKey stores the SHA-256 hash of a key that authenticates as the
+	// tenant.
+	AddAPIKey(tenantID int64, keyHash string) error
+	GetByAPIKey(keyHash string) (*Tenant, error)
+}
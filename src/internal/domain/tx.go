@@ -0,0 +1,14 @@
+package domain
+
+// Tx exposes repositories bound to a single database transaction.
+type Tx interface {
+	Users() UserRepository
+	Events() UserEventRepository
+	Webhooks() WebhookDeliveryRepository
+	Outbox() UserEventOutbox
+	EmailVerifications() EmailVerificationRepository
+}
+
+type TxManager interface {
+	WithinTx(fn func(tx Tx) error) error
+}
@@ -1,16 +1,49 @@
 package domain
 
+import "encoding/xml"
+
+// A user is created pending or active. From there:
+//
+//	pending     -> active, deactivated
+//	active      -> suspended, deactivated
+//	suspended   -> active, deactivated
+//	deactivated -> active
+const (
+	UserStatusPending     = "pending"
+	UserStatusActive      = "active"
+	UserStatusSuspended   = "suspended"
+	UserStatusDeactivated = "deactivated"
+)
+
 type User struct {
-	ID        int64  `json:"id"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	XMLName         xml.Name   `json:"-" xml:"user"`
+	ID              int64      `json:"id" xml:"id"`
+	Name            string     `json:"name" xml:"name"`
+	Email           string     `json:"email" xml:"email"`
+	Status          string     `json:"status" xml:"status"`
+	StatusReason    string     `json:"status_reason,omitempty" xml:"status_reason,omitempty"`
+	StatusChangedAt *string    `json:"status_changed_at,omitempty" xml:"status_changed_at,omitempty"`
+	EmailVerifiedAt *string    `json:"email_verified_at" xml:"email_verified_at"`
+	MFAEnabled      bool       `json:"mfa_enabled" xml:"mfa_enabled"`
+	LockedUntil     *string    `json:"locked_until" xml:"locked_until"`
+	CreatedAt       string     `json:"created_at" xml:"created_at"`
+	UpdatedAt       string     `json:"updated_at" xml:"updated_at"`
+	Attributes      Attributes `json:"attributes,omitempty" xml:"attributes,omitempty"`
+
+	// EmailCanonical is the key email uniqueness is enforced on. It is set
+	// by UserService before every write and never read back.
+	EmailCanonical string `json:"-" xml:"-"`
 }
 
 type UserRepository interface {
 	Create(user *User) error
 	GetByID(id int64) (*User, error)
+	// GetByIDForUpdate also locks the user until the transaction ends.
+	GetByIDForUpdate(id int64) (*User, error)
+	// GetByEmail finds a user by EmailCanonical.
+	GetByEmail(canonical string) (*User, error)
+	GetByIDs(ids []int64) ([]*User, error)
+	List(filter UserFilter) ([]*User, error)
 	Update(user *User) error
 	Delete(id int64) error
 }
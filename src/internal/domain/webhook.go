@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead"
+)
+
+// WebhookSubscription receives the user events listed in EventTypes, or all
+// of them when EventTypes is empty.
+type WebhookSubscription struct {
+	XMLName    xml.Name  `json:"-" xml:"webhook"`
+	ID         int64     `json:"id" xml:"id"`
+	URL        string    `json:"url" xml:"url"`
+	Secret     string    `json:"secret,omitempty" xml:"secret,omitempty"`
+	EventTypes []string  `json:"event_types" xml:"event_types>event_type"`
+	Active     bool      `json:"active" xml:"active"`
+	CreatedAt  time.Time `json:"created_at" xml:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" xml:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	XMLName        xml.Name   `json:"-" xml:"delivery"`
+	ID             int64      `json:"id" xml:"id"`
+	SubscriptionID int64      `json:"subscription_id" xml:"subscription_id"`
+	EventID        int64      `json:"event_id" xml:"event_id"`
+	EventType      string     `json:"event_type" xml:"event_type"`
+	Status         string     `json:"status" xml:"status"`
+	Attempts       int        `json:"attempts" xml:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty" xml:"next_attempt_at,omitempty"`
+	LastStatusCode *int       `json:"last_status_code,omitempty" xml:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty" xml:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" xml:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" xml:"created_at"`
+
+	// Set only on deliveries claimed for sending.
+	URL        string     `json:"-" xml:"-"`
+	Secret     string     `json:"-" xml:"-"`
+	Event      *UserEvent `json:"-" xml:"-"`
+	ClaimToken string     `json:"-" xml:"-"`
+}
+
+// WebhookAttempt is the outcome of a single delivery attempt. StatusCode is
+// nil when no response was received.
+type WebhookAttempt struct {
+	StatusCode  *int
+	Error       string
+	AttemptedAt time.Time
+}
+
+type WebhookDeliveryFilter struct {
+	SubscriptionID int64
+	Status         string
+	Limit          int
+	Offset         int
+}
+
+type WebhookSubscriptionRepository interface {
+	Create(sub *WebhookSubscription) error
+	GetByID(id int64) (*WebhookSubscription, error)
+	List() ([]*WebhookSubscription, error)
+	Update(sub *WebhookSubscription) error
+	Delete(id int64) error
+}
+
+type WebhookDeliveryRepository interface {
+	// Enqueue creates a pending delivery of event for every active
+	// subscription interested in its type.
+	Enqueue(event *UserEvent) error
+	// Claim leases up to limit due deliveries until leaseUntil, so that other
+	// dispatchers skip them while they are being sent. Every claim gets a new
+	// ClaimToken.
+	Claim(now, leaseUntil time.Time, limit int) ([]*WebhookDelivery, error)
+	// Complete records an attempt and moves the delivery to status. For
+	// pending deliveries nextAttemptAt schedules the retry. Nothing is
+	// recorded once the delivery has been claimed again under another token,
+	// which happens when the lease ran out before the attempt finished.
+	Complete(id int64, claimToken string, attempt WebhookAttempt, status string, nextAttemptAt time.Time) error
+	List(filter WebhookDeliveryFilter) ([]*WebhookDelivery, error)
+	// Requeue schedules a dead delivery for another round of attempts. It
+	// reports false when there is no dead delivery with that ID.
+	Requeue(id int64, at time.Time) (bool, error)
+}
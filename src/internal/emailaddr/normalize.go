@@ -0,0 +1,41 @@
+// Package emailaddr parses, normalizes and vets email addresses, so that
+// spellings of the same mailbox are stored, compared and looked up
+// identically and unusable addresses are refused at sign-up.
+package emailaddr
+
+import "strings"
+
+// gmailDomains deliver to the same mailbox regardless of dots in the local
+// part or a "+tag" suffix.
+var gmailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+}
+
+// Normalize trims and parses address, lower-cases it and converts an
+// internationalized domain to punycode, so "  John@Bücher.DE " becomes
+// "john@xn--bcher-kva.de". It returns errors.ErrInvalidEmail when address
+// does not parse.
+func Normalize(address string) (string, error) {
+	addr, err := Parse(strings.TrimSpace(address))
+	if err != nil {
+		return "", err
+	}
+	addr.Local = strings.ToLower(addr.Local)
+	return addr.String(), nil
+}
+
+// Canonical returns the key uniqueness is enforced on for an address already
+// passed through Normalize. With gmail set, dots and "+tag" suffixes are
+// dropped from Gmail addresses and googlemail.com is folded into gmail.com.
+func Canonical(normalized string, gmail bool) string {
+	if !gmail {
+		return normalized
+	}
+	at := strings.LastIndexByte(normalized, '@')
+	if at < 0 || !gmailDomains[normalized[at+1:]] {
+		return normalized
+	}
+	local, _, _ := strings.Cut(normalized[:at], "+")
+	return strings.ReplaceAll(local, ".", "") + "@gmail.com"
+}
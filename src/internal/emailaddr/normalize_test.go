@@ -0,0 +1,38 @@
+package emailaddr
+
+import (
+	"testing"
+
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"john@example.com", "john@example.com"},
+		{"  John@X.com\t", "john@x.com"},
+		{"anna@Bücher.DE", "anna@xn--bcher-kva.de"},
+		{"user@пример.рф", "user@xn--e1afmkfd.xn--p1ai"},
+		{`"a@b"@example.com`, `"a@b"@example.com`},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.in)
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, in := range []string{"", "john", "@example.com", "john@", "john@exa mple.com"} {
+		_, err := Normalize(in)
+		assert.Equal(t, errors.ErrInvalidEmail, err, in)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	assert.Equal(t, "j.doe+news@gmail.com", Canonical("j.doe+news@gmail.com", false))
+	assert.Equal(t, "jdoe@gmail.com", Canonical("j.doe+news@gmail.com", true))
+	assert.Equal(t, "jdoe@gmail.com", Canonical("j.d.o.e@googlemail.com", true))
+	assert.Equal(t, "j.doe+news@example.com", Canonical("j.doe+news@example.com", true))
+}
@@ -0,0 +1,198 @@
+package emailaddr
+
+import (
+	"net/netip"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"users-api/src/internal/errors"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	maxLocalLength   = 64
+	maxDomainLength  = 253
+	maxLabelLength   = 63
+	maxAddressLength = 254
+)
+
+// Address is a parsed addr-spec. Domain is in ASCII: lower-cased, with
+// internationalized labels in punycode, or an address literal such as
+// "[192.0.2.1]" or "[IPv6:2001:db8::1]".
+type Address struct {
+	Local  string
+	Domain string
+}
+
+func (a Address) String() string {
+	return a.Local + "@" + a.Domain
+}
+
+// IsLiteral reports whether the domain is an IP address literal.
+func (a Address) IsLiteral() bool {
+	return strings.HasPrefix(a.Domain, "[")
+}
+
+// Parse parses a bare addr-spec as defined by RFC 5322, extended by RFC 6531
+// to allow UTF-8 in the local part and domain. Display names, comments and
+// folding whitespace are not accepted. The domain must have at least two
+// labels. It returns errors.ErrInvalidEmail for anything else.
+func Parse(address string) (Address, error) {
+	if !utf8.ValidString(address) {
+		return Address{}, errors.ErrInvalidEmail
+	}
+
+	local, domain, ok := splitAddress(address)
+	if !ok || len(local) > maxLocalLength {
+		return Address{}, errors.ErrInvalidEmail
+	}
+
+	var err error
+	if strings.HasPrefix(domain, "[") {
+		domain, err = parseLiteral(domain)
+	} else {
+		domain, err = parseDomain(domain)
+	}
+	if err != nil {
+		return Address{}, err
+	}
+
+	addr := Address{Local: local, Domain: domain}
+	if len(addr.String()) > maxAddressLength {
+		return Address{}, errors.ErrInvalidEmail
+	}
+	return addr, nil
+}
+
+// splitAddress separates the local part from the domain, checking the local
+// part is a dot-atom or a quoted string.
+func splitAddress(address string) (local, domain string, ok bool) {
+	if strings.HasPrefix(address, `"`) {
+		end := quotedStringEnd(address)
+		if end < 0 || end >= len(address) || address[end] != '@' {
+			return "", "", false
+		}
+		return address[:end], address[end+1:], true
+	}
+
+	local, domain, ok = strings.Cut(address, "@")
+	if !ok || !isDotAtom(local) {
+		return "", "", false
+	}
+	return local, domain, true
+}
+
+// quotedStringEnd returns the index just past the quoted string s starts
+// with, or -1 when it is malformed.
+func quotedStringEnd(s string) int {
+	escaped := false
+	for i, r := range s[1:] {
+		switch {
+		case escaped:
+			if !isQuotedText(r) && r != '"' && r != '\\' {
+				return -1
+			}
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			if i == 0 {
+				return -1
+			}
+			return i + 2
+		case !isQuotedText(r):
+			return -1
+		}
+	}
+	return -1
+}
+
+func isQuotedText(r rune) bool {
+	if r < utf8.RuneSelf {
+		return r == ' ' || r == '\t' || (r >= '!' && r <= '~' && r != '"' && r != '\\')
+	}
+	return unicode.IsPrint(r)
+}
+
+func isDotAtom(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, atom := range strings.Split(s, ".") {
+		if atom == "" {
+			return false
+		}
+		for _, r := range atom {
+			if !isAtomText(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isAtomText(r rune) bool {
+	if r < utf8.RuneSelf {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r)
+	}
+	return unicode.IsPrint(r) && !unicode.IsSpace(r)
+}
+
+func parseLiteral(domain string) (string, error) {
+	inner, ok := strings.CutSuffix(domain[1:], "]")
+	if !ok {
+		return "", errors.ErrInvalidEmail
+	}
+	if v6, ok := strings.CutPrefix(inner, "IPv6:"); ok {
+		ip, err := netip.ParseAddr(v6)
+		if err != nil || !ip.Is6() || ip.Zone() != "" {
+			return "", errors.ErrInvalidEmail
+		}
+		return "[IPv6:" + ip.String() + "]", nil
+	}
+	ip, err := netip.ParseAddr(inner)
+	if err != nil || !ip.Is4() {
+		return "", errors.ErrInvalidEmail
+	}
+	return "[" + ip.String() + "]", nil
+}
+
+// parseDomain converts a host name to its lower-case ASCII form and checks
+// the DNS length and label rules.
+func parseDomain(domain string) (string, error) {
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil || ascii == "" || len(ascii) > maxDomainLength {
+		return "", errors.ErrInvalidEmail
+	}
+	ascii = strings.ToLower(ascii)
+
+	labels := strings.Split(ascii, ".")
+	if len(labels) < 2 {
+		return "", errors.ErrInvalidEmail
+	}
+	for _, label := range labels {
+		if !isLabel(label) {
+			return "", errors.ErrInvalidEmail
+		}
+	}
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return "", errors.ErrInvalidEmail
+	}
+	return ascii, nil
+}
+
+func isLabel(label string) bool {
+	if label == "" || len(label) > maxLabelLength || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package emailaddr
+
+import (
+	"strings"
+	"testing"
+
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	valid := []struct {
+		in   string
+		want Address
+	}{
+		{"john@example.com", Address{"john", "example.com"}},
+		{"John.Doe+tag@Sub.Example.COM", Address{"John.Doe+tag", "sub.example.com"}},
+		{"o'neil!#$%&*/=?^_`{|}~-@example.org", Address{"o'neil!#$%&*/=?^_`{|}~-", "example.org"}},
+		{`"john doe"@example.com`, Address{`"john doe"`, "example.com"}},
+		{`"a\"b@c"@example.com`, Address{`"a\"b@c"`, "example.com"}},
+		{"иван@пример.рф", Address{"иван", "xn--e1afmkfd.xn--p1ai"}},
+		{"用户@例子.广告", Address{"用户", "xn--fsqu00a.xn--4rr70v"}},
+		{"admin@[192.0.2.1]", Address{"admin", "[192.0.2.1]"}},
+		{"admin@[IPv6:2001:DB8::1]", Address{"admin", "[IPv6:2001:db8::1]"}},
+		{"x@a-b.io", Address{"x", "a-b.io"}},
+	}
+	for _, tt := range valid {
+		got, err := Parse(tt.in)
+		if assert.NoError(t, err, tt.in) {
+			assert.Equal(t, tt.want, got, tt.in)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"john",
+		"john@",
+		"@example.com",
+		"john@localhost",
+		"john..doe@example.com",
+		".john@example.com",
+		"john.@example.com",
+		"john doe@example.com",
+		"john@doe@example.com",
+		`"unterminated@example.com`,
+		`"quoted"tail@example.com`,
+		"john@-example.com",
+		"john@example-.com",
+		"john@example..com",
+		"john@example.com.",
+		"john@exa_mple.com",
+		"john@example.123",
+		"john@[300.0.0.1]",
+		"john@[IPv6:192.0.2.1]",
+		"John Doe <john@example.com>",
+		"john\x00@example.com",
+		"john​@example.com",
+		strings.Repeat("a", 65) + "@example.com",
+		"john@" + strings.Repeat("a", 64) + ".com",
+		"john@" + strings.Repeat(strings.Repeat("a", 60)+".", 5) + "com",
+		"\xff@example.com",
+	}
+	for _, in := range invalid {
+		_, err := Parse(in)
+		assert.Equal(t, errors.ErrInvalidEmail, err, in)
+	}
+}
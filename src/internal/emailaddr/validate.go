@@ -0,0 +1,193 @@
+package emailaddr
+
+import (
+	"bufio"
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"users-api/src/internal/config"
+	"users-api/src/internal/errors"
+
+	"golang.org/x/net/idna"
+)
+
+// Validator decides whether a well-formed address may be used. It returns
+// errors.ErrEmailRejected for addresses it refuses; any other error means
+// it could not decide.
+type Validator interface {
+	Validate(addr Address) error
+}
+
+// DomainList is a set of domains in ASCII form. A listed domain also covers
+// its subdomains.
+type DomainList map[string]struct{}
+
+func NewDomainList(domains []string) (DomainList, error) {
+	list := make(DomainList, len(domains))
+	for _, domain := range domains {
+		domain = strings.TrimSpace(domain)
+		if domain == "" {
+			continue
+		}
+		ascii, err := idna.Lookup.ToASCII(domain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid domain %q: %w", domain, err)
+		}
+		list[strings.ToLower(ascii)] = struct{}{}
+	}
+	return list, nil
+}
+
+// ParseDomainList reads one domain per line. Blank lines and text after "#"
+// are ignored.
+func ParseDomainList(r io.Reader) (DomainList, error) {
+	var domains []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		domains = append(domains, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewDomainList(domains)
+}
+
+func LoadDomainList(path string) (DomainList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseDomainList(f)
+}
+
+// Contains reports whether domain or one of its parent domains is listed.
+func (l DomainList) Contains(domain string) bool {
+	for {
+		if _, ok := l[domain]; ok {
+			return true
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			return false
+		}
+		domain = parent
+	}
+}
+
+type domainPolicy struct {
+	allow DomainList
+	deny  DomainList
+}
+
+// DomainPolicy rejects addresses whose domain is in deny or, when allow is
+// not empty, missing from allow. Address literals are rejected whenever
+// allow is set.
+func DomainPolicy(allow, deny DomainList) Validator {
+	return domainPolicy{allow: allow, deny: deny}
+}
+
+func (p domainPolicy) Validate(addr Address) error {
+	if p.deny.Contains(addr.Domain) {
+		return errors.ErrEmailRejected
+	}
+	if len(p.allow) > 0 && !p.allow.Contains(addr.Domain) {
+		return errors.ErrEmailRejected
+	}
+	return nil
+}
+
+// Resolver looks up the DNS records MXCheck needs. *net.Resolver
+// implements it.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+type mxCheck struct {
+	resolver Resolver
+	timeout  time.Duration
+}
+
+// MXCheck rejects addresses whose domain cannot receive mail: it publishes a
+// null MX record (RFC 7505), or has neither MX nor address records. Other
+// DNS failures, timeouts included, let the address through so that a
+// resolver outage does not block sign-ups. Address literals are not checked.
+func MXCheck(resolver Resolver, timeout time.Duration) Validator {
+	return mxCheck{resolver: resolver, timeout: timeout}
+}
+
+func (c mxCheck) Validate(addr Address) error {
+	if addr.IsLiteral() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	records, err := c.resolver.LookupMX(ctx, addr.Domain)
+	if err == nil && len(records) > 0 {
+		if len(records) == 1 && strings.TrimSuffix(records[0].Host, ".") == "" {
+			return errors.ErrEmailRejected
+		}
+		return nil
+	}
+	if err != nil && !isNotFound(err) {
+		return nil
+	}
+
+	// Without MX records the domain itself is the mail host (RFC 5321).
+	hosts, err := c.resolver.LookupHost(ctx, addr.Domain)
+	if err != nil && !isNotFound(err) {
+		return nil
+	}
+	if len(hosts) == 0 {
+		return errors.ErrEmailRejected
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return stdErrors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// NewValidators builds the validators enabled in cfg, cheapest first: the
+// domain allow and deny lists, the disposable domain blocklist, then the MX
+// check.
+func NewValidators(cfg *config.Config) ([]Validator, error) {
+	var validators []Validator
+
+	allow, err := NewDomainList(cfg.EmailAllowedDomains)
+	if err != nil {
+		return nil, fmt.Errorf("EMAIL_ALLOWED_DOMAINS: %w", err)
+	}
+	deny, err := NewDomainList(cfg.EmailDeniedDomains)
+	if err != nil {
+		return nil, fmt.Errorf("EMAIL_DENIED_DOMAINS: %w", err)
+	}
+	if len(allow) > 0 || len(deny) > 0 {
+		validators = append(validators, DomainPolicy(allow, deny))
+	}
+
+	if cfg.EmailDisposableDomainsFile != "" {
+		disposable, err := LoadDomainList(cfg.EmailDisposableDomainsFile)
+		if err != nil {
+			return nil, fmt.Errorf("EMAIL_DISPOSABLE_DOMAINS_FILE: %w", err)
+		}
+		validators = append(validators, DomainPolicy(nil, disposable))
+	}
+
+	if cfg.EmailCheckMX {
+		validators = append(validators, MXCheck(net.DefaultResolver, cfg.EmailMXTimeout))
+	}
+
+	return validators, nil
+}
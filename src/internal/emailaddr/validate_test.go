@@ -0,0 +1,103 @@
+package emailaddr
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDomainList(t *testing.T) {
+	list, err := ParseDomainList(strings.NewReader("# disposable\nmailinator.com\n\n  Yopmail.COM  # comment\nпочта.рф\n"))
+	require.NoError(t, err)
+
+	assert.True(t, list.Contains("mailinator.com"))
+	assert.True(t, list.Contains("eu.mailinator.com"))
+	assert.True(t, list.Contains("yopmail.com"))
+	assert.True(t, list.Contains("xn--80a1acny.xn--p1ai"))
+	assert.False(t, list.Contains("notmailinator.com"))
+	assert.False(t, list.Contains("com"))
+
+	_, err = NewDomainList([]string{"bad domain.com"})
+	assert.Error(t, err)
+}
+
+func TestDomainPolicy(t *testing.T) {
+	allow, _ := NewDomainList([]string{"example.com"})
+	deny, _ := NewDomainList([]string{"blocked.example.com"})
+	policy := DomainPolicy(allow, deny)
+
+	tests := []struct {
+		domain string
+		want   error
+	}{
+		{"example.com", nil},
+		{"team.example.com", nil},
+		{"blocked.example.com", errors.ErrEmailRejected},
+		{"other.org", errors.ErrEmailRejected},
+		{"[192.0.2.1]", errors.ErrEmailRejected},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, policy.Validate(Address{Local: "x", Domain: tt.domain}), tt.domain)
+	}
+
+	assert.NoError(t, DomainPolicy(nil, deny).Validate(Address{Local: "x", Domain: "other.org"}))
+}
+
+type stubResolver struct {
+	mx    map[string][]*net.MX
+	hosts map[string][]string
+	err   error
+}
+
+func (r stubResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if mx, ok := r.mx[name]; ok {
+		return mx, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r stubResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if hosts, ok := r.hosts[host]; ok {
+		return hosts, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestMXCheck(t *testing.T) {
+	check := MXCheck(stubResolver{
+		mx: map[string][]*net.MX{
+			"example.com":   {{Host: "mx.example.com.", Pref: 10}},
+			"nullmx.com":    {{Host: ".", Pref: 0}},
+			"exchange.test": {},
+		},
+		hosts: map[string][]string{"exchange.test": {"192.0.2.10"}, "hostonly.com": {"192.0.2.1"}},
+	}, time.Second)
+
+	tests := []struct {
+		domain string
+		want   error
+	}{
+		{"example.com", nil},
+		{"hostonly.com", nil},
+		{"exchange.test", nil},
+		{"nullmx.com", errors.ErrEmailRejected},
+		{"nowhere.invalid", errors.ErrEmailRejected},
+		{"[192.0.2.1]", nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, check.Validate(Address{Local: "x", Domain: tt.domain}), tt.domain)
+	}
+
+	outage := MXCheck(stubResolver{err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, time.Second)
+	assert.NoError(t, outage.Validate(Address{Local: "x", Domain: "example.com"}))
+}
@@ -1,9 +1,85 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
-	ErrUserNotFound = errors.New("user not found")
-	ErrInvalidInput = errors.New("invalid input")
-	ErrInvalidEmail = errors.New("invalid email format")
+	ErrUserNotFound  = errors.New("user not found")
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrInvalidEmail  = errors.New("invalid email format")
+	ErrEmailRejected = errors.New("email address not accepted")
+	ErrEmailTaken    = errors.New("email already in use")
+
+	ErrInvalidStatusTransition = errors.New("status transition not allowed")
+
+	ErrNotAcceptable        = errors.New("not acceptable")
+	ErrMethodNotAllowed     = errors.New("method not allowed")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrRequestInvalid       = errors.New("request does not match api specification")
+	ErrResponseInvalid      = errors.New("response does not match api specification")
+
+	ErrBatchTooLarge  = errors.New("batch too large")
+	ErrBatchAborted   = errors.New("batch aborted")
+	ErrInvalidBatchOp = errors.New("invalid batch operation")
+	ErrTxNotSupported = errors.New("transactions not supported")
+
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+
+	ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+	ErrMFANotEnabled     = errors.New("mfa not enabled")
+	ErrMFANotEnrolled    = errors.New("mfa enrollment not started")
+	ErrInvalidMFACode    = errors.New("invalid mfa code")
+
+	ErrAccountLocked = errors.New("account temporarily locked")
+	ErrRateLimited   = errors.New("rate limit exceeded")
+
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrInvalidAPIKey   = errors.New("invalid api key")
+	ErrForbidden       = errors.New("forbidden")
+	ErrTenantNotFound  = errors.New("tenant not found")
+
+	ErrEventLogDisabled = errors.New("event log disabled")
+
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhookURL       = errors.New("invalid webhook url")
+
+	ErrAttributeNotFound = errors.New("user attribute not found")
+	ErrAttributeExists   = errors.New("user attribute already defined")
+	ErrInvalidAttribute  = errors.New("invalid user attribute")
+
+	ErrGroupNotFound     = errors.New("group not found")
+	ErrGroupExists       = errors.New("group name already in use")
+	ErrGroupCycle        = errors.New("group cannot be nested in itself")
+	ErrGroupHasSubgroups = errors.New("group has subgroups")
+	ErrMemberNotFound    = errors.New("group member not found")
 )
+
+// AttributeError reports which custom attribute failed validation and why.
+type AttributeError struct {
+	Name   string
+	Reason string
+}
+
+func (e *AttributeError) Error() string {
+	return "attribute " + e.Name + ": " + e.Reason
+}
+
+func (e *AttributeError) Unwrap() error {
+	return ErrInvalidAttribute
+}
+
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrAccountLocked
+}
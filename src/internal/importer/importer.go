@@ -0,0 +1,174 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"users-api/src/internal/domain"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+type UserImporter interface {
+	ImportUser(user *domain.User, opts domain.ImportOptions) (string, error)
+}
+
+type Options struct {
+	Format string
+	DryRun bool
+	Upsert bool
+	// Skip is the number of data rows processed by a previous run; these rows
+	// are read but not imported again.
+	Skip int
+	// Mapping maps user fields (name, email) to source column names.
+	Mapping map[string]string
+}
+
+type RowResult struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	ID     int64  `json:"id,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Summary struct {
+	DryRun      bool `json:"dry_run"`
+	Rows        int  `json:"rows"`
+	Skipped     int  `json:"skipped"`
+	Created     int  `json:"created"`
+	Updated     int  `json:"updated"`
+	Unchanged   int  `json:"unchanged"`
+	WouldCreate int  `json:"would_create"`
+	WouldUpdate int  `json:"would_update"`
+	Failed      int  `json:"failed"`
+	LastRow     int  `json:"last_row"`
+}
+
+type Importer struct {
+	users UserImporter
+}
+
+func New(users UserImporter) *Importer {
+	return &Importer{users: users}
+}
+
+var defaultMapping = map[string]string{
+	"name":  "name",
+	"email": "email",
+}
+
+// Run streams records from r, imports them one by one and reports every
+// processed row to onRow. Row numbers are 1-based and do not count the CSV
+// header, so Summary.LastRow can be passed back as Options.Skip to resume.
+func (i *Importer) Run(r io.Reader, opts Options, onRow func(RowResult) error) (*Summary, error) {
+	src, err := newSource(r, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := make(map[string]string, len(defaultMapping))
+	for field, column := range defaultMapping {
+		mapping[field] = column
+	}
+	for field, column := range opts.Mapping {
+		mapping[field] = column
+	}
+
+	summary := &Summary{DryRun: opts.DryRun}
+	importOpts := domain.ImportOptions{DryRun: opts.DryRun, Upsert: opts.Upsert}
+
+	for row := 1; ; row++ {
+		record, recordErr := src.next()
+		if recordErr == io.EOF {
+			break
+		}
+		if recordErr != nil && !isRecordError(recordErr) {
+			return summary, recordErr
+		}
+
+		summary.LastRow = row
+		if row <= opts.Skip {
+			summary.Skipped++
+			continue
+		}
+		summary.Rows++
+
+		result := RowResult{Row: row}
+		if recordErr != nil {
+			result.Status = domain.ImportStatusFailed
+			result.Error = recordErr.Error()
+		} else {
+			user := &domain.User{
+				Name:  lookup(record, mapping["name"]),
+				Email: lookup(record, mapping["email"]),
+			}
+			status, err := i.users.ImportUser(user, importOpts)
+			result.Status = status
+			result.ID = user.ID
+			result.Email = user.Email
+			if err != nil {
+				result.Error = err.Error()
+			}
+		}
+
+		summary.count(result.Status)
+		if onRow != nil {
+			if err := onRow(result); err != nil {
+				return summary, err
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+func lookup(record map[string]string, column string) string {
+	value, ok := record[column]
+	if !ok {
+		value = record[strings.ToLower(column)]
+	}
+	return strings.TrimSpace(value)
+}
+
+func (s *Summary) count(status string) {
+	switch status {
+	case domain.ImportStatusCreated:
+		s.Created++
+	case domain.ImportStatusUpdated:
+		s.Updated++
+	case domain.ImportStatusUnchanged:
+		s.Unchanged++
+	case domain.ImportStatusWouldCreate:
+		s.WouldCreate++
+	case domain.ImportStatusWouldUpdate:
+		s.WouldUpdate++
+	default:
+		s.Failed++
+	}
+}
+
+// ParseMapping parses "name=full_name,email=mail" into a field mapping.
+func ParseMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		field, column, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mapping %q, expected field=column", pair)
+		}
+		field = strings.TrimSpace(field)
+		if _, known := defaultMapping[field]; !known {
+			return nil, fmt.Errorf("unknown user field %q", field)
+		}
+		mapping[field] = strings.TrimSpace(column)
+	}
+	return mapping, nil
+}
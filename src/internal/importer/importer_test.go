@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUserImporter struct {
+	imported []domain.User
+	nextID   int64
+}
+
+func (f *fakeUserImporter) ImportUser(user *domain.User, opts domain.ImportOptions) (string, error) {
+	if user.Name == "" || user.Email == "" {
+		return domain.ImportStatusFailed, errors.ErrInvalidInput
+	}
+	f.imported = append(f.imported, *user)
+	if opts.DryRun {
+		return domain.ImportStatusWouldCreate, nil
+	}
+	f.nextID++
+	user.ID = f.nextID
+	return domain.ImportStatusCreated, nil
+}
+
+func collect(t *testing.T, input string, opts Options) ([]RowResult, *Summary, *fakeUserImporter) {
+	users := &fakeUserImporter{}
+	var rows []RowResult
+	summary, err := New(users).Run(strings.NewReader(input), opts, func(row RowResult) error {
+		rows = append(rows, row)
+		return nil
+	})
+	assert.NoError(t, err)
+	return rows, summary, users
+}
+
+func TestImportCSV(t *testing.T) {
+	input := "Email,Full Name\njohn@example.com,John Doe\n,Missing Email\n\"broken,x\n"
+
+	rows, summary, users := collect(t, input, Options{
+		Format:  FormatCSV,
+		Mapping: map[string]string{"name": "full name"},
+	})
+
+	assert.Equal(t, "John Doe", users.imported[0].Name)
+	assert.Equal(t, domain.ImportStatusCreated, rows[0].Status)
+	assert.Equal(t, int64(1), rows[0].ID)
+	assert.Equal(t, domain.ImportStatusFailed, rows[1].Status)
+	assert.Equal(t, errors.ErrInvalidInput.Error(), rows[1].Error)
+	assert.Equal(t, domain.ImportStatusFailed, rows[2].Status)
+	assert.Equal(t, 3, summary.Rows)
+	assert.Equal(t, 1, summary.Created)
+	assert.Equal(t, 2, summary.Failed)
+	assert.Equal(t, 3, summary.LastRow)
+}
+
+func TestImportNDJSON(t *testing.T) {
+	input := `{"name":"John Doe","email":"john@example.com"}
+
+{"name":"Jane Doe","email":"jane@example.com"}
+not json
+`
+
+	rows, summary, _ := collect(t, input, Options{Format: FormatNDJSON, DryRun: true})
+
+	assert.Len(t, rows, 3)
+	assert.Equal(t, domain.ImportStatusWouldCreate, rows[0].Status)
+	assert.Equal(t, domain.ImportStatusWouldCreate, rows[1].Status)
+	assert.Equal(t, domain.ImportStatusFailed, rows[2].Status)
+	assert.True(t, summary.DryRun)
+	assert.Equal(t, 2, summary.WouldCreate)
+}
+
+func TestImportResume(t *testing.T) {
+	input := "name,email\nA,a@example.com\nB,b@example.com\nC,c@example.com\n"
+
+	rows, summary, users := collect(t, input, Options{Format: FormatCSV, Skip: 2})
+
+	assert.Len(t, users.imported, 1)
+	assert.Equal(t, "c@example.com", users.imported[0].Email)
+	assert.Equal(t, 3, rows[0].Row)
+	assert.Equal(t, 2, summary.Skipped)
+	assert.Equal(t, 3, summary.LastRow)
+}
+
+func TestParseMapping(t *testing.T) {
+	mapping, err := ParseMapping("name=full_name, email=mail")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "full_name", "email": "mail"}, mapping)
+
+	_, err = ParseMapping("phone=tel")
+	assert.Error(t, err)
+}
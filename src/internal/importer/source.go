@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const maxNDJSONLine = 1 << 20
+
+type source interface {
+	next() (map[string]string, error)
+}
+
+// recordError is a problem with a single record; the import reports it for
+// that row and carries on with the next one.
+type recordError struct {
+	err error
+}
+
+func (e *recordError) Error() string {
+	return e.err.Error()
+}
+
+func isRecordError(err error) bool {
+	var re *recordError
+	return errors.As(err, &re)
+}
+
+func newSource(r io.Reader, format string) (source, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVSource(r)
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+		return &ndjsonSource{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+}
+
+type csvSource struct {
+	reader *csv.Reader
+	header []string
+}
+
+func newCSVSource(r io.Reader) (*csvSource, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("csv input has no header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading csv header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+	}
+
+	return &csvSource{reader: reader, header: header}, nil
+}
+
+func (s *csvSource) next() (map[string]string, error) {
+	fields, err := s.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, &recordError{err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record := make(map[string]string, len(s.header))
+	for i, column := range s.header {
+		if i < len(fields) {
+			record[column] = fields[i]
+		}
+	}
+	return record, nil
+}
+
+type ndjsonSource struct {
+	scanner *bufio.Scanner
+}
+
+func (s *ndjsonSource) next() (map[string]string, error) {
+	for s.scanner.Scan() {
+		line := strings.TrimSpace(s.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &raw); err != nil {
+			return nil, &recordError{err: fmt.Errorf("invalid json: %w", err)}
+		}
+
+		record := make(map[string]string, len(raw))
+		for key, value := range raw {
+			if value == nil {
+				continue
+			}
+			if str, ok := value.(string); ok {
+				record[key] = str
+			} else {
+				record[key] = fmt.Sprint(value)
+			}
+		}
+		return record, nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
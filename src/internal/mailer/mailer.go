@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"users-api/src/internal/config"
+)
+
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.MailerType {
+	case "log":
+		return NewLogMailer(os.Stdout), nil
+	case "file":
+		return NewFileMailer(cfg.MailerFileDir)
+	default:
+		return nil, fmt.Errorf("unknown mailer type: %s", cfg.MailerType)
+	}
+}
+
+type LogMailer struct {
+	logger *log.Logger
+}
+
+func NewLogMailer(w io.Writer) *LogMailer {
+	return &LogMailer{logger: log.New(w, "mailer: ", log.LstdFlags)}
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	m.logger.Printf("to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+type FileMailer struct {
+	dir string
+	mu  sync.Mutex
+	seq int
+}
+
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating mail directory: %w", err)
+	}
+	return &FileMailer{dir: dir}, nil
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	m.mu.Lock()
+	m.seq++
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().UTC().Format("20060102T150405"), m.seq)
+	m.mu.Unlock()
+
+	content := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		msg.From,
+		msg.To,
+		msg.Subject,
+		time.Now().Format(time.RFC1123Z),
+		msg.Body,
+	)
+
+	if err := os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o644); err != nil {
+		return fmt.Errorf("error writing mail file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterPublisher writes every message body as one line of NDJSON.
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+	f  *os.File
+}
+
+func NewStdoutPublisher() *WriterPublisher {
+	return &WriterPublisher{w: os.Stdout}
+}
+
+// NewFilePublisher appends to path and syncs after every message.
+func NewFilePublisher(path string) (*WriterPublisher, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening outbox file: %w", err)
+	}
+	return &WriterPublisher{w: f, f: f}, nil
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.w.Write(msg.Body); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(p.w, "\n"); err != nil {
+		return err
+	}
+	if p.f != nil {
+		return p.f.Sync()
+	}
+	return nil
+}
+
+func (p *WriterPublisher) Close() error {
+	if p.f != nil {
+		return p.f.Close()
+	}
+	return nil
+}
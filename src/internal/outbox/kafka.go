@@ -0,0 +1,41 @@
+package outbox
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher writes to one topic keyed by user ID, so all events of a user
+// land in the same partition, and waits for all in-sync replicas.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(brokers []string, topic string) (*KafkaPublisher, error) {
+	return &KafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// The relay publishes one message at a time and needs the result
+		// before moving on; don't hold messages back waiting for a batch.
+		BatchSize: 1,
+	}}, nil
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, msg Message) error {
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(msg.Key),
+		Value: msg.Body,
+		Headers: []kafka.Header{
+			{Key: "event-id", Value: []byte(strconv.FormatInt(msg.ID, 10))},
+			{Key: "event-type", Value: []byte(msg.Type)},
+		},
+	})
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,46 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher publishes to JetStream on "<prefix>.<event type>" and waits
+// for the stream to acknowledge. The event ID is sent as Nats-Msg-Id so that
+// the stream drops duplicates of a message the relay had to resend. A stream
+// capturing "<prefix>.>" must exist.
+type NATSPublisher struct {
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+	prefix string
+}
+
+func NewNATSPublisher(url, prefix string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to NATS: %w", err)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error opening JetStream context: %w", err)
+	}
+	return &NATSPublisher{conn: conn, js: js, prefix: prefix}, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, msg Message) error {
+	m := nats.NewMsg(p.prefix + "." + msg.Type)
+	m.Data = msg.Body
+	m.Header.Set(nats.MsgIdHdr, strconv.FormatInt(msg.ID, 10))
+	m.Header.Set("User-Id", msg.Key)
+
+	_, err := p.js.PublishMsg(m, nats.Context(ctx))
+	return err
+}
+
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,39 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+
+	"users-api/src/internal/config"
+)
+
+// Message is a user event ready to be published. Key is the user ID; brokers
+// that partition by key keep each user's events in order.
+type Message struct {
+	ID   int64
+	Key  string
+	Type string
+	Body []byte
+}
+
+// Publisher delivers messages to a broker. Publish must return only once the
+// broker has accepted the message, since the relay then forgets about it.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+func NewPublisher(cfg *config.Config) (Publisher, error) {
+	switch cfg.OutboxPublisher {
+	case "stdout":
+		return NewStdoutPublisher(), nil
+	case "file":
+		return NewFilePublisher(cfg.OutboxFilePath)
+	case "nats":
+		return NewNATSPublisher(cfg.NATSURL, cfg.NATSSubjectPrefix)
+	case "kafka":
+		return NewKafkaPublisher(cfg.KafkaBrokers, cfg.KafkaTopic)
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher: %s", cfg.OutboxPublisher)
+	}
+}
@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"users-api/src/internal/domain"
+)
+
+type Config struct {
+	BatchSize    int
+	PollInterval time.Duration
+	// RetryDelay is how long the events of a user wait after one of them
+	// could not be published.
+	RetryDelay time.Duration
+}
+
+// Relay moves events from the outbox to a Publisher. An event leaves the
+// outbox only after the publisher accepted it, so every event is published
+// at least once. When an event cannot be published, the later events of the
+// same user are held back until it is, which keeps each user's events in
+// order. The user is then skipped for RetryDelay, so a backlog that keeps
+// failing does not fill every batch and hold up other users.
+type Relay struct {
+	outbox    domain.UserEventOutbox
+	publisher Publisher
+	cfg       Config
+}
+
+func NewRelay(outbox domain.UserEventOutbox, publisher Publisher, cfg Config) *Relay {
+	return &Relay{outbox: outbox, publisher: publisher, cfg: cfg}
+}
+
+// Run relays pending events every PollInterval until ctx is done. A batch
+// that was published in full is followed by the next one right away; after
+// any failure Run waits for the next tick.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.RelayOnce(ctx)
+			if err != nil {
+				log.Printf("Failed to relay user events: %v", err)
+			}
+			if err != nil || n < r.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publishes one batch of pending events and returns how many of
+// them were published.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	return r.outbox.Relay(r.cfg.BatchSize, r.cfg.RetryDelay, func(events []*domain.UserEvent) []int64 {
+		return r.publish(ctx, events)
+	})
+}
+
+func (r *Relay) publish(ctx context.Context, events []*domain.UserEvent) []int64 {
+	published := make([]int64, 0, len(events))
+	blocked := make(map[int64]bool)
+
+	for _, event := range events {
+		if blocked[event.UserID] {
+			continue
+		}
+		body, err := json.Marshal(event)
+		if err == nil {
+			err = r.publisher.Publish(ctx, Message{
+				ID:   event.ID,
+				Key:  strconv.FormatInt(event.UserID, 10),
+				Type: event.Type,
+				Body: body,
+			})
+		}
+		if err != nil {
+			log.Printf("Failed to publish %s event %d: %v", event.Type, event.ID, err)
+			blocked[event.UserID] = true
+			continue
+		}
+		published = append(published, event.ID)
+	}
+	return published
+}
@@ -0,0 +1,140 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeOutbox struct {
+	pending   []*domain.UserEvent
+	published []int64
+	blocked   map[int64]bool
+	relays    int
+}
+
+func (o *fakeOutbox) Add(event *domain.UserEvent) error {
+	o.pending = append(o.pending, event)
+	return nil
+}
+
+func (o *fakeOutbox) Relay(limit int, retryAfter time.Duration, publish func(events []*domain.UserEvent) []int64) (int, error) {
+	var batch []*domain.UserEvent
+	for _, event := range o.pending {
+		if len(batch) < limit && !o.blocked[event.UserID] {
+			batch = append(batch, event)
+		}
+	}
+	o.relays++
+	o.published = publish(batch)
+
+	done := map[int64]bool{}
+	for _, id := range o.published {
+		done[id] = true
+	}
+	var pending []*domain.UserEvent
+	for _, event := range o.pending {
+		if done[event.ID] {
+			continue
+		}
+		pending = append(pending, event)
+	}
+	if o.blocked == nil {
+		o.blocked = map[int64]bool{}
+	}
+	for _, event := range batch {
+		if !done[event.ID] {
+			o.blocked[event.UserID] = true
+		}
+	}
+	o.pending = pending
+	return len(o.published), nil
+}
+
+type fakePublisher struct {
+	fail map[int64]bool
+	sent []int64
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, msg Message) error {
+	if p.fail[msg.ID] {
+		return errors.New("broker unavailable")
+	}
+	p.sent = append(p.sent, msg.ID)
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	return nil
+}
+
+func TestRelayKeepsPerUserOrder(t *testing.T) {
+	box := &fakeOutbox{pending: []*domain.UserEvent{
+		{ID: 1, UserID: 10, Type: domain.UserEventCreated},
+		{ID: 2, UserID: 20, Type: domain.UserEventCreated},
+		{ID: 3, UserID: 10, Type: domain.UserEventUpdated},
+		{ID: 4, UserID: 20, Type: domain.UserEventUpdated},
+	}}
+	publisher := &fakePublisher{fail: map[int64]bool{1: true}}
+
+	n, err := NewRelay(box, publisher, Config{BatchSize: 10}).RelayOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{2, 4}, publisher.sent)
+	assert.Equal(t, []int64{2, 4}, box.published)
+}
+
+func TestRelayDoesNotStarveOtherUsers(t *testing.T) {
+	box := &fakeOutbox{pending: []*domain.UserEvent{
+		{ID: 1, UserID: 10, Type: domain.UserEventCreated},
+		{ID: 2, UserID: 10, Type: domain.UserEventUpdated},
+		{ID: 3, UserID: 10, Type: domain.UserEventUpdated},
+		{ID: 4, UserID: 20, Type: domain.UserEventCreated},
+	}}
+	publisher := &fakePublisher{fail: map[int64]bool{1: true}}
+	relay := NewRelay(box, publisher, Config{BatchSize: 2})
+
+	n, err := relay.RelayOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	// User 10 fills a whole batch with events that cannot go out; once it is
+	// blocked, user 20 gets through.
+	n, err = relay.RelayOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{4}, publisher.sent)
+}
+
+func TestRelayWaitsWhileBrokerIsDown(t *testing.T) {
+	box := &fakeOutbox{pending: []*domain.UserEvent{
+		{ID: 1, UserID: 10, Type: domain.UserEventCreated},
+		{ID: 2, UserID: 20, Type: domain.UserEventCreated},
+		{ID: 3, UserID: 30, Type: domain.UserEventCreated},
+	}}
+	publisher := &fakePublisher{fail: map[int64]bool{1: true, 2: true, 3: true}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The backlog fills the batch, but nothing is published, so Run must not
+	// fetch it again before the next tick.
+	NewRelay(box, publisher, Config{BatchSize: 2, PollInterval: time.Hour}).Run(ctx)
+
+	assert.Equal(t, 1, box.relays)
+	assert.Empty(t, publisher.sent)
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	publisher := &WriterPublisher{w: &buf}
+
+	assert.NoError(t, publisher.Publish(context.Background(), Message{ID: 1, Body: []byte(`{"id":1}`)}))
+	assert.NoError(t, publisher.Publish(context.Background(), Message{ID: 2, Body: []byte(`{"id":2}`)}))
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n", buf.String())
+}
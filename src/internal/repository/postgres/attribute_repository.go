@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+)
+
+var attributeDefinitionColumns = []string{"name", "type", "required", "pattern", "enum", "description", "created_at", "updated_at"}
+
+type AttributeDefinitionRepository struct {
+	db      squirrel.StdSqlCtx
+	builder squirrel.StatementBuilderType
+}
+
+func NewAttributeDefinitionRepository(db *sql.DB) *AttributeDefinitionRepository {
+	return &AttributeDefinitionRepository{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (r *AttributeDefinitionRepository) Create(def *domain.AttributeDefinition) error {
+	now := time.Now().UTC()
+	def.CreatedAt = now
+	def.UpdatedAt = now
+
+	query := r.builder.
+		Insert("user_attribute_definitions").
+		Columns(attributeDefinitionColumns...).
+		Values(def.Name, def.Type, def.Required, def.Pattern, enumArray(def.Enum), def.Description, def.CreatedAt, def.UpdatedAt)
+
+	_, err := query.RunWith(r.db).Exec()
+	if isUniqueViolation(err) {
+		return errors.ErrAttributeExists
+	}
+	return err
+}
+
+func (r *AttributeDefinitionRepository) Get(name string) (*domain.AttributeDefinition, error) {
+	query := r.builder.
+		Select(attributeDefinitionColumns...).
+		From("user_attribute_definitions").
+		Where(squirrel.Eq{"name": name})
+
+	def, err := scanAttributeDefinition(query.RunWith(r.db).QueryRow())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+func (r *AttributeDefinitionRepository) List() ([]*domain.AttributeDefinition, error) {
+	query := r.builder.
+		Select(attributeDefinitionColumns...).
+		From("user_attribute_definitions").
+		OrderBy("name")
+
+	rows, err := query.RunWith(r.db).Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	defs := []*domain.AttributeDefinition{}
+	for rows.Next() {
+		def, err := scanAttributeDefinition(rows)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	return defs, rows.Err()
+}
+
+func (r *AttributeDefinitionRepository) Update(def *domain.AttributeDefinition) error {
+	def.UpdatedAt = time.Now().UTC()
+
+	query := r.builder.
+		Update("user_attribute_definitions").
+		Set("type", def.Type).
+		Set("required", def.Required).
+		Set("pattern", def.Pattern).
+		Set("enum", enumArray(def.Enum)).
+		Set("description", def.Description).
+		Set("updated_at", def.UpdatedAt).
+		Where(squirrel.Eq{"name": def.Name}).
+		Suffix("RETURNING created_at")
+
+	return query.RunWith(r.db).QueryRow().Scan(&def.CreatedAt)
+}
+
+func (r *AttributeDefinitionRepository) Delete(name string) error {
+	query := r.builder.
+		Delete("user_attribute_definitions").
+		Where(squirrel.Eq{"name": name})
+
+	return execAffectingRow(query.RunWith(r.db).Exec())
+}
+
+func scanAttributeDefinition(row squirrel.RowScanner) (*domain.AttributeDefinition, error) {
+	def := &domain.AttributeDefinition{}
+	var enum pq.StringArray
+	err := row.Scan(&def.Name, &def.Type, &def.Required, &def.Pattern, &enum, &def.Description, &def.CreatedAt, &def.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(enum) > 0 {
+		def.Enum = []string(enum)
+	}
+	return def, nil
+}
+
+// enumArray stores a missing enum as an empty array, the column being NOT
+// NULL.
+func enumArray(enum []string) pq.StringArray {
+	if enum == nil {
+		return pq.StringArray{}
+	}
+	return pq.StringArray(enum)
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAttributeDefinition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAttributeDefinitionRepository(db)
+	def := &domain.AttributeDefinition{Name: "department", Type: domain.AttributeTypeString, Enum: []string{"Sales", "IT"}}
+
+	mock.ExpectExec("INSERT INTO user_attribute_definitions").
+		WithArgs("department", "string", false, "", pq.StringArray{"Sales", "IT"}, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO user_attribute_definitions").
+		WithArgs("department", "string", false, "", pq.StringArray{"Sales", "IT"}, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(&pq.Error{Code: uniqueViolation})
+
+	assert.NoError(t, repo.Create(def))
+	assert.False(t, def.CreatedAt.IsZero())
+	assert.Equal(t, errors.ErrAttributeExists, repo.Create(def))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAttributeDefinitions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAttributeDefinitionRepository(db)
+	rows := sqlmock.NewRows([]string{"name", "type", "required", "pattern", "enum", "description", "created_at", "updated_at"}).
+		AddRow("department", "string", true, "", "{Sales,IT}", "", time.Now(), time.Now()).
+		AddRow("phone", "string", false, `^\+[0-9]+$`, "{}", "E.164 number", time.Now(), time.Now())
+
+	mock.ExpectQuery("SELECT (.+) FROM user_attribute_definitions ORDER BY name").WillReturnRows(rows)
+
+	defs, err := repo.List()
+	assert.NoError(t, err)
+	if assert.Len(t, defs, 2) {
+		assert.Equal(t, []string{"Sales", "IT"}, defs[0].Enum)
+		assert.True(t, defs[0].Required)
+		assert.Nil(t, defs[1].Enum)
+		assert.Equal(t, `^\+[0-9]+$`, defs[1].Pattern)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateMissingAttributeDefinition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewAttributeDefinitionRepository(db)
+
+	mock.ExpectQuery("UPDATE user_attribute_definitions (.+) RETURNING created_at").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}))
+
+	err = repo.Update(&domain.AttributeDefinition{Name: "missing", Type: domain.AttributeTypeBoolean})
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"users-api/src/internal/domain"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type EmailVerificationRepository struct {
+	db      squirrel.StdSqlCtx
+	builder squirrel.StatementBuilderType
+}
+
+func NewEmailVerificationRepository(db *sql.DB) *EmailVerificationRepository {
+	return &EmailVerificationRepository{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (r *EmailVerificationRepository) Create(token *domain.EmailVerificationToken) error {
+	token.CreatedAt = time.Now()
+
+	query := r.builder.
+		Insert("email_verification_tokens").
+		Columns("user_id", "email", "token_hash", "expires_at", "created_at").
+		Values(token.UserID, token.Email, token.TokenHash, token.ExpiresAt, token.CreatedAt).
+		Suffix("RETURNING id")
+
+	return query.RunWith(r.db).QueryRow().Scan(&token.ID)
+}
+
+// GetByHash finds a token in any tenant, along with the tenant of the user
+// it was issued to.
+func (r *EmailVerificationRepository) GetByHash(hash string) (*domain.EmailVerificationToken, error) {
+	token := &domain.EmailVerificationToken{}
+
+	query := r.builder.
+		Select("t.id", "t.user_id", "u.tenant_id", "t.email", "t.token_hash", "t.expires_at", "t.used_at", "t.created_at").
+		From("email_verification_tokens t").
+		Join("users u ON u.id = t.user_id").
+		Where(squirrel.Eq{"t.token_hash": hash})
+
+	var usedAt sql.NullTime
+	err := query.RunWith(r.db).QueryRow().Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TenantID,
+		&token.Email,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&usedAt,
+		&token.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return token, nil
+}
+
+func (r *EmailVerificationRepository) MarkUsed(id int64) (bool, error) {
+	query := r.builder.
+		Update("email_verification_tokens").
+		Set("used_at", time.Now()).
+		Where(squirrel.Eq{"id": id, "used_at": nil})
+
+	result, err := query.RunWith(r.db).Exec()
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateEmailVerificationToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailVerificationRepository(db)
+
+	token := &domain.EmailVerificationToken{
+		UserID:    1,
+		Email:     "john@example.com",
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	mock.ExpectQuery("INSERT INTO email_verification_tokens").
+		WithArgs(token.UserID, token.Email, token.TokenHash, token.ExpiresAt, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	err = repo.Create(token)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), token.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetEmailVerificationTokenByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailVerificationRepository(db)
+
+	t.Run("token exists", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "tenant_id", "email", "token_hash", "expires_at", "used_at", "created_at"}).
+			AddRow(7, 1, 3, "john@example.com", "hash", time.Now(), nil, time.Now())
+
+		mock.ExpectQuery(`SELECT (.+) FROM email_verification_tokens t JOIN users u ON u.id = t.user_id WHERE t.token_hash = \$1`).
+			WithArgs("hash").
+			WillReturnRows(rows)
+
+		token, err := repo.GetByHash("hash")
+		assert.NoError(t, err)
+		assert.NotNil(t, token)
+		assert.Equal(t, int64(1), token.UserID)
+		assert.Equal(t, int64(3), token.TenantID)
+		assert.Nil(t, token.UsedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("token not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM email_verification_tokens").
+			WithArgs("missing").
+			WillReturnError(sql.ErrNoRows)
+
+		token, err := repo.GetByHash("missing")
+		assert.NoError(t, err)
+		assert.Nil(t, token)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMarkEmailVerificationTokenUsed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewEmailVerificationRepository(db)
+
+	t.Run("unused token", func(t *testing.T) {
+		mock.ExpectExec("UPDATE email_verification_tokens").
+			WithArgs(sqlmock.AnyArg(), 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		used, err := repo.MarkUsed(7)
+		assert.NoError(t, err)
+		assert.True(t, used)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already used token", func(t *testing.T) {
+		mock.ExpectExec("UPDATE email_verification_tokens").
+			WithArgs(sqlmock.AnyArg(), 7).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		used, err := repo.MarkUsed(7)
+		assert.NoError(t, err)
+		assert.False(t, used)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
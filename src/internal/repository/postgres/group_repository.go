@@ -0,0 +1,335 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// groupHierarchyLockKey identifies the advisory lock that serializes moving
+// groups, so that two concurrent moves cannot close a cycle between them.
+const groupHierarchyLockKey = 0x67726f75
+
+var groupColumns = []string{"id", "name", "description", "parent_id", "created_at", "updated_at"}
+
+const (
+	// groupCycleQuery reports whether $1 is $2 or one of the groups above it.
+	// UNION stops the recursion should a cycle ever exist.
+	groupCycleQuery = `
+WITH RECURSIVE ancestors AS (
+    SELECT id, parent_id FROM groups WHERE id = $2 AND tenant_id = $3
+    UNION
+    SELECT g.id, g.parent_id FROM groups g JOIN ancestors a ON g.id = a.parent_id
+)
+SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $1)`
+
+	userGroupsQuery = `
+WITH RECURSIVE member_groups AS (
+    SELECT g.id, g.parent_id, TRUE AS direct
+    FROM group_members m JOIN groups g ON g.id = m.group_id
+    WHERE m.user_id = $1 AND g.tenant_id = $2
+    UNION
+    SELECT g.id, g.parent_id, FALSE
+    FROM groups g JOIN member_groups mg ON g.id = mg.parent_id
+)
+SELECT g.id, g.name, g.description, g.parent_id, g.created_at, g.updated_at, bool_or(mg.direct)
+FROM member_groups mg JOIN groups g ON g.id = mg.id
+GROUP BY g.id
+ORDER BY g.id`
+)
+
+// GroupRepository reads and writes the groups of one tenant. Memberships
+// are only reached through a group of the tenant.
+type GroupRepository struct {
+	db       squirrel.StdSqlCtx
+	builder  squirrel.StatementBuilderType
+	tenantID int64
+}
+
+func NewGroupRepository(db *sql.DB) *GroupRepository {
+	return &GroupRepository{
+		db:       db,
+		builder:  squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		tenantID: domain.DefaultTenantID,
+	}
+}
+
+// ForTenant returns a repository for the groups of another tenant sharing
+// the connection.
+func (r *GroupRepository) ForTenant(tenantID int64) *GroupRepository {
+	scoped := *r
+	scoped.tenantID = tenantID
+	return &scoped
+}
+
+func (r *GroupRepository) scoped(where squirrel.Sqlizer) squirrel.And {
+	return squirrel.And{squirrel.Eq{"tenant_id": r.tenantID}, where}
+}
+
+func (r *GroupRepository) Create(group *domain.Group) error {
+	now := time.Now().UTC()
+	group.CreatedAt = now
+	group.UpdatedAt = now
+
+	query := r.builder.
+		Insert("groups").
+		Columns("tenant_id", "name", "description", "parent_id", "created_at", "updated_at").
+		Values(r.tenantID, group.Name, group.Description, group.ParentID, group.CreatedAt, group.UpdatedAt).
+		Suffix("RETURNING id")
+
+	err := query.RunWith(r.db).QueryRow().Scan(&group.ID)
+	if isUniqueViolation(err) {
+		return errors.ErrGroupExists
+	}
+	return err
+}
+
+func (r *GroupRepository) GetByID(id int64) (*domain.Group, error) {
+	query := r.builder.
+		Select(groupColumns...).
+		From("groups").
+		Where(r.scoped(squirrel.Eq{"id": id}))
+
+	group, err := scanGroup(query.RunWith(r.db).QueryRow())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (r *GroupRepository) List(filter domain.GroupFilter) ([]*domain.Group, error) {
+	query := r.builder.
+		Select(groupColumns...).
+		From("groups").
+		Where(squirrel.Eq{"tenant_id": r.tenantID}).
+		OrderBy("id")
+	if filter.Limit > 0 {
+		query = query.Limit(uint64(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(uint64(filter.Offset))
+	}
+
+	rows, err := query.RunWith(r.db).Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []*domain.Group{}
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+// Update checks for a cycle and writes the group in one transaction, holding
+// the hierarchy lock while the group gets a parent. It joins the surrounding
+// transaction when the repository is bound to one.
+func (r *GroupRepository) Update(group *domain.Group) error {
+	tx, inTx := r.db.(*sql.Tx)
+	if !inTx {
+		db, ok := r.db.(*sql.DB)
+		if !ok {
+			return errors.ErrTxNotSupported
+		}
+		own, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer own.Rollback()
+		tx = own
+	}
+
+	if group.ParentID != nil {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", groupHierarchyLockKey); err != nil {
+			return err
+		}
+		var cycle bool
+		if err := tx.QueryRow(groupCycleQuery, group.ID, *group.ParentID, r.tenantID).Scan(&cycle); err != nil {
+			return err
+		}
+		if cycle {
+			return errors.ErrGroupCycle
+		}
+	}
+
+	group.UpdatedAt = time.Now().UTC()
+	query := r.builder.
+		Update("groups").
+		Set("name", group.Name).
+		Set("description", group.Description).
+		Set("parent_id", group.ParentID).
+		Set("updated_at", group.UpdatedAt).
+		Where(r.scoped(squirrel.Eq{"id": group.ID}))
+
+	err := execAffectingRow(query.RunWith(tx).Exec())
+	if isUniqueViolation(err) {
+		return errors.ErrGroupExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if !inTx {
+		return tx.Commit()
+	}
+	return nil
+}
+
+func (r *GroupRepository) Delete(id int64) error {
+	query := r.builder.
+		Delete("groups").
+		Where(r.scoped(squirrel.Eq{"id": id}))
+
+	err := execAffectingRow(query.RunWith(r.db).Exec())
+	if isForeignKeyViolation(err) {
+		return errors.ErrGroupHasSubgroups
+	}
+	return err
+}
+
+func (r *GroupRepository) GetMember(groupID, userID int64) (*domain.GroupMember, error) {
+	query := r.builder.
+		Select("m.created_at").
+		From("group_members m").
+		Join("groups g ON g.id = m.group_id").
+		Where(squirrel.Eq{"m.group_id": groupID, "m.user_id": userID, "g.tenant_id": r.tenantID})
+
+	var createdAt time.Time
+	err := query.RunWith(r.db).QueryRow().Scan(&createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &domain.GroupMember{GroupID: groupID, UserID: userID, Direct: true, CreatedAt: &createdAt}, nil
+}
+
+func (r *GroupRepository) AddMember(member *domain.GroupMember) (bool, error) {
+	groups := r.builder.
+		Select("id").
+		Column("?", member.UserID).
+		From("groups").
+		Where(r.scoped(squirrel.Eq{"id": member.GroupID}))
+	query := r.builder.
+		Insert("group_members").
+		Columns("group_id", "user_id").
+		Select(groups).
+		Suffix("ON CONFLICT (group_id, user_id) DO NOTHING RETURNING created_at")
+
+	var createdAt time.Time
+	err := query.RunWith(r.db).QueryRow().Scan(&createdAt)
+	if err == sql.ErrNoRows {
+		existing, err := r.GetMember(member.GroupID, member.UserID)
+		if err != nil {
+			return false, err
+		}
+		if existing == nil {
+			return false, sql.ErrNoRows
+		}
+		*member = *existing
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	member.Direct = true
+	member.CreatedAt = &createdAt
+	return true, nil
+}
+
+func (r *GroupRepository) RemoveMember(groupID, userID int64) error {
+	// Subqueries keep ? placeholders; the outer query numbers them.
+	groups := squirrel.
+		Select("id").
+		From("groups").
+		Where(r.scoped(squirrel.Eq{"id": groupID}))
+	query := r.builder.
+		Delete("group_members").
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Expr("group_id IN (?)", groups))
+
+	return execAffectingRow(query.RunWith(r.db).Exec())
+}
+
+func (r *GroupRepository) ListMembers(groupID int64, filter domain.GroupFilter) ([]*domain.User, error) {
+	members := squirrel.
+		Select("user_id").
+		From("group_members").
+		Where(squirrel.Eq{"group_id": groupID})
+	query := r.builder.
+		Select(userColumns...).
+		From("users").
+		Where(squirrel.Eq{"tenant_id": r.tenantID}).
+		Where(squirrel.Expr("id IN (?)", members)).
+		OrderBy("id")
+	if filter.Limit > 0 {
+		query = query.Limit(uint64(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(uint64(filter.Offset))
+	}
+
+	rows, err := query.RunWith(r.db).Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*domain.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r *GroupRepository) ListUserGroups(userID int64) ([]*domain.UserGroup, error) {
+	rows, err := r.db.Query(userGroupsQuery, userID, r.tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []*domain.UserGroup{}
+	for rows.Next() {
+		group := &domain.UserGroup{}
+		var parentID sql.NullInt64
+		if err := rows.Scan(&group.ID, &group.Name, &group.Description, &parentID, &group.CreatedAt, &group.UpdatedAt, &group.Direct); err != nil {
+			return nil, err
+		}
+		if parentID.Valid {
+			group.ParentID = &parentID.Int64
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+func scanGroup(row squirrel.RowScanner) (*domain.Group, error) {
+	group := &domain.Group{}
+	var parentID sql.NullInt64
+	if err := row.Scan(&group.ID, &group.Name, &group.Description, &parentID, &group.CreatedAt, &group.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		group.ParentID = &parentID.Int64
+	}
+	return group, nil
+}
@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateGroup(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewGroupRepository(db).ForTenant(2)
+	parentID := int64(5)
+
+	t.Run("moved under another group", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`WITH RECURSIVE ancestors`).
+			WithArgs(int64(1), parentID, int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectExec(`UPDATE groups SET name = \$1, description = \$2, parent_id = \$3, updated_at = \$4 WHERE \(tenant_id = \$5 AND id = \$6\)`).
+			WithArgs("Backend", "", &parentID, sqlmock.AnyArg(), int64(2), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Update(&domain.Group{ID: 1, Name: "Backend", ParentID: &parentID})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("moved under its own subgroup", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`WITH RECURSIVE ancestors`).
+			WithArgs(int64(1), parentID, int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		err := repo.Update(&domain.Group{ID: 1, Name: "Backend", ParentID: &parentID})
+		assert.Equal(t, errors.ErrGroupCycle, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteGroupWithSubgroups(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`DELETE FROM groups WHERE \(tenant_id = \$1 AND id = \$2\)`).
+		WithArgs(int64(1), int64(3)).
+		WillReturnError(&pq.Error{Code: foreignKeyViolation})
+
+	err = NewGroupRepository(db).Delete(3)
+	assert.Equal(t, errors.ErrGroupHasSubgroups, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGroupMembers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewGroupRepository(db).ForTenant(2)
+	joined := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("add", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO group_members \(group_id,user_id\) SELECT id, \$1 FROM groups WHERE \(tenant_id = \$2 AND id = \$3\) ON CONFLICT \(group_id, user_id\) DO NOTHING RETURNING created_at`).
+			WithArgs(int64(7), int64(2), int64(4)).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(joined))
+
+		member := &domain.GroupMember{GroupID: 4, UserID: 7}
+		added, err := repo.AddMember(member)
+		assert.NoError(t, err)
+		assert.True(t, added)
+		assert.Equal(t, &joined, member.CreatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("add again", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO group_members`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}))
+		mock.ExpectQuery(`SELECT m.created_at FROM group_members m JOIN groups g ON g.id = m.group_id WHERE`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(joined))
+
+		member := &domain.GroupMember{GroupID: 4, UserID: 7}
+		added, err := repo.AddMember(member)
+		assert.NoError(t, err)
+		assert.False(t, added)
+		assert.True(t, member.Direct)
+		assert.Equal(t, &joined, member.CreatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM group_members WHERE user_id = \$1 AND group_id IN \(SELECT id FROM groups WHERE \(tenant_id = \$2 AND id = \$3\)\)`).
+			WithArgs(int64(7), int64(2), int64(4)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.RemoveMember(4, 7))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("members", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM users WHERE tenant_id = \$1 AND id IN \(SELECT user_id FROM group_members WHERE group_id = \$2\) ORDER BY id LIMIT 10`).
+			WithArgs(int64(2), int64(4)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "status", "email_verified_at", "mfa_enabled", "locked_until", "created_at", "updated_at", "attributes", "status_reason", "status_changed_at"}).
+				AddRow(7, "Ivan", "ivan@example.com", domain.UserStatusActive, nil, false, nil, "2025-03-01T12:00:00Z", "2025-03-01T12:00:00Z", nil, "", nil))
+
+		users, err := repo.ListMembers(4, domain.GroupFilter{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, users, 1) {
+			assert.Equal(t, "Ivan", users[0].Name)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("groups of a user", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(`WITH RECURSIVE member_groups`).
+			WithArgs(int64(7), int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "parent_id", "created_at", "updated_at", "direct"}).
+				AddRow(1, "Engineering", "", nil, now, now, false).
+				AddRow(4, "Backend", "", 1, now, now, true))
+
+		groups, err := repo.ListUserGroups(7)
+		assert.NoError(t, err)
+		if assert.Len(t, groups, 2) {
+			assert.False(t, groups[0].Direct)
+			assert.Nil(t, groups[0].ParentID)
+			assert.True(t, groups[1].Direct)
+			assert.Equal(t, int64(1), *groups[1].ParentID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
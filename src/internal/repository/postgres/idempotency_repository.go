@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"users-api/src/internal/domain"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type IdempotencyRepository struct {
+	db      *sql.DB
+	builder squirrel.StatementBuilderType
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// Reserve claims the key for a new request. An expired record with the same
+// key is taken over; a live one is left untouched and Reserve returns false.
+func (r *IdempotencyRepository) Reserve(record *domain.IdempotencyRecord) (bool, error) {
+	query := r.builder.
+		Insert("idempotency_keys").
+		Columns("key", "fingerprint", "created_at", "expires_at").
+		Values(record.Key, record.Fingerprint, record.CreatedAt, record.ExpiresAt).
+		Suffix(`ON CONFLICT (key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL,
+			content_type = '',
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= EXCLUDED.created_at`)
+
+	result, err := query.RunWith(r.db).Exec()
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (r *IdempotencyRepository) Get(key string) (*domain.IdempotencyRecord, error) {
+	record := &domain.IdempotencyRecord{}
+
+	query := r.builder.
+		Select("key", "fingerprint", "status_code", "content_type", "response_body", "created_at", "expires_at").
+		From("idempotency_keys").
+		Where(squirrel.Eq{"key": key})
+
+	var statusCode sql.NullInt64
+	err := query.RunWith(r.db).QueryRow().Scan(
+		&record.Key,
+		&record.Fingerprint,
+		&statusCode,
+		&record.ContentType,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		record.StatusCode = &code
+	}
+
+	return record, nil
+}
+
+func (r *IdempotencyRepository) Complete(key string, statusCode int, contentType string, body []byte) error {
+	query := r.builder.
+		Update("idempotency_keys").
+		Set("status_code", statusCode).
+		Set("content_type", contentType).
+		Set("response_body", body).
+		Where(squirrel.Eq{"key": key})
+
+	_, err := query.RunWith(r.db).Exec()
+	return err
+}
+
+func (r *IdempotencyRepository) Release(key string) error {
+	query := r.builder.
+		Delete("idempotency_keys").
+		Where(squirrel.Eq{"key": key, "status_code": nil})
+
+	_, err := query.RunWith(r.db).Exec()
+	return err
+}
+
+func (r *IdempotencyRepository) DeleteExpired(before time.Time) (int64, error) {
+	query := r.builder.
+		Delete("idempotency_keys").
+		Where(squirrel.LtOrEq{"expires_at": before})
+
+	result, err := query.RunWith(r.db).Exec()
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewIdempotencyRepository(db)
+	now := time.Now()
+	record := &domain.IdempotencyRecord{
+		Key:         "key-1",
+		Fingerprint: "fp",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+
+	t.Run("new key", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO idempotency_keys (.+) ON CONFLICT").
+			WithArgs(record.Key, record.Fingerprint, record.CreatedAt, record.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		reserved, err := repo.Reserve(record)
+		assert.NoError(t, err)
+		assert.True(t, reserved)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("live key", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO idempotency_keys (.+) ON CONFLICT").
+			WithArgs(record.Key, record.Fingerprint, record.CreatedAt, record.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		reserved, err := repo.Reserve(record)
+		assert.NoError(t, err)
+		assert.False(t, reserved)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"users-api/src/internal/domain"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type LockoutRepository struct {
+	db      *sql.DB
+	builder squirrel.StatementBuilderType
+}
+
+func NewLockoutRepository(db *sql.DB) *LockoutRepository {
+	return &LockoutRepository{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// Reserve counts the attempt in a single statement, so concurrent attempts
+// get consecutive counts. A failure older than window starts a new count.
+func (r *LockoutRepository) Reserve(scope, subject string, at time.Time, window time.Duration) (*domain.LockoutState, error) {
+	query := r.builder.
+		Insert("auth_failures").
+		Columns("scope", "subject", "failures", "last_failure_at").
+		Values(scope, subject, 1, at).
+		Suffix(`ON CONFLICT (scope, subject) DO UPDATE SET
+			failures = CASE
+				WHEN auth_failures.locked_until > ? THEN auth_failures.failures
+				WHEN auth_failures.last_failure_at < ? THEN 1
+				ELSE auth_failures.failures + 1 END,
+			last_failure_at = CASE
+				WHEN auth_failures.locked_until > ? THEN auth_failures.last_failure_at
+				ELSE EXCLUDED.last_failure_at END
+			RETURNING failures, last_failure_at, locked_until`, at, at.Add(-window), at)
+
+	state := &domain.LockoutState{Scope: scope, Subject: subject}
+	var lockedUntil sql.NullTime
+	if err := query.RunWith(r.db).QueryRow().Scan(&state.Failures, &state.LastFailureAt, &lockedUntil); err != nil {
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		state.LockedUntil = &lockedUntil.Time
+	}
+
+	return state, nil
+}
+
+func (r *LockoutRepository) Lock(scope, subject string, until time.Time) error {
+	query := r.builder.
+		Update("auth_failures").
+		Set("locked_until", until).
+		Where(squirrel.Eq{"scope": scope, "subject": subject})
+
+	_, err := query.RunWith(r.db).Exec()
+	return err
+}
+
+func (r *LockoutRepository) Refund(scope, subject string, failures int) error {
+	query := r.builder.
+		Update("auth_failures").
+		Set("failures", squirrel.Expr("GREATEST(failures - 1, 0)")).
+		Set("locked_until", squirrel.Expr("CASE WHEN failures = ? THEN NULL ELSE locked_until END", failures)).
+		Where(squirrel.Eq{"scope": scope, "subject": subject})
+
+	_, err := query.RunWith(r.db).Exec()
+	return err
+}
+
+func (r *LockoutRepository) Reset(scope, subject string) error {
+	query := r.builder.
+		Delete("auth_failures").
+		Where(squirrel.Eq{"scope": scope, "subject": subject})
+
+	_, err := query.RunWith(r.db).Exec()
+	return err
+}
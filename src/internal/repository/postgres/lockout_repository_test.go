@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserveLockout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLockoutRepository(db)
+	now := time.Now()
+	until := now.Add(time.Minute)
+
+	mock.ExpectQuery("INSERT INTO auth_failures (.+) ON CONFLICT (.+) RETURNING failures, last_failure_at, locked_until").
+		WithArgs("user", "1", 1, now, now, now.Add(-time.Hour), now).
+		WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failure_at", "locked_until"}).AddRow(3, now, until))
+
+	state, err := repo.Reserve("user", "1", now, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, state.Failures)
+	assert.Equal(t, until, *state.LockedUntil)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefundLockout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLockoutRepository(db)
+
+	mock.ExpectExec(`UPDATE auth_failures SET failures = GREATEST\(failures - 1, 0\), locked_until = CASE WHEN failures = \$1 THEN NULL ELSE locked_until END WHERE scope = \$2 AND subject = \$3`).
+		WithArgs(3, "ip", "192.0.2.1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.Refund("ip", "192.0.2.1", 3))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResetLockout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewLockoutRepository(db)
+
+	mock.ExpectExec("DELETE FROM auth_failures").
+		WithArgs("user", "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.Reset("user", "1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,183 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"users-api/src/internal/domain"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type MFARepository struct {
+	db      *sql.DB
+	builder squirrel.StatementBuilderType
+}
+
+func NewMFARepository(db *sql.DB) *MFARepository {
+	return &MFARepository{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (r *MFARepository) Get(userID int64) (*domain.UserMFA, error) {
+	mfa := &domain.UserMFA{}
+
+	query := r.builder.
+		Select("user_id", "secret", "enabled_at", "last_used_step", "created_at").
+		From("user_mfa").
+		Where(squirrel.Eq{"user_id": userID})
+
+	var enabledAt sql.NullTime
+	err := query.RunWith(r.db).QueryRow().Scan(
+		&mfa.UserID,
+		&mfa.Secret,
+		&enabledAt,
+		&mfa.LastUsedStep,
+		&mfa.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if enabledAt.Valid {
+		mfa.EnabledAt = &enabledAt.Time
+	}
+
+	return mfa, nil
+}
+
+// Enroll stores a new secret for a user without MFA, replacing an
+// unconfirmed one. It writes nothing and reports false once MFA is enabled,
+// so a late enrollment cannot undo a confirmation.
+func (r *MFARepository) Enroll(mfa *domain.UserMFA) (bool, error) {
+	if mfa.CreatedAt.IsZero() {
+		mfa.CreatedAt = time.Now()
+	}
+
+	query := r.builder.
+		Insert("user_mfa").
+		Columns("user_id", "secret", "enabled_at", "last_used_step", "created_at").
+		Values(mfa.UserID, mfa.Secret, nil, mfa.LastUsedStep, mfa.CreatedAt).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = EXCLUDED.last_used_step, created_at = EXCLUDED.created_at WHERE user_mfa.enabled_at IS NULL")
+
+	result, err := query.RunWith(r.db).Exec()
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// Enable turns on the unconfirmed enrollment with secret. It reports false
+// when the enrollment was confirmed or replaced in the meantime.
+func (r *MFARepository) Enable(userID int64, secret string, enabledAt time.Time, step int64) (bool, error) {
+	query := r.builder.
+		Update("user_mfa").
+		Set("enabled_at", enabledAt).
+		Set("last_used_step", step).
+		Where(squirrel.Eq{"user_id": userID, "secret": secret, "enabled_at": nil})
+
+	result, err := query.RunWith(r.db).Exec()
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (r *MFARepository) Delete(userID int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := r.builder.Delete("mfa_recovery_codes").Where(squirrel.Eq{"user_id": userID}).RunWith(tx).Exec(); err != nil {
+		return err
+	}
+	if _, err := r.builder.Delete("user_mfa").Where(squirrel.Eq{"user_id": userID}).RunWith(tx).Exec(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *MFARepository) AdvanceStep(userID int64, step int64) (bool, error) {
+	query := r.builder.
+		Update("user_mfa").
+		Set("last_used_step", step).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Lt{"last_used_step": step})
+
+	result, err := query.RunWith(r.db).Exec()
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (r *MFARepository) ReplaceRecoveryCodes(userID int64, hashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := r.builder.Delete("mfa_recovery_codes").Where(squirrel.Eq{"user_id": userID}).RunWith(tx).Exec(); err != nil {
+		return err
+	}
+
+	if len(hashes) > 0 {
+		insert := r.builder.Insert("mfa_recovery_codes").Columns("user_id", "code_hash")
+		for _, hash := range hashes {
+			insert = insert.Values(userID, hash)
+		}
+		if _, err := insert.RunWith(tx).Exec(); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *MFARepository) UseRecoveryCode(userID int64, hash string) (bool, error) {
+	query := r.builder.
+		Update("mfa_recovery_codes").
+		Set("used_at", time.Now()).
+		Where(squirrel.Eq{"user_id": userID, "code_hash": hash, "used_at": nil})
+
+	result, err := query.RunWith(r.db).Exec()
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"users-api/src/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMFAEnroll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewMFARepository(db)
+	createdAt := time.Now()
+
+	mock.ExpectExec(`INSERT INTO user_mfa (.+) ON CONFLICT \(user_id\) DO UPDATE (.+) WHERE user_mfa.enabled_at IS NULL`).
+		WithArgs(int64(1), "SECRET", nil, int64(0), createdAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO user_mfa`).
+		WithArgs(int64(1), "OTHER", nil, int64(0), createdAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	enrolled, err := repo.Enroll(&domain.UserMFA{UserID: 1, Secret: "SECRET", CreatedAt: createdAt})
+	assert.NoError(t, err)
+	assert.True(t, enrolled)
+
+	// MFA was enabled in between: the conflict update matches no row.
+	enrolled, err = repo.Enroll(&domain.UserMFA{UserID: 1, Secret: "OTHER", CreatedAt: createdAt})
+	assert.NoError(t, err)
+	assert.False(t, enrolled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMFAEnable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewMFARepository(db)
+	now := time.Now()
+
+	mock.ExpectExec(`UPDATE user_mfa SET enabled_at = \$1, last_used_step = \$2 WHERE enabled_at IS NULL AND secret = \$3 AND user_id = \$4`).
+		WithArgs(now, int64(42), "SECRET", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	enabled, err := repo.Enable(1, "SECRET", now, 42)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"users-api/src/internal/domain"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type TenantRepository struct {
+	db      squirrel.StdSqlCtx
+	builder squirrel.StatementBuilderType
+}
+
+func NewTenantRepository(db *sql.DB) *TenantRepository {
+	return &TenantRepository{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (r *TenantRepository) Create(tenant *domain.Tenant) error {
+	tenant.CreatedAt = time.Now().UTC()
+
+	query := r.builder.
+		Insert("tenants").
+		Columns("name", "created_at").
+		Values(tenant.Name, tenant.CreatedAt).
+		Suffix("RETURNING id")
+
+	return query.RunWith(r.db).QueryRow().Scan(&tenant.ID)
+}
+
+func (r *TenantRepository) GetByID(id int64) (*domain.Tenant, error) {
+	query := r.builder.
+		Select("id", "name", "created_at").
+		From("tenants").
+		Where(squirrel.Eq{"id": id})
+
+	return scanTenant(query.RunWith(r.db).QueryRow())
+}
+
+func (r *TenantRepository) AddAPIKey(tenantID int64, keyHash string) error {
+	query := r.builder.
+		Insert("tenant_api_keys").
+		Columns("key_hash", "tenant_id").
+		Values(keyHash, tenantID)
+
+	_, err := query.RunWith(r.db).Exec()
+	return err
+}
+
+func (r *TenantRepository) GetByAPIKey(keyHash string) (*domain.Tenant, error) {
+	query := r.builder.
+		Select("t.id", "t.name", "t.created_at").
+		From("tenant_api_keys k").
+		Join("tenants t ON t.id = k.tenant_id").
+		Where(squirrel.Eq{"k.key_hash": keyHash})
+
+	return scanTenant(query.RunWith(r.db).QueryRow())
+}
+
+func scanTenant(row squirrel.RowScanner) (*domain.Tenant, error) {
+	tenant := &domain.Tenant{}
+	err := row.Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
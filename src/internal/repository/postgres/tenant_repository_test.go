@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTenantByAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewTenantRepository(db)
+
+	mock.ExpectQuery(`SELECT t.id, t.name, t.created_at FROM tenant_api_keys k JOIN tenants t ON t.id = k.tenant_id WHERE k.key_hash = \$1`).
+		WithArgs("known").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}).AddRow(2, "Acme", time.Now()))
+	mock.ExpectQuery(`SELECT (.+) FROM tenant_api_keys`).
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}))
+
+	tenant, err := repo.GetByAPIKey("known")
+	assert.NoError(t, err)
+	if assert.NotNil(t, tenant) {
+		assert.Equal(t, int64(2), tenant.ID)
+		assert.Equal(t, "Acme", tenant.Name)
+	}
+
+	tenant, err = repo.GetByAPIKey("unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, tenant)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"users-api/src/internal/domain"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// TxManager runs transactions whose user and event repositories are scoped
+// to one tenant.
+type TxManager struct {
+	db       *sql.DB
+	tenantID int64
+}
+
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{db: db, tenantID: domain.DefaultTenantID}
+}
+
+func (m *TxManager) ForTenant(tenantID int64) *TxManager {
+	return &TxManager{db: m.db, tenantID: tenantID}
+}
+
+func (m *TxManager) WithinTx(fn func(tx domain.Tx) error) error {
+	sqlTx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	if err := fn(newTx(sqlTx, m.tenantID)); err != nil {
+		return err
+	}
+
+	return sqlTx.Commit()
+}
+
+type tx struct {
+	users    *UserRepository
+	events   *UserEventRepository
+	webhooks *WebhookDeliveryRepository
+	outbox   *UserEventOutboxRepository
+	tokens   *EmailVerificationRepository
+}
+
+func newTx(sqlTx *sql.Tx, tenantID int64) *tx {
+	builder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	return &tx{
+		users:    &UserRepository{db: sqlTx, builder: builder, tenantID: tenantID},
+		events:   &UserEventRepository{db: sqlTx, builder: builder, tenantID: tenantID},
+		webhooks: &WebhookDeliveryRepository{db: sqlTx, builder: builder},
+		outbox:   &UserEventOutboxRepository{db: sqlTx, builder: builder},
+		tokens:   &EmailVerificationRepository{db: sqlTx, builder: builder},
+	}
+}
+
+func (t *tx) Users() domain.UserRepository {
+	return t.users
+}
+
+func (t *tx) Events() domain.UserEventRepository {
+	return t.events
+}
+
+func (t *tx) Webhooks() domain.WebhookDeliveryRepository {
+	return t.webhooks
+}
+
+func (t *tx) Outbox() domain.UserEventOutbox {
+	return t.outbox
+}
+
+func (t *tx) EmailVerifications() domain.EmailVerificationRepository {
+	return t.tokens
+}
@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"testing"
+	"users-api/src/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	tm := NewTxManager(db).ForTenant(3)
+	users := []*domain.User{
+		{Name: "John Doe", Email: "john@example.com", EmailCanonical: "john@example.com", Status: domain.UserStatusActive},
+		{Name: "Jane Doe", Email: "jane@example.com", EmailCanonical: "jane@example.com", Status: domain.UserStatusActive},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DROP TABLE IF EXISTS users_bulk").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TEMP TABLE users_bulk").WillReturnResult(sqlmock.NewResult(0, 0))
+	copyStmt := mock.ExpectPrepare("COPY")
+	copyStmt.ExpectExec().WithArgs(0, "John Doe", "john@example.com", "john@example.com", domain.UserStatusActive, "{}", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	copyStmt.ExpectExec().WithArgs(1, "Jane Doe", "jane@example.com", "jane@example.com", domain.UserStatusActive, "{}", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	copyStmt.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO users (.+) SELECT (.+) FROM users_bulk").
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).
+			AddRow(10, "john@example.com").
+			AddRow(11, "jane@example.com"))
+	mock.ExpectCommit()
+
+	err = tm.WithinTx(func(tx domain.Tx) error {
+		return tx.Users().(domain.UserBulkCreator).BulkCreate(users)
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), users[0].ID)
+	assert.Equal(t, int64(11), users[1].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// outboxLockKey identifies the advisory lock held by the active relay.
+const outboxLockKey = 0x75736572
+
+type UserEventOutboxRepository struct {
+	db      squirrel.StdSqlCtx
+	builder squirrel.StatementBuilderType
+}
+
+func NewUserEventOutboxRepository(db *sql.DB) *UserEventOutboxRepository {
+	return &UserEventOutboxRepository{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (r *UserEventOutboxRepository) Add(event *domain.UserEvent) error {
+	query := r.builder.
+		Insert("user_event_outbox").
+		Columns("event_id", "user_id").
+		Values(event.ID, event.UserID)
+
+	_, err := query.RunWith(r.db).Exec()
+	return err
+}
+
+// Relay runs in its own transaction, so published events are removed only
+// once publish has returned. Writes to one user are serialized by its row
+// lock and log their event after the write, so ID order is commit order per
+// user. Events that were not published get blocked_until, which keeps every
+// event of their user out of the batches until it passes.
+func (r *UserEventOutboxRepository) Relay(limit int, retryAfter time.Duration, publish func(events []*domain.UserEvent) []int64) (int, error) {
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return 0, errors.ErrTxNotSupported
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRow("SELECT pg_try_advisory_xact_lock($1)", outboxLockKey).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	query := r.builder.
+		Select("e.id", "e.type", "e.user_id", "e.payload", "e.occurred_at").
+		From("user_event_outbox o").
+		Join("user_events e ON e.id = o.event_id").
+		Where("NOT EXISTS (SELECT 1 FROM user_event_outbox b WHERE b.user_id = o.user_id AND b.blocked_until > NOW())").
+		OrderBy("o.event_id").
+		Limit(uint64(limit))
+
+	rows, err := query.RunWith(tx).Query()
+	if err != nil {
+		return 0, err
+	}
+	events := []*domain.UserEvent{}
+	for rows.Next() {
+		event, err := scanUserEvent(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	published := publish(events)
+	if len(published) > 0 {
+		del := r.builder.
+			Delete("user_event_outbox").
+			Where(squirrel.Eq{"event_id": published})
+		if _, err := del.RunWith(tx).Exec(); err != nil {
+			return 0, err
+		}
+	}
+	if held := unpublished(events, published); len(held) > 0 {
+		block := r.builder.
+			Update("user_event_outbox").
+			Set("blocked_until", squirrel.Expr("NOW() + make_interval(secs => ?)", retryAfter.Seconds())).
+			Where(squirrel.Eq{"event_id": held})
+		if _, err := block.RunWith(tx).Exec(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(published), tx.Commit()
+}
+
+func unpublished(events []*domain.UserEvent, published []int64) []int64 {
+	done := make(map[int64]bool, len(published))
+	for _, id := range published {
+		done[id] = true
+	}
+	var held []int64
+	for _, event := range events {
+		if !done[event.ID] {
+			held = append(held, event.ID)
+		}
+	}
+	return held
+}
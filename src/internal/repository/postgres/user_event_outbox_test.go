@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelayUserEventOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserEventOutboxRepository(db)
+
+	t.Run("publishes and removes", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+		mock.ExpectQuery(`SELECT e.id, e.type, e.user_id, e.payload, e.occurred_at FROM user_event_outbox o JOIN user_events e ON e.id = o.event_id WHERE NOT EXISTS \(SELECT 1 FROM user_event_outbox b WHERE b.user_id = o.user_id AND b.blocked_until > NOW\(\)\) ORDER BY o.event_id LIMIT 10`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "user_id", "payload", "occurred_at"}).
+				AddRow(5, domain.UserEventDeleted, 7, nil, time.Now()).
+				AddRow(6, domain.UserEventCreated, 8, []byte(`{"id":8}`), time.Now()))
+		mock.ExpectExec(`DELETE FROM user_event_outbox WHERE event_id IN \(\$1\)`).
+			WithArgs(int64(6)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE user_event_outbox SET blocked_until = NOW\(\) \+ make_interval\(secs => \$1\) WHERE event_id IN \(\$2\)`).
+			WithArgs(float64(60), int64(5)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		n, err := repo.Relay(10, time.Minute, func(events []*domain.UserEvent) []int64 {
+			assert.Len(t, events, 2)
+			return []int64{6}
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("another relay holds the lock", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+		mock.ExpectRollback()
+
+		n, err := repo.Relay(10, time.Minute, func(events []*domain.UserEvent) []int64 {
+			t.Fatal("publish must not be called")
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
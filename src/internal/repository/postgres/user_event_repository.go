@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"users-api/src/internal/domain"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// UserEventRepository keeps the change log of one tenant's users.
+type UserEventRepository struct {
+	db       squirrel.StdSqlCtx
+	builder  squirrel.StatementBuilderType
+	tenantID int64
+}
+
+func NewUserEventRepository(db *sql.DB) *UserEventRepository {
+	return &UserEventRepository{
+		db:       db,
+		builder:  squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		tenantID: domain.DefaultTenantID,
+	}
+}
+
+func (r *UserEventRepository) ForTenant(tenantID int64) *UserEventRepository {
+	scoped := *r
+	scoped.tenantID = tenantID
+	return &scoped
+}
+
+func (r *UserEventRepository) Append(event *domain.UserEvent) error {
+	var payload interface{}
+	if event.User != nil {
+		data, err := json.Marshal(event.User)
+		if err != nil {
+			return err
+		}
+		payload = data
+	}
+
+	query := r.builder.
+		Insert("user_events").
+		Columns("tenant_id", "type", "user_id", "payload", "occurred_at").
+		Values(r.tenantID, event.Type, event.UserID, payload, event.OccurredAt).
+		Suffix("RETURNING id")
+
+	return query.RunWith(r.db).QueryRow().Scan(&event.ID)
+}
+
+func (r *UserEventRepository) ListAfter(afterID, userID int64, limit int) ([]*domain.UserEvent, error) {
+	query := r.builder.
+		Select("id", "type", "user_id", "payload", "occurred_at").
+		From("user_events").
+		Where(squirrel.Eq{"tenant_id": r.tenantID}).
+		Where(squirrel.Gt{"id": afterID}).
+		OrderBy("id").
+		Limit(uint64(limit))
+
+	if userID != 0 {
+		query = query.Where(squirrel.Eq{"user_id": userID})
+	}
+
+	rows, err := query.RunWith(r.db).Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*domain.UserEvent{}
+	for rows.Next() {
+		event, err := scanUserEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func scanUserEvent(row squirrel.RowScanner) (*domain.UserEvent, error) {
+	event := &domain.UserEvent{}
+	var payload []byte
+	if err := row.Scan(&event.ID, &event.Type, &event.UserID, &payload, &event.OccurredAt); err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		event.User = &domain.User{}
+		if err := json.Unmarshal(payload, event.User); err != nil {
+			return nil, err
+		}
+	}
+	return event, nil
+}
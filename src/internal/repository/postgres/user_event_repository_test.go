@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendUserEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserEventRepository(db)
+	now := time.Now()
+
+	t.Run("with snapshot", func(t *testing.T) {
+		event := &domain.UserEvent{Type: domain.UserEventCreated, UserID: 1, User: &domain.User{ID: 1, Name: "John Doe"}, OccurredAt: now}
+
+		mock.ExpectQuery("INSERT INTO user_events").
+			WithArgs(domain.DefaultTenantID, domain.UserEventCreated, int64(1), sqlmock.AnyArg(), now).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+		assert.NoError(t, repo.Append(event))
+		assert.Equal(t, int64(42), event.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("deletion has no payload", func(t *testing.T) {
+		event := &domain.UserEvent{Type: domain.UserEventDeleted, UserID: 1, OccurredAt: now}
+
+		mock.ExpectQuery("INSERT INTO user_events").
+			WithArgs(domain.DefaultTenantID, domain.UserEventDeleted, int64(1), nil, now).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(43))
+
+		assert.NoError(t, repo.Append(event))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestListUserEventsAfter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserEventRepository(db).ForTenant(2)
+
+	rows := sqlmock.NewRows([]string{"id", "type", "user_id", "payload", "occurred_at"}).
+		AddRow(11, domain.UserEventUpdated, 7, []byte(`{"id":7,"name":"John Doe"}`), time.Now()).
+		AddRow(12, domain.UserEventDeleted, 7, nil, time.Now())
+
+	mock.ExpectQuery(`SELECT id, type, user_id, payload, occurred_at FROM user_events WHERE tenant_id = \$1 AND id > \$2 AND user_id = \$3 ORDER BY id LIMIT 100`).
+		WithArgs(int64(2), int64(10), int64(7)).
+		WillReturnRows(rows)
+
+	events, err := repo.ListAfter(10, 7, 100)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "John Doe", events[0].User.Name)
+	assert.Nil(t, events[1].User)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,25 +2,52 @@ package postgres
 
 import (
 	"database/sql"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
 
 	"github.com/Masterminds/squirrel"
 )
 
+const (
+	mfaEnabledColumn  = "EXISTS (SELECT 1 FROM user_mfa WHERE user_mfa.user_id = users.id AND user_mfa.enabled_at IS NOT NULL)"
+	lockedUntilColumn = "(SELECT locked_until FROM auth_failures WHERE auth_failures.scope = 'user' AND auth_failures.subject = users.id::text AND auth_failures.locked_until > NOW())"
+)
+
+var userColumns = []string{"id", "name", "email", "status", "email_verified_at", mfaEnabledColumn, lockedUntilColumn, "created_at", "updated_at", "attributes", "status_reason", "status_changed_at"}
+
+// UserRepository reads and writes the users of one tenant; every query is
+// restricted to its tenant_id.
 type UserRepository struct {
-	db      *sql.DB
-	builder squirrel.StatementBuilderType
+	db       squirrel.StdSqlCtx
+	builder  squirrel.StatementBuilderType
+	tenantID int64
 }
 
 func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{
-		db:      db,
-		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		db:       db,
+		builder:  squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		tenantID: domain.DefaultTenantID,
 	}
 }
 
+// ForTenant returns a repository for the users of another tenant sharing the
+// connection.
+func (r *UserRepository) ForTenant(tenantID int64) *UserRepository {
+	scoped := *r
+	scoped.tenantID = tenantID
+	return &scoped
+}
+
+// scoped restricts where to the repository's tenant.
+func (r *UserRepository) scoped(where squirrel.Sqlizer) squirrel.And {
+	return squirrel.And{squirrel.Eq{"tenant_id": r.tenantID}, where}
+}
+
 func (r *UserRepository) Create(user *domain.User) error {
 	now := time.Now().Format(time.RFC3339)
 	user.CreatedAt = now
@@ -28,11 +55,14 @@ func (r *UserRepository) Create(user *domain.User) error {
 
 	query := r.builder.
 		Insert("users").
-		Columns("name", "email", "created_at", "updated_at").
-		Values(user.Name, user.Email, user.CreatedAt, user.UpdatedAt).
+		Columns("tenant_id", "name", "email", "email_canonical", "status", "attributes", "created_at", "updated_at").
+		Values(r.tenantID, user.Name, user.Email, user.EmailCanonical, user.Status, attributesJSON(user.Attributes), user.CreatedAt, user.UpdatedAt).
 		Suffix("RETURNING id")
 
 	err := query.RunWith(r.db).QueryRow().Scan(&user.ID)
+	if isUniqueViolation(err) {
+		return errors.ErrEmailTaken
+	}
 	if err != nil {
 		return err
 	}
@@ -41,27 +71,172 @@ func (r *UserRepository) Create(user *domain.User) error {
 }
 
 func (r *UserRepository) GetByID(id int64) (*domain.User, error) {
-	user := &domain.User{}
+	return r.getOne(squirrel.Eq{"id": id})
+}
+
+// GetByIDForUpdate is GetByID that also locks the row until the end of the
+// transaction, for changes that depend on the current values.
+func (r *UserRepository) GetByIDForUpdate(id int64) (*domain.User, error) {
+	return r.getOne(squirrel.Eq{"id": id}, "FOR UPDATE OF users")
+}
+
+func (r *UserRepository) GetByEmail(canonical string) (*domain.User, error) {
+	return r.getOne(squirrel.Eq{"email_canonical": canonical})
+}
+
+// GetByIDs returns the users with the given IDs in no particular order,
+// silently skipping IDs that do not exist.
+func (r *UserRepository) GetByIDs(ids []int64) ([]*domain.User, error) {
+	if len(ids) == 0 {
+		return []*domain.User{}, nil
+	}
+
+	query := r.builder.
+		Select(userColumns...).
+		From("users").
+		Where(r.scoped(squirrel.Eq{"id": ids}))
+
+	return r.queryUsers(query)
+}
+
+func (r *UserRepository) getOne(where squirrel.Sqlizer, suffix ...string) (*domain.User, error) {
+	query := r.builder.
+		Select(userColumns...).
+		From("users").
+		Where(r.scoped(where))
+	for _, s := range suffix {
+		query = query.Suffix(s)
+	}
+
+	user, err := scanUser(query.RunWith(r.db).QueryRow())
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) List(filter domain.UserFilter) ([]*domain.User, error) {
+	query := r.selectUsers(filter).OrderBy("id")
+	if filter.Limit > 0 {
+		query = query.Limit(uint64(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(uint64(filter.Offset))
+	}
+
+	return r.queryUsers(query)
+}
 
+func (r *UserRepository) Count(filter domain.UserFilter) (int, error) {
+	query := r.selectUsers(filter).RemoveColumns().Column("COUNT(*)")
+
+	var count int
+	if err := query.RunWith(r.db).QueryRow().Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *UserRepository) queryUsers(query squirrel.SelectBuilder) ([]*domain.User, error) {
+	rows, err := query.RunWith(r.db).Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*domain.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *UserRepository) selectUsers(filter domain.UserFilter) squirrel.SelectBuilder {
 	query := r.builder.
-		Select("id", "name", "email", "created_at", "updated_at").
+		Select(userColumns...).
 		From("users").
-		Where(squirrel.Eq{"id": id})
+		Where(squirrel.Eq{"tenant_id": r.tenantID})
+
+	if filter.NameContains != "" {
+		query = query.Where(squirrel.ILike{"name": "%" + escapeLike(filter.NameContains) + "%"})
+	}
+	if filter.EmailContains != "" {
+		query = query.Where(squirrel.ILike{"email": "%" + escapeLike(filter.EmailContains) + "%"})
+	}
+	if filter.Email != "" {
+		query = query.Where(squirrel.ILike{"email": escapeLike(filter.Email)})
+	}
+	if filter.Status != "" {
+		query = query.Where(squirrel.Eq{"status": filter.Status})
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where(squirrel.Eq{"status": filter.Statuses})
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where(squirrel.GtOrEq{"created_at": *filter.CreatedAfter})
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where(squirrel.Lt{"created_at": *filter.CreatedBefore})
+	}
+	if len(filter.Attributes) > 0 {
+		query = query.Where("attributes @> ?::jsonb", attributesJSON(filter.Attributes))
+	}
 
-	err := query.RunWith(r.db).QueryRow().Scan(
+	return query
+}
+
+func scanUser(row squirrel.RowScanner) (*domain.User, error) {
+	user := &domain.User{}
+
+	var emailVerifiedAt, lockedUntil, statusChangedAt sql.NullString
+	var attributes []byte
+	err := row.Scan(
 		&user.ID,
 		&user.Name,
 		&user.Email,
+		&user.Status,
+		&emailVerifiedAt,
+		&user.MFAEnabled,
+		&lockedUntil,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&attributes,
+		&user.StatusReason,
+		&statusChangedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+	if len(attributes) > 0 {
+		if err := json.Unmarshal(attributes, &user.Attributes); err != nil {
+			return nil, err
+		}
+		if len(user.Attributes) == 0 {
+			user.Attributes = nil
+		}
+	}
 
-	if err == sql.ErrNoRows {
-		return nil, nil
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.String
 	}
 
-	if err != nil {
-		return nil, err
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.String
+	}
+
+	if statusChangedAt.Valid {
+		user.StatusChangedAt = &statusChangedAt.String
 	}
 
 	return user, nil
@@ -74,10 +249,19 @@ func (r *UserRepository) Update(user *domain.User) error {
 		Update("users").
 		Set("name", user.Name).
 		Set("email", user.Email).
+		Set("email_canonical", user.EmailCanonical).
+		Set("status", user.Status).
+		Set("status_reason", user.StatusReason).
+		Set("status_changed_at", user.StatusChangedAt).
+		Set("attributes", attributesJSON(user.Attributes)).
+		Set("email_verified_at", user.EmailVerifiedAt).
 		Set("updated_at", user.UpdatedAt).
-		Where(squirrel.Eq{"id": user.ID})
+		Where(r.scoped(squirrel.Eq{"id": user.ID}))
 
 	result, err := query.RunWith(r.db).Exec()
+	if isUniqueViolation(err) {
+		return errors.ErrEmailTaken
+	}
 	if err != nil {
 		return err
 	}
@@ -97,7 +281,7 @@ func (r *UserRepository) Update(user *domain.User) error {
 func (r *UserRepository) Delete(id int64) error {
 	query := r.builder.
 		Delete("users").
-		Where(squirrel.Eq{"id": id})
+		Where(r.scoped(squirrel.Eq{"id": id}))
 
 	result, err := query.RunWith(r.db).Exec()
 	if err != nil {
@@ -115,3 +299,19 @@ func (r *UserRepository) Delete(id int64) error {
 
 	return nil
 }
+
+// attributesJSON encodes attributes for a JSONB column, nil as an empty
+// object.
+func attributesJSON(attributes domain.Attributes) string {
+	if len(attributes) == 0 {
+		return "{}"
+	}
+	b, _ := json.Marshal(attributes)
+	return string(b)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
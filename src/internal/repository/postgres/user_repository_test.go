@@ -5,8 +5,10 @@ import (
 	"testing"
 	"time"
 	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,12 +23,14 @@ func TestCreateUser(t *testing.T) {
 
 	t.Run("successful creation", func(t *testing.T) {
 		user := &domain.User{
-			Name:  "John Doe",
-			Email: "john@example.com",
+			Name:           "John Doe",
+			Email:          "john@example.com",
+			EmailCanonical: "john@example.com",
+			Status:         domain.UserStatusActive,
 		}
 
 		mock.ExpectQuery("INSERT INTO users").
-			WithArgs(user.Name, user.Email, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(domain.DefaultTenantID, user.Name, user.Email, user.EmailCanonical, user.Status, "{}", sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 		err := repo.Create(user)
@@ -34,6 +38,23 @@ func TestCreateUser(t *testing.T) {
 		assert.Equal(t, int64(1), user.ID)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		user := &domain.User{
+			Name:           "John Doe",
+			Email:          "john@example.com",
+			EmailCanonical: "john@example.com",
+			Status:         domain.UserStatusActive,
+		}
+
+		mock.ExpectQuery("INSERT INTO users").
+			WithArgs(domain.DefaultTenantID, user.Name, user.Email, user.EmailCanonical, user.Status, "{}", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		err := repo.Create(user)
+		assert.Equal(t, errors.ErrEmailTaken, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestGetUser(t *testing.T) {
@@ -46,11 +67,11 @@ func TestGetUser(t *testing.T) {
 	repo := NewUserRepository(db)
 
 	t.Run("user exists", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "name", "email", "created_at", "updated_at"}).
-			AddRow(1, "John Doe", "john@example.com", time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "status", "email_verified_at", "mfa_enabled", "locked_until", "created_at", "updated_at", "attributes", "status_reason", "status_changed_at"}).
+			AddRow(1, "John Doe", "john@example.com", "active", nil, false, nil, time.Now(), time.Now(), "{}", "", nil)
 
 		mock.ExpectQuery("SELECT (.+) FROM users").
-			WithArgs(1).
+			WithArgs(domain.DefaultTenantID, 1).
 			WillReturnRows(rows)
 
 		user, err := repo.GetByID(1)
@@ -58,12 +79,13 @@ func TestGetUser(t *testing.T) {
 		assert.NotNil(t, user)
 		assert.Equal(t, "John Doe", user.Name)
 		assert.Equal(t, "john@example.com", user.Email)
+		assert.Nil(t, user.EmailVerifiedAt)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("user not found", func(t *testing.T) {
 		mock.ExpectQuery("SELECT (.+) FROM users").
-			WithArgs(999).
+			WithArgs(domain.DefaultTenantID, 999).
 			WillReturnError(sql.ErrNoRows)
 
 		user, err := repo.GetByID(999)
@@ -73,6 +95,63 @@ func TestGetUser(t *testing.T) {
 	})
 }
 
+func TestGetUserForUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "status", "email_verified_at", "mfa_enabled", "locked_until", "created_at", "updated_at", "attributes", "status_reason", "status_changed_at"}).
+		AddRow(1, "John Doe", "john@example.com", "active", nil, false, nil, time.Now(), time.Now(), "{}", "", nil)
+
+	mock.ExpectQuery(`SELECT (.+) FROM users WHERE \(tenant_id = \$1 AND id = \$2\) FOR UPDATE OF users`).
+		WithArgs(domain.DefaultTenantID, 1).
+		WillReturnRows(rows)
+
+	user, err := repo.GetByIDForUpdate(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), user.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepository(db).ForTenant(2)
+
+	t.Run("user exists", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "status", "email_verified_at", "mfa_enabled", "locked_until", "created_at", "updated_at", "attributes", "status_reason", "status_changed_at"}).
+			AddRow(1, "John Doe", "john@example.com", "active", nil, false, nil, time.Now(), time.Now(), "{}", "", nil)
+
+		mock.ExpectQuery(`SELECT (.+) FROM users WHERE \(tenant_id = \$1 AND email_canonical = \$2\)`).
+			WithArgs(int64(2), "john@example.com").
+			WillReturnRows(rows)
+
+		user, err := repo.GetByEmail("john@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), user.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM users WHERE \(tenant_id = \$1 AND email_canonical = \$2\)`).
+			WithArgs(int64(2), "nobody@example.com").
+			WillReturnError(sql.ErrNoRows)
+
+		user, err := repo.GetByEmail("nobody@example.com")
+		assert.NoError(t, err)
+		assert.Nil(t, user)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestUpdateUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -90,7 +169,7 @@ func TestUpdateUser(t *testing.T) {
 		}
 
 		mock.ExpectExec("UPDATE users").
-			WithArgs(user.Name, user.Email, sqlmock.AnyArg(), user.ID).
+			WithArgs(user.Name, user.Email, user.EmailCanonical, user.Status, user.StatusReason, user.StatusChangedAt, "{}", user.EmailVerifiedAt, sqlmock.AnyArg(), domain.DefaultTenantID, user.ID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
 		err := repo.Update(user)
@@ -106,7 +185,7 @@ func TestUpdateUser(t *testing.T) {
 		}
 
 		mock.ExpectExec("UPDATE users").
-			WithArgs(user.Name, user.Email, sqlmock.AnyArg(), user.ID).
+			WithArgs(user.Name, user.Email, user.EmailCanonical, user.Status, user.StatusReason, user.StatusChangedAt, "{}", user.EmailVerifiedAt, sqlmock.AnyArg(), domain.DefaultTenantID, user.ID).
 			WillReturnResult(sqlmock.NewResult(0, 0))
 
 		err := repo.Update(user)
@@ -126,7 +205,7 @@ func TestDeleteUser(t *testing.T) {
 
 	t.Run("successful deletion", func(t *testing.T) {
 		mock.ExpectExec("DELETE FROM users").
-			WithArgs(1).
+			WithArgs(domain.DefaultTenantID, 1).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
 		err := repo.Delete(1)
@@ -136,7 +215,7 @@ func TestDeleteUser(t *testing.T) {
 
 	t.Run("user not found", func(t *testing.T) {
 		mock.ExpectExec("DELETE FROM users").
-			WithArgs(999).
+			WithArgs(domain.DefaultTenantID, 999).
 			WillReturnResult(sqlmock.NewResult(0, 0))
 
 		err := repo.Delete(999)
@@ -144,3 +223,103 @@ func TestDeleteUser(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestListUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepository(db)
+
+	t.Run("filters and pagination", func(t *testing.T) {
+		after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "status", "email_verified_at", "mfa_enabled", "locked_until", "created_at", "updated_at", "attributes", "status_reason", "status_changed_at"}).
+			AddRow(3, "John Doe", "john@example.com", "active", nil, false, nil, time.Now(), time.Now(), "{}", "", nil)
+
+		mock.ExpectQuery(`SELECT (.+) FROM users WHERE tenant_id = \$1 AND name ILIKE \$2 AND created_at >= \$3 ORDER BY id LIMIT 10 OFFSET 20`).
+			WithArgs(domain.DefaultTenantID, `%50\%%`, after).
+			WillReturnRows(rows)
+
+		users, err := repo.List(domain.UserFilter{NameContains: "50%", CreatedAfter: &after, Limit: 10, Offset: 20})
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, int64(3), users[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("attributes", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "status", "email_verified_at", "mfa_enabled", "locked_until", "created_at", "updated_at", "attributes", "status_reason", "status_changed_at"}).
+			AddRow(4, "Jane Doe", "jane@example.com", "active", nil, false, nil, time.Now(), time.Now(), `{"department":"Sales","level":3}`, "", nil)
+
+		mock.ExpectQuery(`SELECT (.+) FROM users WHERE tenant_id = \$1 AND attributes @> \$2::jsonb ORDER BY id`).
+			WithArgs(domain.DefaultTenantID, `{"department":"Sales"}`).
+			WillReturnRows(rows)
+
+		users, err := repo.List(domain.UserFilter{Attributes: domain.Attributes{"department": "Sales"}})
+		assert.NoError(t, err)
+		if assert.Len(t, users, 1) {
+			assert.Equal(t, domain.Attributes{"department": "Sales", "level": float64(3)}, users[0].Attributes)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM users WHERE tenant_id = (.+) AND email ILIKE").
+			WithArgs(domain.DefaultTenantID, "%nobody%").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "status", "email_verified_at", "mfa_enabled", "locked_until", "created_at", "updated_at", "attributes", "status_reason", "status_changed_at"}))
+
+		users, err := repo.List(domain.UserFilter{EmailContains: "nobody"})
+		assert.NoError(t, err)
+		assert.NotNil(t, users)
+		assert.Empty(t, users)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetUsersByIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "status", "email_verified_at", "mfa_enabled", "locked_until", "created_at", "updated_at", "attributes", "status_reason", "status_changed_at"}).
+		AddRow(1, "John Doe", "john@example.com", "active", nil, false, nil, time.Now(), time.Now(), "{}", "", nil).
+		AddRow(3, "Jane Doe", "jane@example.com", "active", nil, false, nil, time.Now(), time.Now(), "{}", "", nil)
+
+	mock.ExpectQuery(`SELECT (.+) FROM users WHERE \(tenant_id = \$1 AND id IN \(\$2,\$3,\$4\)\)`).
+		WithArgs(domain.DefaultTenantID, 1, 2, 3).
+		WillReturnRows(rows)
+
+	users, err := repo.GetByIDs([]int64{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	users, err = repo.GetByIDs(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestCountUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepository(db)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users WHERE tenant_id = \$1 AND email ILIKE \$2 AND status = \$3`).
+		WithArgs(domain.DefaultTenantID, "john@example.com", domain.UserStatusActive).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	count, err := repo.Count(domain.UserFilter{Email: "john@example.com", Status: domain.UserStatusActive, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"users-api/src/internal/domain"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// searchScore ranks a user by the best of its trigram similarity to the query
+// on name and email and its full-text rank. word_similarity lets a short
+// query match one word of a longer name.
+const searchScore = "GREATEST(word_similarity(?, name), word_similarity(?, email), similarity(?, email), ts_rank(search_vector, plainto_tsquery('simple', ?)))"
+
+// Search finds users whose name or email matches query as whole words or,
+// through the pg_trgm indexes, approximately.
+func (r *UserRepository) Search(query string, limit int) ([]*domain.UserSearchResult, error) {
+	q := r.builder.
+		Select(userColumns...).
+		Column(squirrel.Expr(searchScore+" AS score", query, query, query, query)).
+		From("users").
+		Where(squirrel.Eq{"tenant_id": r.tenantID}).
+		Where(squirrel.Or{
+			squirrel.Expr("search_vector @@ plainto_tsquery('simple', ?)", query),
+			squirrel.Expr("? <% name", query),
+			squirrel.Expr("? <% email", query),
+			squirrel.Expr("email % ?", query),
+		}).
+		OrderBy("score DESC", "id").
+		Limit(uint64(limit))
+
+	rows, err := q.RunWith(r.db).Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []*domain.UserSearchResult{}
+	for rows.Next() {
+		result := &domain.UserSearchResult{}
+		user, err := scanUser(scoredRow{RowScanner: rows, score: &result.Score})
+		if err != nil {
+			return nil, err
+		}
+		result.User = user
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// scoredRow scans a user row followed by a score column.
+type scoredRow struct {
+	squirrel.RowScanner
+	score *float64
+}
+
+func (r scoredRow) Scan(dest ...interface{}) error {
+	return r.RowScanner.Scan(append(dest, r.score)...)
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepository(db)
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "status", "email_verified_at", "mfa_enabled", "locked_until", "created_at", "updated_at", "attributes", "status_reason", "status_changed_at", "score"}).
+		AddRow(3, "Ivan Petrov", "ivan@example.com", "active", nil, false, nil, time.Now(), time.Now(), "{}", "", nil, 0.8).
+		AddRow(5, "Petra Ivanova", "petra@example.com", "active", nil, false, nil, time.Now(), time.Now(), "{}", "", nil, 0.4)
+
+	mock.ExpectQuery(`SELECT (.+), GREATEST\(word_similarity\(\$1, name\), word_similarity\(\$2, email\), similarity\(\$3, email\), ts_rank\(search_vector, plainto_tsquery\('simple', \$4\)\)\) AS score FROM users `+
+		`WHERE tenant_id = \$5 AND \(search_vector @@ plainto_tsquery\('simple', \$6\) OR \$7 <% name OR \$8 <% email OR email % \$9\) ORDER BY score DESC, id LIMIT 20`).
+		WithArgs("petrvo", "petrvo", "petrvo", "petrvo", domain.DefaultTenantID, "petrvo", "petrvo", "petrvo", "petrvo").
+		WillReturnRows(rows)
+
+	results, err := repo.Search("petrvo", 20)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, int64(3), results[0].User.ID)
+	assert.Equal(t, 0.8, results[0].Score)
+	assert.Equal(t, "Petra Ivanova", results[1].User.Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+const streamFetchSize = 500
+
+// Stream walks the users matching filter through a server-side cursor,
+// fetching streamFetchSize rows at a time, so exports of any size use a
+// constant amount of memory.
+func (r *UserRepository) Stream(filter domain.UserFilter, fn func(user *domain.User) error) error {
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return errors.ErrTxNotSupported
+	}
+
+	query, args, err := r.selectUsers(filter).OrderBy("id").ToSql()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DECLARE users_stream NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM users_stream", streamFetchSize)
+	for {
+		rows, err := tx.Query(fetch)
+		if err != nil {
+			return err
+		}
+
+		n := 0
+		for rows.Next() {
+			n++
+			user, err := scanUser(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			if err := fn(user); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return err
+		}
+		rows.Close()
+
+		if n < streamFetchSize {
+			break
+		}
+	}
+
+	if _, err := tx.Exec("CLOSE users_stream"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
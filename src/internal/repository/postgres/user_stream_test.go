@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewUserRepository(db)
+	columns := []string{"id", "name", "email", "status", "email_verified_at", "mfa_enabled", "locked_until", "created_at", "updated_at", "attributes", "status_reason", "status_changed_at"}
+
+	full := sqlmock.NewRows(columns)
+	for i := 1; i <= streamFetchSize; i++ {
+		full.AddRow(i, "User", "user@example.com", "active", nil, false, nil, time.Now(), time.Now(), "{}", "", nil)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DECLARE users_stream NO SCROLL CURSOR FOR SELECT (.+) FROM users WHERE tenant_id = \$1 AND email ILIKE \$2 ORDER BY id`).
+		WithArgs(domain.DefaultTenantID, "%example%").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FETCH FORWARD 500 FROM users_stream").WillReturnRows(full)
+	mock.ExpectQuery("FETCH FORWARD 500 FROM users_stream").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(501, "Last", "last@example.com", "active", nil, false, nil, time.Now(), time.Now(), "{}", "", nil))
+	mock.ExpectExec("CLOSE users_stream").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	var ids []int64
+	err = repo.Stream(domain.UserFilter{EmailContains: "example"}, func(user *domain.User) error {
+		ids = append(ids, user.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ids, streamFetchSize+1)
+	assert.Equal(t, int64(501), ids[len(ids)-1])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
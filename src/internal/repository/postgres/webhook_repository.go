@@ -0,0 +1,298 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"users-api/src/internal/domain"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+)
+
+var webhookSubscriptionColumns = []string{"id", "url", "secret", "event_types", "active", "created_at", "updated_at"}
+
+type WebhookSubscriptionRepository struct {
+	db      squirrel.StdSqlCtx
+	builder squirrel.StatementBuilderType
+}
+
+func NewWebhookSubscriptionRepository(db *sql.DB) *WebhookSubscriptionRepository {
+	return &WebhookSubscriptionRepository{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (r *WebhookSubscriptionRepository) Create(sub *domain.WebhookSubscription) error {
+	now := time.Now().UTC()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+
+	query := r.builder.
+		Insert("webhook_subscriptions").
+		Columns("url", "secret", "event_types", "active", "created_at", "updated_at").
+		Values(sub.URL, sub.Secret, pq.Array(sub.EventTypes), sub.Active, sub.CreatedAt, sub.UpdatedAt).
+		Suffix("RETURNING id")
+
+	return query.RunWith(r.db).QueryRow().Scan(&sub.ID)
+}
+
+func (r *WebhookSubscriptionRepository) GetByID(id int64) (*domain.WebhookSubscription, error) {
+	query := r.builder.
+		Select(webhookSubscriptionColumns...).
+		From("webhook_subscriptions").
+		Where(squirrel.Eq{"id": id})
+
+	sub, err := scanWebhookSubscription(query.RunWith(r.db).QueryRow())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (r *WebhookSubscriptionRepository) List() ([]*domain.WebhookSubscription, error) {
+	query := r.builder.
+		Select(webhookSubscriptionColumns...).
+		From("webhook_subscriptions").
+		OrderBy("id")
+
+	rows, err := query.RunWith(r.db).Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*domain.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func (r *WebhookSubscriptionRepository) Update(sub *domain.WebhookSubscription) error {
+	sub.UpdatedAt = time.Now().UTC()
+
+	query := r.builder.
+		Update("webhook_subscriptions").
+		Set("url", sub.URL).
+		Set("event_types", pq.Array(sub.EventTypes)).
+		Set("active", sub.Active).
+		Set("updated_at", sub.UpdatedAt).
+		Where(squirrel.Eq{"id": sub.ID})
+
+	return execAffectingRow(query.RunWith(r.db).Exec())
+}
+
+func (r *WebhookSubscriptionRepository) Delete(id int64) error {
+	query := r.builder.
+		Delete("webhook_subscriptions").
+		Where(squirrel.Eq{"id": id})
+
+	return execAffectingRow(query.RunWith(r.db).Exec())
+}
+
+func scanWebhookSubscription(row squirrel.RowScanner) (*domain.WebhookSubscription, error) {
+	sub := &domain.WebhookSubscription{}
+	var eventTypes pq.StringArray
+	err := row.Scan(&sub.ID, &sub.URL, &sub.Secret, &eventTypes, &sub.Active, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	sub.EventTypes = []string(eventTypes)
+	if sub.EventTypes == nil {
+		sub.EventTypes = []string{}
+	}
+	return sub, nil
+}
+
+func execAffectingRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const (
+	enqueueWebhookDeliveriesQuery = `
+INSERT INTO webhook_deliveries (subscription_id, event_id, next_attempt_at)
+SELECT id, $1::bigint, $2::timestamptz FROM webhook_subscriptions
+WHERE active AND (cardinality(event_types) = 0 OR $3::text = ANY(event_types))`
+
+	// The claimed rows stay pending; pushing next_attempt_at to the lease
+	// expiry hides them from other dispatchers, and a dispatcher that dies
+	// mid-send simply lets the lease run out.
+	claimWebhookDeliveriesQuery = `
+UPDATE webhook_deliveries d SET next_attempt_at = $2, claim_token = gen_random_uuid()
+FROM webhook_subscriptions s, user_events e
+WHERE d.id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= $1
+    ORDER BY next_attempt_at, id
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+) AND s.id = d.subscription_id AND e.id = d.event_id
+RETURNING d.id, d.subscription_id, d.event_id, d.attempts, d.created_at, d.claim_token, s.url, s.secret, e.type, e.user_id, e.payload, e.occurred_at`
+)
+
+var webhookDeliveryColumns = []string{
+	"d.id", "d.subscription_id", "d.event_id", "e.type", "d.status", "d.attempts",
+	"d.next_attempt_at", "d.last_status_code", "d.last_error", "d.delivered_at", "d.created_at",
+}
+
+type WebhookDeliveryRepository struct {
+	db      squirrel.StdSqlCtx
+	builder squirrel.StatementBuilderType
+}
+
+func NewWebhookDeliveryRepository(db *sql.DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (r *WebhookDeliveryRepository) Enqueue(event *domain.UserEvent) error {
+	_, err := r.db.Exec(enqueueWebhookDeliveriesQuery, event.ID, event.OccurredAt, event.Type)
+	return err
+}
+
+func (r *WebhookDeliveryRepository) Claim(now, leaseUntil time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.db.Query(claimWebhookDeliveriesQuery, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		d := &domain.WebhookDelivery{Status: domain.WebhookDeliveryPending, Event: &domain.UserEvent{}}
+		var payload []byte
+		err := rows.Scan(
+			&d.ID, &d.SubscriptionID, &d.EventID, &d.Attempts, &d.CreatedAt, &d.ClaimToken,
+			&d.URL, &d.Secret,
+			&d.Event.Type, &d.Event.UserID, &payload, &d.Event.OccurredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		d.Event.ID = d.EventID
+		d.EventType = d.Event.Type
+		if payload != nil {
+			d.Event.User = &domain.User{}
+			if err := json.Unmarshal(payload, d.Event.User); err != nil {
+				return nil, err
+			}
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookDeliveryRepository) Complete(id int64, claimToken string, attempt domain.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	query := r.builder.
+		Update("webhook_deliveries").
+		Set("status", status).
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("last_status_code", attempt.StatusCode).
+		Set("last_error", attempt.Error).
+		Set("claim_token", nil).
+		Where(squirrel.Eq{"id": id, "claim_token": claimToken})
+
+	switch status {
+	case domain.WebhookDeliveryPending:
+		query = query.Set("next_attempt_at", nextAttemptAt)
+	case domain.WebhookDeliverySucceeded:
+		query = query.Set("next_attempt_at", nil).Set("delivered_at", attempt.AttemptedAt)
+	default:
+		query = query.Set("next_attempt_at", nil)
+	}
+
+	return execAffectingRow(query.RunWith(r.db).Exec())
+}
+
+func (r *WebhookDeliveryRepository) List(filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	query := r.builder.
+		Select(webhookDeliveryColumns...).
+		From("webhook_deliveries d").
+		Join("user_events e ON e.id = d.event_id").
+		OrderBy("d.id DESC")
+
+	if filter.SubscriptionID != 0 {
+		query = query.Where(squirrel.Eq{"d.subscription_id": filter.SubscriptionID})
+	}
+	if filter.Status != "" {
+		query = query.Where(squirrel.Eq{"d.status": filter.Status})
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(uint64(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(uint64(filter.Offset))
+	}
+
+	rows, err := query.RunWith(r.db).Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		d := &domain.WebhookDelivery{}
+		var nextAttemptAt, deliveredAt sql.NullTime
+		var lastStatusCode sql.NullInt64
+		err := rows.Scan(
+			&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+			&nextAttemptAt, &lastStatusCode, &d.LastError, &deliveredAt, &d.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if nextAttemptAt.Valid {
+			d.NextAttemptAt = &nextAttemptAt.Time
+		}
+		if lastStatusCode.Valid {
+			code := int(lastStatusCode.Int64)
+			d.LastStatusCode = &code
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookDeliveryRepository) Requeue(id int64, at time.Time) (bool, error) {
+	query := r.builder.
+		Update("webhook_deliveries").
+		Set("status", domain.WebhookDeliveryPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", at).
+		Where(squirrel.Eq{"id": id, "status": domain.WebhookDeliveryDead})
+
+	err := execAffectingRow(query.RunWith(r.db).Exec())
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
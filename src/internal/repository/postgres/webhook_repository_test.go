@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateWebhookSubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWebhookSubscriptionRepository(db)
+	sub := &domain.WebhookSubscription{URL: "https://example.com/hook", Secret: "whsec_x", EventTypes: []string{domain.UserEventCreated}, Active: true}
+
+	mock.ExpectQuery("INSERT INTO webhook_subscriptions").
+		WithArgs(sub.URL, sub.Secret, pq.Array(sub.EventTypes), true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+	assert.NoError(t, repo.Create(sub))
+	assert.Equal(t, int64(3), sub.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueueWebhookDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWebhookDeliveryRepository(db)
+	event := &domain.UserEvent{ID: 42, Type: domain.UserEventUpdated, OccurredAt: time.Now()}
+
+	mock.ExpectExec(`INSERT INTO webhook_deliveries \(subscription_id, event_id, next_attempt_at\)\s+SELECT id`).
+		WithArgs(int64(42), event.OccurredAt, domain.UserEventUpdated).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.NoError(t, repo.Enqueue(event))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimWebhookDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWebhookDeliveryRepository(db)
+	now := time.Now()
+	lease := now.Add(time.Minute)
+
+	rows := sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "attempts", "created_at", "claim_token", "url", "secret", "type", "user_id", "payload", "occurred_at"}).
+		AddRow(1, 3, 42, 2, now, "8f14e45f-ceea-4e7a-9f6c-2d1b7a1e0c3b", "https://example.com/hook", "whsec_x", domain.UserEventUpdated, 7, []byte(`{"id":7,"name":"John Doe"}`), now)
+
+	mock.ExpectQuery(`UPDATE webhook_deliveries d SET next_attempt_at = \$2, claim_token = gen_random_uuid\(\).*FOR UPDATE SKIP LOCKED`).
+		WithArgs(now, lease, 50).
+		WillReturnRows(rows)
+
+	deliveries, err := repo.Claim(now, lease, 50)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+	assert.Equal(t, "whsec_x", deliveries[0].Secret)
+	assert.Equal(t, "8f14e45f-ceea-4e7a-9f6c-2d1b7a1e0c3b", deliveries[0].ClaimToken)
+	assert.Equal(t, int64(42), deliveries[0].Event.ID)
+	assert.Equal(t, "John Doe", deliveries[0].Event.User.Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteWebhookDelivery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWebhookDeliveryRepository(db)
+	now := time.Now()
+	statusCode := 200
+
+	mock.ExpectExec(`UPDATE webhook_deliveries SET status = \$1, attempts = attempts \+ 1, last_status_code = \$2, last_error = \$3, claim_token = \$4, next_attempt_at = \$5, delivered_at = \$6 WHERE claim_token = \$7 AND id = \$8`).
+		WithArgs(domain.WebhookDeliverySucceeded, &statusCode, "", nil, nil, now, "token", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE webhook_deliveries SET .* WHERE claim_token = \$7 AND id = \$8`).
+		WithArgs(domain.WebhookDeliverySucceeded, &statusCode, "", nil, nil, now, "stale-token", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	attempt := domain.WebhookAttempt{StatusCode: &statusCode, AttemptedAt: now}
+	assert.NoError(t, repo.Complete(1, "token", attempt, domain.WebhookDeliverySucceeded, time.Time{}))
+	// The lease ran out and the delivery was claimed again.
+	assert.Equal(t, sql.ErrNoRows, repo.Complete(1, "stale-token", attempt, domain.WebhookDeliverySucceeded, time.Time{}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRequeueWebhookDelivery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewWebhookDeliveryRepository(db)
+	now := time.Now()
+
+	mock.ExpectExec("UPDATE webhook_deliveries SET status = \\$1, attempts = \\$2, next_attempt_at = \\$3 WHERE id = \\$4 AND status = \\$5").
+		WithArgs(domain.WebhookDeliveryPending, 0, now, int64(1), domain.WebhookDeliveryDead).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	requeued, err := repo.Requeue(1, now)
+	assert.NoError(t, err)
+	assert.False(t, requeued)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
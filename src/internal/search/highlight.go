@@ -0,0 +1,81 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// FuzzyThreshold is the Similarity above which a word counts as a misspelled
+// match of a query word. It is pg_trgm's default similarity threshold.
+const FuzzyThreshold = 0.3
+
+// Highlight returns text, HTML-escaped, with the parts matching query wrapped
+// in <mark>. A word matches when it contains a query word or is similar
+// enough to one; in the first case only the contained part is marked. ok is
+// false when nothing matched.
+func Highlight(text, query string) (highlighted string, ok bool) {
+	terms := Words(query)
+	if len(terms) == 0 {
+		return html.EscapeString(text), false
+	}
+
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			j := i
+			for j < len(runes) && !isWordRune(runes[j]) {
+				j++
+			}
+			b.WriteString(html.EscapeString(string(runes[i:j])))
+			i = j
+			continue
+		}
+
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		marked, matched := highlightWord(word, terms)
+		b.WriteString(marked)
+		ok = ok || matched
+		i = j
+	}
+	return b.String(), ok
+}
+
+func highlightWord(word string, terms []string) (string, bool) {
+	lower := []rune(strings.ToLower(word))
+	original := []rune(word)
+
+	for _, term := range terms {
+		t := []rune(term)
+		if start := indexRunes(lower, t); start >= 0 && len(lower) == len(original) {
+			end := start + len(t)
+			return html.EscapeString(string(original[:start])) +
+				"<mark>" + html.EscapeString(string(original[start:end])) + "</mark>" +
+				html.EscapeString(string(original[end:])), true
+		}
+	}
+	for _, term := range terms {
+		if Similarity(term, word) >= FuzzyThreshold {
+			return "<mark>" + html.EscapeString(word) + "</mark>", true
+		}
+	}
+	return html.EscapeString(word), false
+}
+
+func indexRunes(s, sub []rune) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if string(s[i:i+len(sub)]) == string(sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,48 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, Similarity("Ivan", "ivan"))
+	assert.Equal(t, 0.0, Similarity("ivan", "xyz"))
+	assert.Equal(t, 0.0, Similarity("", "ivan"))
+	// pg_trgm: SELECT similarity('word', 'two words') = 0.36363637
+	assert.InDelta(t, 0.3636, Similarity("word", "two words"), 0.0001)
+	assert.GreaterOrEqual(t, Similarity("petrov", "petrvo"), FuzzyThreshold)
+}
+
+func TestWordSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, WordSimilarity("petrov", "Ivan Petrov"))
+	assert.Greater(t, WordSimilarity("petrvo", "Ivan Petrov"), WordSimilarity("petrvo", "Anna Smirnova"))
+	assert.Equal(t, 1.0, WordSimilarity("ivan petrov", "Mr Ivan Petrov"))
+	assert.Equal(t, 0.0, WordSimilarity("", "Ivan"))
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  string
+		ok    bool
+	}{
+		{name: "substring", text: "Ivan Petrov", query: "petr", want: "Ivan <mark>Petr</mark>ov", ok: true},
+		{name: "several words", text: "Ivan Petrov", query: "ivan petrov", want: "<mark>Ivan</mark> <mark>Petrov</mark>", ok: true},
+		{name: "email", text: "ivan.petrov@example.com", query: "example", want: "ivan.petrov@<mark>example</mark>.com", ok: true},
+		{name: "misspelled", text: "Ivan Petrov", query: "petrvo", want: "Ivan <mark>Petrov</mark>", ok: true},
+		{name: "no match", text: "Anna", query: "boris", want: "Anna", ok: false},
+		{name: "escaped", text: "<b>Ivan</b>", query: "ivan", want: "&lt;b&gt;<mark>Ivan</mark>&lt;/b&gt;", ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Highlight(tt.text, tt.query)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
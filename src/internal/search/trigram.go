@@ -0,0 +1,67 @@
+// Package search scores and highlights free-text matches against users. Its
+// trigram similarity follows the definition used by PostgreSQL's pg_trgm, so
+// that the in-memory search ranks users the way the database does.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words splits s into lower-cased runs of letters and digits.
+func Words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// trigrams returns the trigram set of s: every word is padded with two spaces
+// in front and one behind, as pg_trgm does.
+func trigrams(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, word := range Words(s) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
+
+// Similarity is the share of trigrams a and b have in common, from 0 to 1.
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+// WordSimilarity is the best Similarity between query and any run of as many
+// consecutive words of text as query has. It approximates pg_trgm's
+// word_similarity: a short query scores high against a long text containing
+// a close match.
+func WordSimilarity(query, text string) float64 {
+	n := len(Words(query))
+	words := Words(text)
+	if n == 0 || len(words) == 0 {
+		return 0
+	}
+	if n > len(words) {
+		n = len(words)
+	}
+
+	best := 0.0
+	for i := 0; i+n <= len(words); i++ {
+		if s := Similarity(query, strings.Join(words[i:i+n], " ")); s > best {
+			best = s
+		}
+	}
+	return best
+}
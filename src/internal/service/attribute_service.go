@@ -0,0 +1,284 @@
+package service
+
+import (
+	"database/sql"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+// attributeSchemaTTL bounds how long another instance's changes to the
+// definitions can go unnoticed; this instance's own changes apply at once.
+const attributeSchemaTTL = 30 * time.Second
+
+var attributeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// AttributeService manages the definitions of custom user attributes and
+// validates attribute values against them.
+type AttributeService struct {
+	repo domain.AttributeDefinitionRepository
+	now  func() time.Time
+
+	mu       sync.Mutex
+	schema   map[string]*attributeRule
+	loadedAt time.Time
+}
+
+type attributeRule struct {
+	def     *domain.AttributeDefinition
+	pattern *regexp.Regexp
+}
+
+func NewAttributeService(repo domain.AttributeDefinitionRepository) *AttributeService {
+	return &AttributeService{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+func (s *AttributeService) CreateDefinition(def *domain.AttributeDefinition) error {
+	if err := validateDefinition(def); err != nil {
+		return err
+	}
+	defer s.invalidate()
+	return s.repo.Create(def)
+}
+
+func (s *AttributeService) GetDefinition(name string) (*domain.AttributeDefinition, error) {
+	def, err := s.repo.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if def == nil {
+		return nil, errors.ErrAttributeNotFound
+	}
+	return def, nil
+}
+
+func (s *AttributeService) ListDefinitions() ([]*domain.AttributeDefinition, error) {
+	return s.repo.List()
+}
+
+// UpdateDefinition replaces everything but the name of an existing
+// definition. Values already stored are not revalidated; they are checked
+// against the new rules the next time they are written.
+func (s *AttributeService) UpdateDefinition(def *domain.AttributeDefinition) error {
+	if err := validateDefinition(def); err != nil {
+		return err
+	}
+	defer s.invalidate()
+	if err := s.repo.Update(def); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrAttributeNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteDefinition removes a definition. Users keep their values for it
+// until they are updated with a null value.
+func (s *AttributeService) DeleteDefinition(name string) error {
+	defer s.invalidate()
+	if err := s.repo.Delete(name); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrAttributeNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func validateDefinition(def *domain.AttributeDefinition) error {
+	if !attributeNamePattern.MatchString(def.Name) {
+		return errors.ErrInvalidInput
+	}
+	switch def.Type {
+	case domain.AttributeTypeString:
+		if def.Pattern != "" {
+			if _, err := regexp.Compile(def.Pattern); err != nil {
+				return errors.ErrInvalidInput
+			}
+		}
+	case domain.AttributeTypeNumber, domain.AttributeTypeInteger, domain.AttributeTypeBoolean:
+		if def.Pattern != "" || len(def.Enum) > 0 {
+			return errors.ErrInvalidInput
+		}
+	default:
+		return errors.ErrInvalidInput
+	}
+	return nil
+}
+
+func (s *AttributeService) invalidate() {
+	s.mu.Lock()
+	s.schema = nil
+	s.mu.Unlock()
+}
+
+// rules returns the compiled definitions by name, reloading them once they
+// are older than attributeSchemaTTL.
+func (s *AttributeService) rules() (map[string]*attributeRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.schema != nil && s.now().Sub(s.loadedAt) < attributeSchemaTTL {
+		return s.schema, nil
+	}
+
+	defs, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	schema := make(map[string]*attributeRule, len(defs))
+	for _, def := range defs {
+		rule := &attributeRule{def: def}
+		if def.Pattern != "" {
+			// Definitions are validated before they are stored, so this only
+			// fails for rows written behind the service's back.
+			if rule.pattern, err = regexp.Compile(def.Pattern); err != nil {
+				return nil, err
+			}
+		}
+		schema[def.Name] = rule
+	}
+	s.schema, s.loadedAt = schema, s.now()
+	return schema, nil
+}
+
+// Validate checks values against their definitions and converts them to the
+// declared types in place; string forms of numbers and booleans, as sent in
+// XML or a query string, are accepted. Nil values, which delete attributes
+// in an update, are only allowed with allowNull set.
+func (s *AttributeService) Validate(values domain.Attributes, allowNull bool) error {
+	schema, err := s.rules()
+	if err != nil {
+		return err
+	}
+
+	for name, value := range values {
+		if value == nil && allowNull {
+			continue
+		}
+		rule, ok := schema[name]
+		if !ok {
+			return &errors.AttributeError{Name: name, Reason: "is not defined"}
+		}
+		converted, err := rule.convert(value)
+		if err != nil {
+			return err
+		}
+		values[name] = converted
+	}
+	return nil
+}
+
+// CheckRequired reports the first required attribute values has no value
+// for.
+func (s *AttributeService) CheckRequired(values domain.Attributes) error {
+	schema, err := s.rules()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(schema))
+	for name, rule := range schema {
+		if rule.def.Required && values[name] == nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return &errors.AttributeError{Name: names[0], Reason: "is required"}
+	}
+	return nil
+}
+
+func (r *attributeRule) convert(value interface{}) (interface{}, error) {
+	invalid := func(reason string) error {
+		return &errors.AttributeError{Name: r.def.Name, Reason: reason}
+	}
+
+	switch r.def.Type {
+	case domain.AttributeTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, invalid("must be a string")
+		}
+		if r.pattern != nil && !r.pattern.MatchString(s) {
+			return nil, invalid("does not match " + r.def.Pattern)
+		}
+		if len(r.def.Enum) > 0 && !slices.Contains(r.def.Enum, s) {
+			return nil, invalid("is not one of the allowed values")
+		}
+		return s, nil
+
+	case domain.AttributeTypeNumber:
+		n, ok := toFloat(value)
+		if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, invalid("must be a number")
+		}
+		return n, nil
+
+	case domain.AttributeTypeInteger:
+		if v, ok := value.(string); ok {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return n, nil
+			}
+			return nil, invalid("must be an integer")
+		}
+		switch v := reflect.ValueOf(value); v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v.Uint() <= math.MaxInt64 {
+				return int64(v.Uint()), nil
+			}
+		case reflect.Float32, reflect.Float64:
+			// JSON numbers decode as float64, which holds integers exactly up
+			// to 2^53.
+			if n := v.Float(); n == math.Trunc(n) && math.Abs(n) <= 1<<53 {
+				return int64(n), nil
+			}
+		}
+		return nil, invalid("must be an integer")
+
+	case domain.AttributeTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, nil
+			}
+		}
+		return nil, invalid("must be a boolean")
+	}
+	return nil, invalid("has an unknown type " + r.def.Type)
+}
+
+// toFloat accepts the numeric types the request codecs decode to, and
+// strings.
+func toFloat(value interface{}) (float64, bool) {
+	if v, ok := value.(string); ok {
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
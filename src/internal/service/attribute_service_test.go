@@ -0,0 +1,150 @@
+package service
+
+import (
+	stdErrors "errors"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAttributeDefinitionRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttributeDefinitionRepository) Create(def *domain.AttributeDefinition) error {
+	args := m.Called(def)
+	return args.Error(0)
+}
+
+func (m *MockAttributeDefinitionRepository) Get(name string) (*domain.AttributeDefinition, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeDefinitionRepository) List() ([]*domain.AttributeDefinition, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeDefinitionRepository) Update(def *domain.AttributeDefinition) error {
+	args := m.Called(def)
+	return args.Error(0)
+}
+
+func (m *MockAttributeDefinitionRepository) Delete(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+var testAttributeDefinitions = []*domain.AttributeDefinition{
+	{Name: "department", Type: domain.AttributeTypeString, Required: true, Enum: []string{"Sales", "IT"}},
+	{Name: "phone", Type: domain.AttributeTypeString, Pattern: `^\+[0-9]{7,15}$`},
+	{Name: "level", Type: domain.AttributeTypeInteger},
+	{Name: "remote", Type: domain.AttributeTypeBoolean},
+}
+
+func attributeName(err error) string {
+	var attrErr *errors.AttributeError
+	if stdErrors.As(err, &attrErr) {
+		return attrErr.Name
+	}
+	return ""
+}
+
+func TestCreateDefinitionValidation(t *testing.T) {
+	repo := new(MockAttributeDefinitionRepository)
+	attrs := NewAttributeService(repo)
+
+	invalid := []*domain.AttributeDefinition{
+		{Name: "Department", Type: domain.AttributeTypeString},
+		{Name: "level", Type: "float"},
+		{Name: "level", Type: domain.AttributeTypeInteger, Enum: []string{"1"}},
+		{Name: "phone", Type: domain.AttributeTypeString, Pattern: "(+"},
+	}
+	for _, def := range invalid {
+		assert.Equal(t, errors.ErrInvalidInput, attrs.CreateDefinition(def), def.Name)
+	}
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+
+	repo.On("Create", mock.Anything).Return(nil)
+	assert.NoError(t, attrs.CreateDefinition(&domain.AttributeDefinition{Name: "cost_center", Type: domain.AttributeTypeString, Pattern: `^[0-9]+$`}))
+}
+
+func TestUserAttributes(t *testing.T) {
+	attrRepo := new(MockAttributeDefinitionRepository)
+	attrRepo.On("List").Return(testAttributeDefinitions, nil).Once()
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, WithAttributeSchema(NewAttributeService(attrRepo)))
+
+	tests := []struct {
+		attributes domain.Attributes
+		invalid    string
+	}{
+		{domain.Attributes{"level": 3}, "department"},
+		{domain.Attributes{"department": "HR"}, "department"},
+		{domain.Attributes{"department": "IT", "phone": "555-1234"}, "phone"},
+		{domain.Attributes{"department": "IT", "level": 2.5}, "level"},
+		{domain.Attributes{"department": "IT", "remote": "maybe"}, "remote"},
+		{domain.Attributes{"department": "IT", "shoe_size": 42}, "shoe_size"},
+	}
+	for _, tt := range tests {
+		err := service.CreateUser(&domain.User{Name: "Ivan", Email: "ivan@example.com", Attributes: tt.attributes})
+		assert.ErrorIs(t, err, errors.ErrInvalidAttribute)
+		assert.Equal(t, tt.invalid, attributeName(err))
+	}
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+
+	// Values sent as XML arrive as strings and are converted to their types.
+	mockRepo.On("Create", mock.Anything).Return(nil)
+	user := &domain.User{Name: "Ivan", Email: "ivan@example.com", Attributes: domain.Attributes{"department": "IT", "level": "3", "remote": "true"}}
+	assert.NoError(t, service.CreateUser(user))
+	assert.Equal(t, domain.Attributes{"department": "IT", "level": int64(3), "remote": true}, user.Attributes)
+
+	mockRepo.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Name: "Ivan", Email: "ivan@example.com", Attributes: domain.Attributes{"department": "IT", "level": float64(3)}}, nil)
+	mockRepo.On("Update", mock.Anything).Return(nil)
+
+	err := service.UpdateUser(&domain.User{ID: 1, Attributes: domain.Attributes{"department": nil}})
+	assert.Equal(t, "department", attributeName(err))
+
+	update := &domain.User{ID: 1, Attributes: domain.Attributes{"level": nil, "remote": false}}
+	assert.NoError(t, service.UpdateUser(update))
+	assert.Equal(t, domain.Attributes{"department": "IT", "remote": false}, update.Attributes)
+	assert.Equal(t, []int64{1, 1}, mockRepo.locked)
+
+	// The definitions were loaded once and served from the cache since.
+	attrRepo.AssertNumberOfCalls(t, "List", 1)
+}
+
+func TestUserAttributesWithoutSchema(t *testing.T) {
+	service := NewUserService(new(MockUserRepository))
+
+	err := service.CreateUser(&domain.User{Name: "Ivan", Email: "ivan@example.com", Attributes: domain.Attributes{"department": "IT"}})
+	assert.Equal(t, "department", attributeName(err))
+}
+
+func TestListUsersByAttribute(t *testing.T) {
+	attrRepo := new(MockAttributeDefinitionRepository)
+	attrRepo.On("List").Return(testAttributeDefinitions, nil)
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, WithAttributeSchema(NewAttributeService(attrRepo)))
+
+	mockRepo.On("List", mock.MatchedBy(func(filter domain.UserFilter) bool {
+		return filter.Attributes["level"] == int64(3) && filter.Attributes["remote"] == true
+	})).Return([]*domain.User{}, nil)
+
+	_, err := service.ListUsers(domain.UserFilter{Attributes: domain.Attributes{"level": "3", "remote": "1"}})
+	assert.NoError(t, err)
+
+	_, err = service.ListUsers(domain.UserFilter{Attributes: domain.Attributes{"level": "three"}})
+	assert.Equal(t, "level", attributeName(err))
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+type BatchConfig struct {
+	MaxSize       int
+	CopyThreshold int
+}
+
+var defaultBatchConfig = BatchConfig{
+	MaxSize:       10000,
+	CopyThreshold: 500,
+}
+
+// Batch applies the operations in order. In atomic mode every operation runs
+// in one transaction and the first failure rolls the whole batch back;
+// otherwise each operation is applied on its own and failures are reported
+// per item. Operations are validated before the transaction they run in is
+// opened. Large batches that only create users are inserted with one COPY;
+// when that fails, for example because an email is taken, the operations
+// are applied one by one instead, so the result names the failing item.
+func (s *UserService) Batch(ops []domain.BatchOperation, atomic bool) ([]domain.BatchResult, error) {
+	if len(ops) == 0 {
+		return nil, errors.ErrInvalidInput
+	}
+	if s.batch.MaxSize > 0 && len(ops) > s.batch.MaxSize {
+		return nil, errors.ErrBatchTooLarge
+	}
+	if atomic {
+		return s.atomicBatch(ops)
+	}
+
+	results := make([]domain.BatchResult, len(ops))
+	if s.bulkBatch(ops, results) {
+		return results, nil
+	}
+	for i, op := range ops {
+		if err := s.validateOperation(op); err != nil {
+			results[i] = failedResult(i, op, err)
+			continue
+		}
+		err := s.write(func(u *unitOfWork) error {
+			results[i] = s.applyOperation(u, i, op)
+			if results[i].Status == domain.BatchStatusFailed {
+				return errors.ErrBatchAborted
+			}
+			return nil
+		})
+		if err != nil && results[i].Status != domain.BatchStatusFailed {
+			results[i] = failedResult(i, op, err)
+		}
+	}
+	return results, nil
+}
+
+func (s *UserService) atomicBatch(ops []domain.BatchOperation) ([]domain.BatchResult, error) {
+	if s.tx == nil {
+		return nil, errors.ErrTxNotSupported
+	}
+
+	results := make([]domain.BatchResult, len(ops))
+	failed := false
+
+	for i, op := range ops {
+		if err := s.validateOperation(op); err != nil {
+			results[i] = failedResult(i, op, err)
+			rollBackResults(ops, results)
+			return results, errors.ErrBatchAborted
+		}
+	}
+
+	run := func(useBulk bool) error {
+		return s.write(func(u *unitOfWork) error {
+			if bulk, ok := u.users.(domain.UserBulkCreator); ok && useBulk {
+				return s.bulkCreate(u, bulk, ops, results, &failed)
+			}
+
+			for i, op := range ops {
+				results[i] = s.applyOperation(u, i, op)
+				if results[i].Status == domain.BatchStatusFailed {
+					failed = true
+					return errors.ErrBatchAborted
+				}
+			}
+			return nil
+		})
+	}
+
+	useBulk := s.useBulkCreate(ops)
+	err := run(useBulk)
+	if useBulk && err == errors.ErrEmailTaken {
+		// COPY cannot tell which row clashed; find it one row at a time.
+		clear(results)
+		err = run(false)
+	}
+
+	if failed || err != nil {
+		rollBackResults(ops, results)
+		if err != nil && err != errors.ErrBatchAborted {
+			return results, err
+		}
+		return results, errors.ErrBatchAborted
+	}
+
+	return results, nil
+}
+
+func (s *UserService) useBulkCreate(ops []domain.BatchOperation) bool {
+	if s.batch.CopyThreshold <= 0 || len(ops) < s.batch.CopyThreshold {
+		return false
+	}
+	for _, op := range ops {
+		if op.Op != domain.BatchOpCreate {
+			return false
+		}
+	}
+	return true
+}
+
+// bulkBatch creates the users of a large create-only batch with one COPY
+// outside atomic mode. It reports false, leaving results empty, when the
+// batch does not qualify or the COPY fails. It needs a transaction, so that
+// nothing of a failed attempt is left behind for the row-by-row retry.
+func (s *UserService) bulkBatch(ops []domain.BatchOperation, results []domain.BatchResult) bool {
+	if s.tx == nil || !s.useBulkCreate(ops) {
+		return false
+	}
+	for _, op := range ops {
+		if s.validateOperation(op) != nil {
+			return false
+		}
+	}
+
+	failed := false
+	err := s.write(func(u *unitOfWork) error {
+		bulk, ok := u.users.(domain.UserBulkCreator)
+		if !ok {
+			return errors.ErrTxNotSupported
+		}
+		return s.bulkCreate(u, bulk, ops, results, &failed)
+	})
+	if err != nil {
+		clear(results)
+		return false
+	}
+	return true
+}
+
+func (s *UserService) bulkCreate(u *unitOfWork, bulk domain.UserBulkCreator, ops []domain.BatchOperation, results []domain.BatchResult, failed *bool) error {
+	users := make([]*domain.User, len(ops))
+	for i, op := range ops {
+		users[i] = op.User
+	}
+
+	if err := bulk.BulkCreate(users); err != nil {
+		return err
+	}
+
+	for i, user := range users {
+		results[i] = domain.BatchResult{
+			Index:  i,
+			Op:     domain.BatchOpCreate,
+			Status: domain.BatchStatusCreated,
+			ID:     user.ID,
+			User:   user,
+		}
+		user := user
+		u.onCommit(func() { s.requestEmailVerification(user) })
+		if err := s.record(u, domain.UserEventCreated, user.ID, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *UserService) applyOperation(u *unitOfWork, index int, op domain.BatchOperation) domain.BatchResult {
+	switch op.Op {
+	case domain.BatchOpCreate:
+		user := op.User
+		if err := u.users.Create(user); err != nil {
+			return failedResult(index, op, err)
+		}
+		if err := s.record(u, domain.UserEventCreated, user.ID, user); err != nil {
+			return failedResult(index, op, err)
+		}
+		u.onCommit(func() { s.requestEmailVerification(user) })
+		return domain.BatchResult{
+			Index:  index,
+			Op:     op.Op,
+			Status: domain.BatchStatusCreated,
+			ID:     user.ID,
+			User:   user,
+		}
+
+	case domain.BatchOpUpdate:
+		user := op.User
+		emailChanged, err := s.update(u.users, user)
+		if err != nil {
+			return failedResult(index, op, err)
+		}
+		if err := s.record(u, domain.UserEventUpdated, user.ID, user); err != nil {
+			return failedResult(index, op, err)
+		}
+		if emailChanged {
+			u.onCommit(func() { s.requestEmailVerification(user) })
+		}
+		return domain.BatchResult{
+			Index:  index,
+			Op:     op.Op,
+			Status: domain.BatchStatusUpdated,
+			ID:     user.ID,
+			User:   user,
+		}
+
+	case domain.BatchOpDelete:
+		if op.ID == 0 {
+			return failedResult(index, op, errors.ErrInvalidInput)
+		}
+		existing, err := u.users.GetByID(op.ID)
+		if err != nil {
+			return failedResult(index, op, err)
+		}
+		if existing == nil {
+			return failedResult(index, op, errors.ErrUserNotFound)
+		}
+		if err := u.users.Delete(op.ID); err != nil {
+			return failedResult(index, op, err)
+		}
+		if err := s.record(u, domain.UserEventDeleted, op.ID, nil); err != nil {
+			return failedResult(index, op, err)
+		}
+		return domain.BatchResult{
+			Index:  index,
+			Op:     op.Op,
+			Status: domain.BatchStatusDeleted,
+			ID:     op.ID,
+		}
+
+	default:
+		return failedResult(index, op, errors.ErrInvalidBatchOp)
+	}
+}
+
+// rollBackResults marks every operation that did not fail as rolled back.
+func rollBackResults(ops []domain.BatchOperation, results []domain.BatchResult) {
+	for i := range results {
+		if results[i].Status != domain.BatchStatusFailed {
+			results[i] = domain.BatchResult{
+				Index:  i,
+				Op:     ops[i].Op,
+				Status: domain.BatchStatusRolledBack,
+			}
+		}
+	}
+}
+
+// validateOperation runs the checks of an operation that need no
+// transaction, the email validators among them.
+func (s *UserService) validateOperation(op domain.BatchOperation) error {
+	switch op.Op {
+	case domain.BatchOpCreate:
+		if op.User == nil {
+			return errors.ErrInvalidInput
+		}
+		return s.validateNewUser(op.User)
+	case domain.BatchOpUpdate:
+		if op.User == nil {
+			return errors.ErrInvalidInput
+		}
+		if op.ID != 0 {
+			op.User.ID = op.ID
+		}
+		return s.validateNewEmail(op.User)
+	}
+	return nil
+}
+
+func failedResult(index int, op domain.BatchOperation, err error) domain.BatchResult {
+	return domain.BatchResult{
+		Index:  index,
+		Op:     op.Op,
+		Status: domain.BatchStatusFailed,
+		ID:     op.ID,
+		Error:  err.Error(),
+	}
+}
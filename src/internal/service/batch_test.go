@@ -0,0 +1,227 @@
+package service
+
+import (
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type fakeTx struct {
+	users    domain.UserRepository
+	events   domain.UserEventRepository
+	webhooks domain.WebhookDeliveryRepository
+	outbox   domain.UserEventOutbox
+	tokens   domain.EmailVerificationRepository
+}
+
+func (t *fakeTx) Users() domain.UserRepository {
+	return t.users
+}
+
+func (t *fakeTx) Events() domain.UserEventRepository {
+	return t.events
+}
+
+func (t *fakeTx) Webhooks() domain.WebhookDeliveryRepository {
+	return t.webhooks
+}
+
+func (t *fakeTx) Outbox() domain.UserEventOutbox {
+	return t.outbox
+}
+
+func (t *fakeTx) EmailVerifications() domain.EmailVerificationRepository {
+	return t.tokens
+}
+
+type fakeTxManager struct {
+	tx         *fakeTx
+	opened     bool
+	active     bool
+	rolledBack bool
+}
+
+func (m *fakeTxManager) WithinTx(fn func(tx domain.Tx) error) error {
+	m.opened, m.active = true, true
+	err := fn(m.tx)
+	m.active = false
+	m.rolledBack = err != nil
+	return err
+}
+
+type bulkUserRepository struct {
+	*MockUserRepository
+	created []*domain.User
+	err     error
+}
+
+func (r *bulkUserRepository) BulkCreate(users []*domain.User) error {
+	if r.err != nil {
+		return r.err
+	}
+	for i, user := range users {
+		user.ID = int64(i + 1)
+	}
+	r.created = users
+	return nil
+}
+
+func TestBatchBestEffort(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo)
+
+	created := &domain.User{Name: "John Doe", Email: "john@example.com"}
+	mockRepo.On("Create", created).Return(nil)
+	mockRepo.On("GetByID", int64(9)).Return(nil, nil)
+
+	results, err := service.Batch([]domain.BatchOperation{
+		{Op: domain.BatchOpCreate, User: created},
+		{Op: domain.BatchOpDelete, ID: 9},
+		{Op: "rename"},
+	}, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BatchStatusCreated, results[0].Status)
+	assert.Equal(t, domain.BatchStatusFailed, results[1].Status)
+	assert.Equal(t, errors.ErrUserNotFound.Error(), results[1].Error)
+	assert.Equal(t, domain.BatchStatusFailed, results[2].Status)
+	assert.Equal(t, errors.ErrInvalidBatchOp.Error(), results[2].Error)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBatchBestEffortBulkCreate(t *testing.T) {
+	ops := func() []domain.BatchOperation {
+		return []domain.BatchOperation{
+			{Op: domain.BatchOpCreate, User: &domain.User{Name: "John Doe", Email: "john@example.com"}},
+			{Op: domain.BatchOpCreate, User: &domain.User{Name: "Jane Doe", Email: "jane@example.com"}},
+		}
+	}
+	config := WithBatchConfig(BatchConfig{MaxSize: 10, CopyThreshold: 2})
+
+	t.Run("bulk insert", func(t *testing.T) {
+		txRepo := &bulkUserRepository{MockUserRepository: new(MockUserRepository)}
+		service := NewUserService(new(MockUserRepository), WithTxManager(&fakeTxManager{tx: &fakeTx{users: txRepo}}), config)
+
+		results, err := service.Batch(ops(), false)
+
+		assert.NoError(t, err)
+		assert.Len(t, txRepo.created, 2)
+		assert.Equal(t, domain.BatchStatusCreated, results[1].Status)
+		txRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("failed bulk insert falls back to one row at a time", func(t *testing.T) {
+		txRepo := &bulkUserRepository{MockUserRepository: new(MockUserRepository), err: errors.ErrEmailTaken}
+		service := NewUserService(new(MockUserRepository), WithTxManager(&fakeTxManager{tx: &fakeTx{users: txRepo}}), config)
+
+		txRepo.On("Create", mock.MatchedBy(func(user *domain.User) bool { return user.Name == "John Doe" })).Return(nil)
+		txRepo.On("Create", mock.MatchedBy(func(user *domain.User) bool { return user.Name == "Jane Doe" })).Return(errors.ErrEmailTaken)
+
+		results, err := service.Batch(ops(), false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.BatchStatusCreated, results[0].Status)
+		assert.Equal(t, domain.BatchStatusFailed, results[1].Status)
+		assert.Equal(t, errors.ErrEmailTaken.Error(), results[1].Error)
+	})
+}
+
+func TestBatchAtomic(t *testing.T) {
+	t.Run("failure rolls back the batch", func(t *testing.T) {
+		txRepo := new(MockUserRepository)
+		tm := &fakeTxManager{tx: &fakeTx{users: txRepo}}
+		service := NewUserService(new(MockUserRepository), WithTxManager(tm))
+
+		txRepo.On("Create", mock.MatchedBy(func(user *domain.User) bool { return user.Name == "John Doe" })).Return(nil)
+		txRepo.On("Create", mock.MatchedBy(func(user *domain.User) bool { return user.Name == "Jane Doe" })).Return(errors.ErrEmailTaken)
+
+		results, err := service.Batch([]domain.BatchOperation{
+			{Op: domain.BatchOpCreate, User: &domain.User{Name: "John Doe", Email: "john@example.com"}},
+			{Op: domain.BatchOpCreate, User: &domain.User{Name: "Jane Doe", Email: "jane@example.com"}},
+			{Op: domain.BatchOpCreate, User: &domain.User{Name: "Jim Doe", Email: "jim@example.com"}},
+		}, true)
+
+		assert.Equal(t, errors.ErrBatchAborted, err)
+		assert.True(t, tm.rolledBack)
+		assert.Equal(t, domain.BatchStatusRolledBack, results[0].Status)
+		assert.Equal(t, domain.BatchStatusFailed, results[1].Status)
+		assert.Equal(t, domain.BatchStatusRolledBack, results[2].Status)
+		txRepo.AssertNumberOfCalls(t, "Create", 2)
+	})
+
+	t.Run("invalid operation fails before the transaction", func(t *testing.T) {
+		txRepo := new(MockUserRepository)
+		tm := &fakeTxManager{tx: &fakeTx{users: txRepo}}
+		service := NewUserService(new(MockUserRepository), WithTxManager(tm))
+
+		results, err := service.Batch([]domain.BatchOperation{
+			{Op: domain.BatchOpCreate, User: &domain.User{Name: "John Doe", Email: "john@example.com"}},
+			{Op: domain.BatchOpCreate, User: &domain.User{Name: "Jane Doe", Email: "not-an-email"}},
+		}, true)
+
+		assert.Equal(t, errors.ErrBatchAborted, err)
+		assert.False(t, tm.opened)
+		assert.Equal(t, domain.BatchStatusRolledBack, results[0].Status)
+		assert.Equal(t, domain.BatchStatusFailed, results[1].Status)
+		txRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("large create-only batch uses bulk insert", func(t *testing.T) {
+		txRepo := &bulkUserRepository{MockUserRepository: new(MockUserRepository)}
+		tm := &fakeTxManager{tx: &fakeTx{users: txRepo}}
+		service := NewUserService(new(MockUserRepository), WithTxManager(tm), WithBatchConfig(BatchConfig{
+			MaxSize:       10,
+			CopyThreshold: 2,
+		}))
+
+		results, err := service.Batch([]domain.BatchOperation{
+			{Op: domain.BatchOpCreate, User: &domain.User{Name: "John Doe", Email: "john@example.com"}},
+			{Op: domain.BatchOpCreate, User: &domain.User{Name: "Jane Doe", Email: "jane@example.com"}},
+		}, true)
+
+		assert.NoError(t, err)
+		assert.Len(t, txRepo.created, 2)
+		assert.Equal(t, int64(2), results[1].ID)
+		assert.Equal(t, domain.BatchStatusCreated, results[1].Status)
+	})
+
+	t.Run("taken email in bulk insert is reported per item", func(t *testing.T) {
+		txRepo := &bulkUserRepository{MockUserRepository: new(MockUserRepository), err: errors.ErrEmailTaken}
+		tm := &fakeTxManager{tx: &fakeTx{users: txRepo}}
+		service := NewUserService(new(MockUserRepository), WithTxManager(tm), WithBatchConfig(BatchConfig{
+			MaxSize:       10,
+			CopyThreshold: 2,
+		}))
+
+		txRepo.On("Create", mock.MatchedBy(func(user *domain.User) bool { return user.Name == "John Doe" })).Return(nil)
+		txRepo.On("Create", mock.MatchedBy(func(user *domain.User) bool { return user.Name == "Jane Doe" })).Return(errors.ErrEmailTaken)
+
+		results, err := service.Batch([]domain.BatchOperation{
+			{Op: domain.BatchOpCreate, User: &domain.User{Name: "John Doe", Email: "john@example.com"}},
+			{Op: domain.BatchOpCreate, User: &domain.User{Name: "Jane Doe", Email: "jane@example.com"}},
+		}, true)
+
+		assert.Equal(t, errors.ErrBatchAborted, err)
+		assert.True(t, tm.rolledBack)
+		assert.Equal(t, domain.BatchStatusRolledBack, results[0].Status)
+		assert.Equal(t, domain.BatchStatusFailed, results[1].Status)
+		assert.Equal(t, errors.ErrEmailTaken.Error(), results[1].Error)
+	})
+
+	t.Run("without transaction support", func(t *testing.T) {
+		service := NewUserService(new(MockUserRepository))
+
+		_, err := service.Batch([]domain.BatchOperation{{Op: domain.BatchOpDelete, ID: 1}}, true)
+		assert.Equal(t, errors.ErrTxNotSupported, err)
+	})
+}
+
+func TestBatchTooLarge(t *testing.T) {
+	service := NewUserService(new(MockUserRepository), WithBatchConfig(BatchConfig{MaxSize: 1}))
+
+	_, err := service.Batch(make([]domain.BatchOperation, 2), false)
+	assert.Equal(t, errors.ErrBatchTooLarge, err)
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+	"users-api/src/internal/mailer"
+	"users-api/src/internal/token"
+)
+
+type emailVerification struct {
+	tokens domain.EmailVerificationRepository
+	mailer mailer.Mailer
+	cfg    EmailVerificationConfig
+}
+
+func (s *UserService) requestEmailVerification(user *domain.User) {
+	if s.verification == nil {
+		return
+	}
+	if err := s.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+}
+
+func (s *UserService) sendVerificationEmail(user *domain.User) error {
+	plain, hash, err := token.Generate()
+	if err != nil {
+		return err
+	}
+
+	t := &domain.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: hash,
+		ExpiresAt: s.now().Add(s.verification.cfg.TTL),
+	}
+	if err := s.verification.tokens.Create(t); err != nil {
+		return fmt.Errorf("error storing verification token: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/users/verify-email?token=%s", s.verification.cfg.BaseURL, url.QueryEscape(plain))
+	return s.verification.mailer.Send(mailer.Message{
+		From:    s.verification.cfg.From,
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf(
+			"Hello, %s!\n\nConfirm your email address by following the link below:\n\n%s\n\nVerification token: %s\n\nThe token expires in %s.",
+			user.Name,
+			link,
+			plain,
+			s.verification.cfg.TTL,
+		),
+	})
+}
+
+func (s *UserService) VerifyEmail(plain string) (*domain.User, error) {
+	if plain == "" || s.verification == nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	t, err := s.verification.tokens.GetByHash(token.Hash(plain))
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.UsedAt != nil {
+		return nil, errors.ErrInvalidToken
+	}
+	if !s.now().Before(t.ExpiresAt) {
+		return nil, errors.ErrTokenExpired
+	}
+
+	// The user is locked while the token is checked against the address and
+	// used up, so a concurrent change is neither lost nor verified with an
+	// address the user no longer has. A failed update leaves the token valid
+	// for another attempt.
+	var user *domain.User
+	err = s.write(func(u *unitOfWork) error {
+		var err error
+		user, err = u.users.GetByIDForUpdate(t.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.ErrUserNotFound
+		}
+		if user.Email != t.Email {
+			return errors.ErrInvalidToken
+		}
+
+		used, err := u.tokens.MarkUsed(t.ID)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return errors.ErrInvalidToken
+		}
+
+		// The canonical key is not read back, and Update writes it.
+		if err := s.normalizeEmail(user); err != nil {
+			return err
+		}
+		verifiedAt := s.now().Format(time.RFC3339)
+		user.EmailVerifiedAt = &verifiedAt
+		if user.Status == domain.UserStatusPending {
+			s.setStatus(user, domain.UserStatusActive, "email verified")
+		}
+		if err := u.users.Update(user); err != nil {
+			return err
+		}
+		return s.record(u, domain.UserEventUpdated, user.ID, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// EmailVerifier verifies tokens of every tenant. Links in verification
+// emails carry no API key, so the token decides whose users it is checked
+// against. services returns the tenant's UserService along with a release
+// func that is called once the token has been checked.
+type EmailVerifier struct {
+	tokens   domain.EmailVerificationRepository
+	services func(tenantID int64) (*UserService, func(), error)
+}
+
+func NewEmailVerifier(tokens domain.EmailVerificationRepository, services func(tenantID int64) (*UserService, func(), error)) *EmailVerifier {
+	return &EmailVerifier{tokens: tokens, services: services}
+}
+
+func (v *EmailVerifier) VerifyEmail(plain string) (*domain.User, error) {
+	if plain == "" {
+		return nil, errors.ErrInvalidToken
+	}
+
+	t, err := v.tokens.GetByHash(token.Hash(plain))
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	s, release, err := v.services(t.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.VerifyEmail(plain)
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+	"users-api/src/internal/mailer"
+	"users-api/src/internal/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEmailVerificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationRepository) Create(t *domain.EmailVerificationToken) error {
+	args := m.Called(t)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationRepository) GetByHash(hash string) (*domain.EmailVerificationToken, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.EmailVerificationToken), args.Error(1)
+}
+
+func (m *MockEmailVerificationRepository) MarkUsed(id int64) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+type recordingMailer struct {
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func newVerifyingService(repo *MockUserRepository, tokens *MockEmailVerificationRepository, m *recordingMailer, now time.Time) *UserService {
+	s := NewUserService(repo, WithEmailVerification(tokens, m, EmailVerificationConfig{
+		BaseURL: "http://localhost:8080",
+		TTL:     time.Hour,
+	}))
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestCreateUserSendsVerificationEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	tokens := new(MockEmailVerificationRepository)
+	m := &recordingMailer{}
+	now := time.Date(2025, 3, 21, 13, 45, 30, 0, time.UTC)
+	service := newVerifyingService(mockRepo, tokens, m, now)
+
+	user := &domain.User{
+		Name:  "John Doe",
+		Email: "john@example.com",
+	}
+
+	mockRepo.On("Create", user).Return(nil)
+	tokens.On("Create", mock.MatchedBy(func(t *domain.EmailVerificationToken) bool {
+		return t.Email == "john@example.com" && t.ExpiresAt.Equal(now.Add(time.Hour)) && len(t.TokenHash) == 64
+	})).Return(nil)
+
+	err := service.CreateUser(user)
+	assert.NoError(t, err)
+	assert.Len(t, m.sent, 1)
+	assert.Equal(t, "john@example.com", m.sent[0].To)
+	mockRepo.AssertExpectations(t)
+	tokens.AssertExpectations(t)
+}
+
+func TestUpdateUserEmailResetsVerification(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	tokens := new(MockEmailVerificationRepository)
+	m := &recordingMailer{}
+	service := newVerifyingService(mockRepo, tokens, m, time.Now())
+
+	verifiedAt := "2025-03-21T13:45:30Z"
+	mockRepo.On("GetByID", int64(1)).Return(&domain.User{
+		ID:              1,
+		Name:            "John Doe",
+		Email:           "john@example.com",
+		EmailVerifiedAt: &verifiedAt,
+	}, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+	tokens.On("Create", mock.AnythingOfType("*domain.EmailVerificationToken")).Return(nil)
+
+	user := &domain.User{ID: 1, Email: "john.new@example.com"}
+	err := service.UpdateUser(user)
+	assert.NoError(t, err)
+	assert.Nil(t, user.EmailVerifiedAt)
+	assert.Len(t, m.sent, 1)
+	assert.Equal(t, "john.new@example.com", m.sent[0].To)
+	assert.Contains(t, m.sent[0].Body, "http://localhost:8080/users/verify-email?token=")
+}
+
+func TestVerifyEmail(t *testing.T) {
+	now := time.Date(2025, 3, 21, 13, 45, 30, 0, time.UTC)
+
+	t.Run("valid token", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokens := new(MockEmailVerificationRepository)
+		service := newVerifyingService(mockRepo, tokens, &recordingMailer{}, now)
+
+		tokens.On("GetByHash", token.Hash("plain")).Return(&domain.EmailVerificationToken{
+			ID:        7,
+			UserID:    1,
+			Email:     "john@example.com",
+			ExpiresAt: now.Add(time.Minute),
+		}, nil)
+		tokens.On("MarkUsed", int64(7)).Return(true, nil)
+		mockRepo.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Name: "John Doe", Email: "john@example.com", Status: domain.UserStatusPending}, nil)
+		mockRepo.On("Update", mock.MatchedBy(func(user *domain.User) bool {
+			return user.EmailCanonical == "john@example.com"
+		})).Return(nil)
+
+		user, err := service.VerifyEmail("plain")
+		assert.NoError(t, err)
+		assert.NotNil(t, user.EmailVerifiedAt)
+		assert.Equal(t, "2025-03-21T13:45:30Z", *user.EmailVerifiedAt)
+		assert.Equal(t, domain.UserStatusActive, user.Status)
+		tokens.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		tokens := new(MockEmailVerificationRepository)
+		service := newVerifyingService(new(MockUserRepository), tokens, &recordingMailer{}, now)
+
+		tokens.On("GetByHash", token.Hash("unknown")).Return(nil, nil)
+
+		_, err := service.VerifyEmail("unknown")
+		assert.Equal(t, errors.ErrInvalidToken, err)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		tokens := new(MockEmailVerificationRepository)
+		service := newVerifyingService(new(MockUserRepository), tokens, &recordingMailer{}, now)
+
+		tokens.On("GetByHash", token.Hash("expired")).Return(&domain.EmailVerificationToken{
+			ID:        7,
+			UserID:    1,
+			Email:     "john@example.com",
+			ExpiresAt: now.Add(-time.Minute),
+		}, nil)
+
+		_, err := service.VerifyEmail("expired")
+		assert.Equal(t, errors.ErrTokenExpired, err)
+	})
+
+	t.Run("token issued for previous email", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokens := new(MockEmailVerificationRepository)
+		service := newVerifyingService(mockRepo, tokens, &recordingMailer{}, now)
+
+		tokens.On("GetByHash", token.Hash("stale")).Return(&domain.EmailVerificationToken{
+			ID:        7,
+			UserID:    1,
+			Email:     "john@example.com",
+			ExpiresAt: now.Add(time.Minute),
+		}, nil)
+		mockRepo.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Email: "john.new@example.com"}, nil)
+
+		_, err := service.VerifyEmail("stale")
+		assert.Equal(t, errors.ErrInvalidToken, err)
+		tokens.AssertNotCalled(t, "MarkUsed", mock.Anything)
+	})
+
+	t.Run("token already used", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokens := new(MockEmailVerificationRepository)
+		service := newVerifyingService(mockRepo, tokens, &recordingMailer{}, now)
+
+		tokens.On("GetByHash", token.Hash("reused")).Return(&domain.EmailVerificationToken{
+			ID:        8,
+			UserID:    1,
+			Email:     "john@example.com",
+			ExpiresAt: now.Add(time.Minute),
+		}, nil)
+		tokens.On("MarkUsed", int64(8)).Return(false, nil)
+		mockRepo.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Email: "john@example.com"}, nil)
+
+		_, err := service.VerifyEmail("reused")
+		assert.Equal(t, errors.ErrInvalidToken, err)
+	})
+
+	t.Run("failed update keeps the token", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokens := new(MockEmailVerificationRepository)
+		txUsers := new(MockUserRepository)
+		txTokens := new(MockEmailVerificationRepository)
+		txManager := &fakeTxManager{tx: &fakeTx{users: txUsers, tokens: txTokens}}
+		service := NewUserService(mockRepo, WithTxManager(txManager), WithEmailVerification(tokens, &recordingMailer{}, EmailVerificationConfig{TTL: time.Hour}))
+		service.now = func() time.Time { return now }
+
+		tokens.On("GetByHash", token.Hash("plain")).Return(&domain.EmailVerificationToken{
+			ID:        7,
+			UserID:    1,
+			Email:     "john@example.com",
+			ExpiresAt: now.Add(time.Minute),
+		}, nil)
+		txUsers.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Email: "john@example.com"}, nil)
+		txTokens.On("MarkUsed", int64(7)).Return(true, nil)
+		txUsers.On("Update", mock.AnythingOfType("*domain.User")).Return(assert.AnError)
+
+		_, err := service.VerifyEmail("plain")
+		assert.Equal(t, assert.AnError, err)
+		assert.True(t, txManager.rolledBack)
+		tokens.AssertNotCalled(t, "MarkUsed", mock.Anything)
+	})
+}
+
+func TestEmailVerifier(t *testing.T) {
+	now := time.Date(2025, 3, 21, 13, 45, 30, 0, time.UTC)
+	issued := &domain.EmailVerificationToken{
+		ID:        7,
+		UserID:    1,
+		TenantID:  3,
+		Email:     "john@example.com",
+		ExpiresAt: now.Add(time.Minute),
+	}
+
+	// The token was issued to a user of tenant 3; the default tenant's
+	// service must not be asked.
+	tenantUsers := new(MockUserRepository)
+	tokens := new(MockEmailVerificationRepository)
+	tenantService := newVerifyingService(tenantUsers, tokens, &recordingMailer{}, now)
+
+	tokens.On("GetByHash", token.Hash("plain")).Return(issued, nil)
+	tokens.On("MarkUsed", int64(7)).Return(true, nil)
+	tenantUsers.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Email: "john@example.com"}, nil)
+	tenantUsers.On("Update", mock.Anything).Return(nil)
+
+	var asked []int64
+	released := 0
+	verifier := NewEmailVerifier(tokens, func(tenantID int64) (*UserService, func(), error) {
+		asked = append(asked, tenantID)
+		return tenantService, func() { released++ }, nil
+	})
+
+	user, err := verifier.VerifyEmail("plain")
+	assert.NoError(t, err)
+	assert.NotNil(t, user.EmailVerifiedAt)
+	assert.Equal(t, []int64{3}, asked)
+	assert.Equal(t, 1, released)
+
+	tokens.On("GetByHash", token.Hash("unknown")).Return(nil, nil)
+	_, err = verifier.VerifyEmail("unknown")
+	assert.Equal(t, errors.ErrInvalidToken, err)
+	assert.Equal(t, []int64{3}, asked)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"sync"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+const (
+	eventBufferSize   = 64
+	maxEventsPageSize = 1000
+)
+
+type eventBroker struct {
+	log domain.UserEventRepository
+
+	mu   sync.Mutex
+	subs map[chan domain.UserEvent]struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subs: make(map[chan domain.UserEvent]struct{})}
+}
+
+// Subscribe returns a channel that receives every user change committed after
+// the call. A subscriber that falls eventBufferSize events behind is dropped
+// and its channel closed, so consumers must be ready to resubscribe. cancel
+// must be called once the subscriber is done.
+func (s *UserService) Subscribe() (events <-chan domain.UserEvent, cancel func()) {
+	ch := make(chan domain.UserEvent, eventBufferSize)
+
+	s.events.mu.Lock()
+	s.events.subs[ch] = struct{}{}
+	s.events.mu.Unlock()
+
+	return ch, func() {
+		s.events.mu.Lock()
+		defer s.events.mu.Unlock()
+		if _, ok := s.events.subs[ch]; ok {
+			delete(s.events.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// broadcast hands a committed event to every live subscriber.
+func (s *UserService) broadcast(event domain.UserEvent) {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+	for ch := range s.events.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(s.events.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// EventsSince returns up to limit persisted events after afterID, optionally
+// restricted to one user.
+func (s *UserService) EventsSince(afterID, userID int64, limit int) ([]*domain.UserEvent, error) {
+	if s.events.log == nil {
+		return nil, errors.ErrEventLogDisabled
+	}
+	if afterID < 0 || limit <= 0 {
+		return nil, errors.ErrInvalidInput
+	}
+	if limit > maxEventsPageSize {
+		limit = maxEventsPageSize
+	}
+	return s.events.log.ListAfter(afterID, userID, limit)
+}
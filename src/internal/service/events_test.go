@@ -0,0 +1,194 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserEvents(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo)
+
+	events, cancel := service.Subscribe()
+	defer cancel()
+
+	t.Run("published after create", func(t *testing.T) {
+		user := &domain.User{Name: "John Doe", Email: "john@example.com"}
+		mockRepo.On("Create", user).Run(func(args mock.Arguments) {
+			args.Get(0).(*domain.User).ID = 1
+		}).Return(nil).Once()
+
+		assert.NoError(t, service.CreateUser(user))
+
+		event := <-events
+		assert.Equal(t, domain.UserEventCreated, event.Type)
+		assert.Equal(t, int64(1), event.UserID)
+		assert.Equal(t, "john@example.com", event.User.Email)
+	})
+
+	t.Run("not published on failure", func(t *testing.T) {
+		mockRepo.On("Delete", int64(2)).Return(assert.AnError).Once()
+
+		assert.Error(t, service.DeleteUser(2))
+		assert.Empty(t, events)
+	})
+
+	t.Run("slow subscriber is dropped", func(t *testing.T) {
+		slow, cancelSlow := service.Subscribe()
+		defer cancelSlow()
+
+		mockRepo.On("Delete", int64(3)).Return(nil)
+		for i := 0; i <= eventBufferSize; i++ {
+			assert.NoError(t, service.DeleteUser(3))
+			<-events
+		}
+
+		received := 0
+		for range slow {
+			received++
+		}
+		assert.Equal(t, eventBufferSize, received)
+	})
+}
+
+type MockUserEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserEventRepository) Append(event *domain.UserEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func (m *MockUserEventRepository) ListAfter(afterID, userID int64, limit int) ([]*domain.UserEvent, error) {
+	args := m.Called(afterID, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UserEvent), args.Error(1)
+}
+
+func TestUserEventLog(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockLog := new(MockUserEventRepository)
+	service := NewUserService(mockRepo, WithEventLog(mockLog))
+
+	t.Run("persisted before broadcast", func(t *testing.T) {
+		events, cancel := service.Subscribe()
+		defer cancel()
+
+		mockRepo.On("Delete", int64(5)).Return(nil).Once()
+		mockLog.On("Append", mock.MatchedBy(func(e *domain.UserEvent) bool {
+			return e.Type == domain.UserEventDeleted && e.UserID == 5
+		})).Run(func(args mock.Arguments) {
+			args.Get(0).(*domain.UserEvent).ID = 100
+		}).Return(nil).Once()
+
+		assert.NoError(t, service.DeleteUser(5))
+		assert.Equal(t, int64(100), (<-events).ID)
+		mockLog.AssertExpectations(t)
+	})
+
+	t.Run("broadcast even if the log fails", func(t *testing.T) {
+		events, cancel := service.Subscribe()
+		defer cancel()
+
+		mockRepo.On("Delete", int64(6)).Return(nil).Once()
+		mockLog.On("Append", mock.Anything).Return(assert.AnError).Once()
+
+		assert.NoError(t, service.DeleteUser(6))
+		event := <-events
+		assert.Equal(t, int64(6), event.UserID)
+		assert.Zero(t, event.ID)
+	})
+
+	t.Run("events since caps the page size", func(t *testing.T) {
+		mockLog.On("ListAfter", int64(10), int64(0), maxEventsPageSize).Return([]*domain.UserEvent{}, nil).Once()
+
+		_, err := service.EventsSince(10, 0, 5000)
+		assert.NoError(t, err)
+		mockLog.AssertExpectations(t)
+	})
+
+	t.Run("events since without a log", func(t *testing.T) {
+		_, err := NewUserService(mockRepo).EventsSince(10, 0, 10)
+		assert.Equal(t, errors.ErrEventLogDisabled, err)
+	})
+}
+
+func TestUserEventOutbox(t *testing.T) {
+	t.Run("recorded in the write transaction", func(t *testing.T) {
+		txRepo := new(MockUserRepository)
+		txLog := new(MockUserEventRepository)
+		txQueue := new(MockWebhookDeliveryRepository)
+		tm := &fakeTxManager{tx: &fakeTx{users: txRepo, events: txLog, webhooks: txQueue}}
+		service := NewUserService(new(MockUserRepository), WithTxManager(tm), WithEventLog(new(MockUserEventRepository)), WithWebhookQueue(new(MockWebhookDeliveryRepository)))
+
+		events, cancel := service.Subscribe()
+		defer cancel()
+
+		txRepo.On("Delete", int64(5)).Return(nil)
+		txLog.On("Append", mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(0).(*domain.UserEvent).ID = 100
+		}).Return(nil)
+		txQueue.On("Enqueue", mock.MatchedBy(func(e *domain.UserEvent) bool { return e.ID == 100 })).Return(nil)
+
+		assert.NoError(t, service.DeleteUser(5))
+		assert.False(t, tm.rolledBack)
+		assert.Equal(t, int64(100), (<-events).ID)
+		txQueue.AssertExpectations(t)
+	})
+
+	t.Run("failure to queue rolls the write back", func(t *testing.T) {
+		txRepo := new(MockUserRepository)
+		txLog := new(MockUserEventRepository)
+		txQueue := new(MockWebhookDeliveryRepository)
+		tm := &fakeTxManager{tx: &fakeTx{users: txRepo, events: txLog, webhooks: txQueue}}
+		service := NewUserService(new(MockUserRepository), WithTxManager(tm), WithEventLog(new(MockUserEventRepository)), WithWebhookQueue(new(MockWebhookDeliveryRepository)))
+
+		events, cancel := service.Subscribe()
+		defer cancel()
+
+		txRepo.On("Delete", int64(5)).Return(nil)
+		txLog.On("Append", mock.Anything).Return(nil)
+		txQueue.On("Enqueue", mock.Anything).Return(assert.AnError)
+
+		assert.Equal(t, assert.AnError, service.DeleteUser(5))
+		assert.True(t, tm.rolledBack)
+		assert.Empty(t, events)
+	})
+}
+
+type fakeOutbox struct {
+	added []int64
+}
+
+func (o *fakeOutbox) Add(event *domain.UserEvent) error {
+	o.added = append(o.added, event.ID)
+	return nil
+}
+
+func (o *fakeOutbox) Relay(limit int, retryAfter time.Duration, publish func(events []*domain.UserEvent) []int64) (int, error) {
+	return 0, nil
+}
+
+func TestUserEventStagedInOutbox(t *testing.T) {
+	txRepo := new(MockUserRepository)
+	txLog := new(MockUserEventRepository)
+	txOutbox := &fakeOutbox{}
+	tm := &fakeTxManager{tx: &fakeTx{users: txRepo, events: txLog, outbox: txOutbox}}
+	service := NewUserService(new(MockUserRepository), WithTxManager(tm), WithEventLog(new(MockUserEventRepository)), WithOutbox(&fakeOutbox{}))
+
+	txRepo.On("Delete", int64(5)).Return(nil)
+	txLog.On("Append", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*domain.UserEvent).ID = 100
+	}).Return(nil)
+
+	assert.NoError(t, service.DeleteUser(5))
+	assert.Equal(t, []int64{100}, txOutbox.added)
+}
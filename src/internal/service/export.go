@@ -0,0 +1,98 @@
+package service
+
+import (
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 1000
+	exportPageSize   = 500
+)
+
+func (s *UserService) ListUsers(filter domain.UserFilter) ([]*domain.User, error) {
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, errors.ErrInvalidInput
+	}
+	if err := s.validateFilter(filter); err != nil {
+		return nil, err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+	return s.repo.List(filter)
+}
+
+// ExportUsers calls fn for every user matching filter, ignoring its limit and
+// offset. Repositories that implement domain.UserStreamer are streamed from;
+// the rest are paged through with List.
+func (s *UserService) ExportUsers(filter domain.UserFilter, fn func(user *domain.User) error) error {
+	if err := s.validateFilter(filter); err != nil {
+		return err
+	}
+	filter.Limit, filter.Offset = 0, 0
+
+	if streamer, ok := s.repo.(domain.UserStreamer); ok {
+		return streamer.Stream(filter, fn)
+	}
+
+	filter.Limit = exportPageSize
+	for {
+		users, err := s.repo.List(filter)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err := fn(user); err != nil {
+				return err
+			}
+		}
+		if len(users) < exportPageSize {
+			return nil
+		}
+		filter.Offset += len(users)
+	}
+}
+
+// CountUsers returns how many users match filter, ignoring its limit and
+// offset. Repositories that do not implement domain.UserCounter are counted
+// by walking the matching users.
+func (s *UserService) CountUsers(filter domain.UserFilter) (int, error) {
+	if err := s.validateFilter(filter); err != nil {
+		return 0, err
+	}
+	filter.Limit, filter.Offset = 0, 0
+
+	if counter, ok := s.repo.(domain.UserCounter); ok {
+		return counter.Count(filter)
+	}
+
+	count := 0
+	err := s.ExportUsers(filter, func(*domain.User) error {
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// validateFilter also converts the attribute values of filter, which arrive
+// as strings from a query string, to the declared types so that they compare
+// equal to the stored ones.
+func (s *UserService) validateFilter(filter domain.UserFilter) error {
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return errors.ErrInvalidInput
+	}
+	if filter.Status != "" && !validStatus(filter.Status) {
+		return errors.ErrInvalidInput
+	}
+	for _, status := range filter.Statuses {
+		if !validStatus(status) {
+			return errors.ErrInvalidInput
+		}
+	}
+	return s.validateAttributes(filter.Attributes, false)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListUsers(t *testing.T) {
+	t.Run("default limit", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo)
+
+		users := []*domain.User{{ID: 1}}
+		mockRepo.On("List", domain.UserFilter{Limit: defaultListLimit}).Return(users, nil)
+
+		got, err := service.ListUsers(domain.UserFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, users, got)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("limit is capped", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo)
+
+		mockRepo.On("List", domain.UserFilter{Limit: maxListLimit, Offset: 10}).Return([]*domain.User{}, nil)
+
+		_, err := service.ListUsers(domain.UserFilter{Limit: 5000, Offset: 10})
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("empty date range", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo)
+
+		at := time.Now()
+		_, err := service.ListUsers(domain.UserFilter{CreatedAfter: &at, CreatedBefore: &at})
+		assert.Equal(t, errors.ErrInvalidInput, err)
+		mockRepo.AssertNotCalled(t, "List")
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo)
+
+		_, err := service.ListUsers(domain.UserFilter{Status: "banned"})
+		assert.Equal(t, errors.ErrInvalidInput, err)
+		mockRepo.AssertNotCalled(t, "List")
+	})
+}
+
+func TestCountUsersWithoutCounter(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo)
+
+	filter := domain.UserFilter{Status: domain.UserStatusDeactivated, Limit: exportPageSize}
+	mockRepo.On("List", filter).Return([]*domain.User{{ID: 1}, {ID: 2}}, nil)
+
+	count, err := service.CountUsers(domain.UserFilter{Status: domain.UserStatusDeactivated, Limit: 1, Offset: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestExportUsersPagesWithoutStreamer(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo)
+
+	page := make([]*domain.User, exportPageSize)
+	for i := range page {
+		page[i] = &domain.User{ID: int64(i + 1)}
+	}
+	mockRepo.On("List", domain.UserFilter{NameContains: "a", Limit: exportPageSize}).Return(page, nil)
+	mockRepo.On("List", domain.UserFilter{NameContains: "a", Limit: exportPageSize, Offset: exportPageSize}).
+		Return([]*domain.User{{ID: exportPageSize + 1}}, nil)
+
+	count := 0
+	err := service.ExportUsers(domain.UserFilter{NameContains: "a", Limit: 10, Offset: 3}, func(user *domain.User) error {
+		count++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, exportPageSize+1, count)
+	mockRepo.AssertExpectations(t)
+}
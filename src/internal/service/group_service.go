@@ -0,0 +1,216 @@
+package service
+
+import (
+	"database/sql"
+	"strings"
+	"unicode/utf8"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+const (
+	maxGroupNameLength        = 255
+	maxGroupDescriptionLength = 1000
+
+	defaultGroupsPageSize = 50
+	maxGroupsPageSize     = 1000
+)
+
+// GroupService manages the groups of a tenant and who belongs to them. Its
+// user repository must be scoped to the same tenant as the groups.
+type GroupService struct {
+	groups domain.GroupRepository
+	users  domain.UserRepository
+}
+
+func NewGroupService(groups domain.GroupRepository, users domain.UserRepository) *GroupService {
+	return &GroupService{groups: groups, users: users}
+}
+
+func (s *GroupService) CreateGroup(group *domain.Group) error {
+	if err := s.validateGroup(group); err != nil {
+		return err
+	}
+	return s.groups.Create(group)
+}
+
+func (s *GroupService) GetGroup(id int64) (*domain.Group, error) {
+	group, err := s.groups.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, errors.ErrGroupNotFound
+	}
+	return group, nil
+}
+
+func (s *GroupService) ListGroups(filter domain.GroupFilter) ([]*domain.Group, error) {
+	return s.groups.List(groupPage(filter))
+}
+
+// UpdateGroup replaces the name, description and parent of an existing
+// group. Moving a group below itself fails with ErrGroupCycle.
+func (s *GroupService) UpdateGroup(group *domain.Group) error {
+	if group.ID == 0 {
+		return errors.ErrInvalidInput
+	}
+	if err := s.validateGroup(group); err != nil {
+		return err
+	}
+	if group.ParentID != nil && *group.ParentID == group.ID {
+		return errors.ErrGroupCycle
+	}
+
+	current, err := s.GetGroup(group.ID)
+	if err != nil {
+		return err
+	}
+
+	current.Name = group.Name
+	current.Description = group.Description
+	current.ParentID = group.ParentID
+	if err := s.groups.Update(current); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrGroupNotFound
+		}
+		return err
+	}
+
+	*group = *current
+	return nil
+}
+
+// DeleteGroup removes a group and its memberships. Groups nested in it must
+// be moved or deleted first.
+func (s *GroupService) DeleteGroup(id int64) error {
+	if err := s.groups.Delete(id); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrGroupNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// GetMember reports whether a user belongs to a group, directly or through
+// one of its subgroups.
+func (s *GroupService) GetMember(groupID, userID int64) (*domain.GroupMember, error) {
+	if _, err := s.GetGroup(groupID); err != nil {
+		return nil, err
+	}
+
+	member, err := s.groups.GetMember(groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil {
+		return member, nil
+	}
+
+	groups, err := s.groups.ListUserGroups(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if group.ID == groupID {
+			return &domain.GroupMember{GroupID: groupID, UserID: userID}, nil
+		}
+	}
+	return nil, errors.ErrMemberNotFound
+}
+
+// AddMember makes a user a direct member of a group. Adding an existing
+// member is not an error; added is false then.
+func (s *GroupService) AddMember(groupID, userID int64) (member *domain.GroupMember, added bool, err error) {
+	if _, err := s.GetGroup(groupID); err != nil {
+		return nil, false, err
+	}
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if user == nil {
+		return nil, false, errors.ErrUserNotFound
+	}
+
+	member = &domain.GroupMember{GroupID: groupID, UserID: userID}
+	added, err = s.groups.AddMember(member)
+	if err == sql.ErrNoRows {
+		// The group was deleted in the meantime.
+		return nil, false, errors.ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return member, added, nil
+}
+
+// RemoveMember ends a direct membership. Memberships through a subgroup end
+// by removing the user from that subgroup.
+func (s *GroupService) RemoveMember(groupID, userID int64) error {
+	if _, err := s.GetGroup(groupID); err != nil {
+		return err
+	}
+	if err := s.groups.RemoveMember(groupID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrMemberNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListMembers returns a page of the direct members of a group.
+func (s *GroupService) ListMembers(groupID int64, filter domain.GroupFilter) ([]*domain.User, error) {
+	if _, err := s.GetGroup(groupID); err != nil {
+		return nil, err
+	}
+	return s.groups.ListMembers(groupID, groupPage(filter))
+}
+
+// ListUserGroups returns every group a user belongs to, including the groups
+// above the ones they were added to.
+func (s *GroupService) ListUserGroups(userID int64) ([]*domain.UserGroup, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.ErrUserNotFound
+	}
+	return s.groups.ListUserGroups(userID)
+}
+
+func (s *GroupService) validateGroup(group *domain.Group) error {
+	group.Name = strings.TrimSpace(group.Name)
+	if group.Name == "" || utf8.RuneCountInString(group.Name) > maxGroupNameLength {
+		return errors.ErrInvalidInput
+	}
+	if utf8.RuneCountInString(group.Description) > maxGroupDescriptionLength {
+		return errors.ErrInvalidInput
+	}
+	if group.ParentID == nil {
+		return nil
+	}
+
+	parent, err := s.groups.GetByID(*group.ParentID)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return errors.ErrGroupNotFound
+	}
+	return nil
+}
+
+func groupPage(filter domain.GroupFilter) domain.GroupFilter {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultGroupsPageSize
+	}
+	if filter.Limit > maxGroupsPageSize {
+		filter.Limit = maxGroupsPageSize
+	}
+	return filter
+}
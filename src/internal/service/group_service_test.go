@@ -0,0 +1,182 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockGroupRepository struct {
+	mock.Mock
+}
+
+func (m *MockGroupRepository) Create(group *domain.Group) error {
+	args := m.Called(group)
+	return args.Error(0)
+}
+
+func (m *MockGroupRepository) GetByID(id int64) (*domain.Group, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Group), args.Error(1)
+}
+
+func (m *MockGroupRepository) List(filter domain.GroupFilter) ([]*domain.Group, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Group), args.Error(1)
+}
+
+func (m *MockGroupRepository) Update(group *domain.Group) error {
+	args := m.Called(group)
+	return args.Error(0)
+}
+
+func (m *MockGroupRepository) Delete(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockGroupRepository) GetMember(groupID, userID int64) (*domain.GroupMember, error) {
+	args := m.Called(groupID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.GroupMember), args.Error(1)
+}
+
+func (m *MockGroupRepository) AddMember(member *domain.GroupMember) (bool, error) {
+	args := m.Called(member)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockGroupRepository) RemoveMember(groupID, userID int64) error {
+	args := m.Called(groupID, userID)
+	return args.Error(0)
+}
+
+func (m *MockGroupRepository) ListMembers(groupID int64, filter domain.GroupFilter) ([]*domain.User, error) {
+	args := m.Called(groupID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockGroupRepository) ListUserGroups(userID int64) ([]*domain.UserGroup, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UserGroup), args.Error(1)
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestCreateGroup(t *testing.T) {
+	groups := new(MockGroupRepository)
+	service := NewGroupService(groups, new(MockUserRepository))
+
+	assert.Equal(t, errors.ErrInvalidInput, service.CreateGroup(&domain.Group{Name: "  "}))
+
+	groups.On("GetByID", int64(9)).Return(nil, nil)
+	assert.Equal(t, errors.ErrGroupNotFound, service.CreateGroup(&domain.Group{Name: "Backend", ParentID: int64Ptr(9)}))
+
+	groups.On("GetByID", int64(1)).Return(&domain.Group{ID: 1, Name: "Engineering"}, nil)
+	groups.On("Create", mock.Anything).Return(nil)
+	group := &domain.Group{Name: " Backend ", ParentID: int64Ptr(1)}
+	assert.NoError(t, service.CreateGroup(group))
+	assert.Equal(t, "Backend", group.Name)
+	groups.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestUpdateGroupParent(t *testing.T) {
+	groups := new(MockGroupRepository)
+	service := NewGroupService(groups, new(MockUserRepository))
+
+	groups.On("GetByID", int64(1)).Return(&domain.Group{ID: 1, Name: "Engineering"}, nil)
+	groups.On("GetByID", int64(2)).Return(&domain.Group{ID: 2, Name: "Backend", ParentID: int64Ptr(1)}, nil)
+
+	err := service.UpdateGroup(&domain.Group{ID: 1, Name: "Engineering", ParentID: int64Ptr(1)})
+	assert.Equal(t, errors.ErrGroupCycle, err)
+
+	groups.On("Update", mock.MatchedBy(func(g *domain.Group) bool { return g.ID == 1 })).Return(errors.ErrGroupCycle)
+	err = service.UpdateGroup(&domain.Group{ID: 1, Name: "Engineering", ParentID: int64Ptr(2)})
+	assert.Equal(t, errors.ErrGroupCycle, err)
+
+	groups.On("GetByID", int64(3)).Return(nil, nil)
+	err = service.UpdateGroup(&domain.Group{ID: 3, Name: "Frontend"})
+	assert.Equal(t, errors.ErrGroupNotFound, err)
+}
+
+func TestDeleteGroup(t *testing.T) {
+	groups := new(MockGroupRepository)
+	service := NewGroupService(groups, new(MockUserRepository))
+
+	groups.On("Delete", int64(1)).Return(errors.ErrGroupHasSubgroups)
+	groups.On("Delete", int64(2)).Return(sql.ErrNoRows)
+
+	assert.Equal(t, errors.ErrGroupHasSubgroups, service.DeleteGroup(1))
+	assert.Equal(t, errors.ErrGroupNotFound, service.DeleteGroup(2))
+}
+
+func TestGroupMembership(t *testing.T) {
+	groups := new(MockGroupRepository)
+	users := new(MockUserRepository)
+	service := NewGroupService(groups, users)
+
+	groups.On("GetByID", int64(1)).Return(&domain.Group{ID: 1, Name: "Engineering"}, nil)
+	groups.On("GetByID", int64(2)).Return(&domain.Group{ID: 2, Name: "Backend", ParentID: int64Ptr(1)}, nil)
+	users.On("GetByID", int64(7)).Return(&domain.User{ID: 7}, nil)
+	users.On("GetByID", int64(8)).Return(nil, nil)
+
+	t.Run("add", func(t *testing.T) {
+		groups.On("AddMember", &domain.GroupMember{GroupID: 2, UserID: 7}).Return(true, nil).Once()
+
+		member, added, err := service.AddMember(2, 7)
+		assert.NoError(t, err)
+		assert.True(t, added)
+		assert.Equal(t, int64(7), member.UserID)
+
+		_, _, err = service.AddMember(2, 8)
+		assert.Equal(t, errors.ErrUserNotFound, err)
+	})
+
+	t.Run("inherited membership", func(t *testing.T) {
+		groups.On("GetMember", int64(1), int64(7)).Return(nil, nil)
+		groups.On("ListUserGroups", int64(7)).Return([]*domain.UserGroup{
+			{Group: domain.Group{ID: 1, Name: "Engineering"}},
+			{Group: domain.Group{ID: 2, Name: "Backend", ParentID: int64Ptr(1)}, Direct: true},
+		}, nil)
+
+		member, err := service.GetMember(1, 7)
+		assert.NoError(t, err)
+		assert.False(t, member.Direct)
+
+		groups.On("RemoveMember", int64(1), int64(7)).Return(sql.ErrNoRows)
+		assert.Equal(t, errors.ErrMemberNotFound, service.RemoveMember(1, 7))
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		groups.On("GetMember", int64(2), int64(9)).Return(nil, nil)
+		groups.On("ListUserGroups", int64(9)).Return([]*domain.UserGroup{}, nil)
+
+		_, err := service.GetMember(2, 9)
+		assert.Equal(t, errors.ErrMemberNotFound, err)
+	})
+
+	t.Run("groups of an unknown user", func(t *testing.T) {
+		_, err := service.ListUserGroups(8)
+		assert.Equal(t, errors.ErrUserNotFound, err)
+	})
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+// ImportUser validates a single imported user with the same rules as
+// CreateUser and either creates it or, when upserting, updates the existing
+// user with the same email. In dry-run mode nothing is written and the
+// status reports what would have happened.
+func (s *UserService) ImportUser(user *domain.User, opts domain.ImportOptions) (string, error) {
+	if err := s.validateNewUser(user); err != nil {
+		return domain.ImportStatusFailed, err
+	}
+
+	existing, err := s.repo.GetByEmail(user.EmailCanonical)
+	if err != nil {
+		return domain.ImportStatusFailed, err
+	}
+
+	if existing == nil {
+		if opts.DryRun {
+			return domain.ImportStatusWouldCreate, nil
+		}
+		if err := s.insertUser(user); err != nil {
+			return domain.ImportStatusFailed, err
+		}
+		return domain.ImportStatusCreated, nil
+	}
+
+	if !opts.Upsert {
+		return domain.ImportStatusFailed, errors.ErrEmailTaken
+	}
+	if existing.Name == user.Name {
+		*user = *existing
+		return domain.ImportStatusUnchanged, nil
+	}
+	if opts.DryRun {
+		user.ID = existing.ID
+		return domain.ImportStatusWouldUpdate, nil
+	}
+
+	update := &domain.User{ID: existing.ID, Name: user.Name}
+	if err := s.UpdateUser(update); err != nil {
+		return domain.ImportStatusFailed, err
+	}
+	*user = *update
+	return domain.ImportStatusUpdated, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImportUser(t *testing.T) {
+	t.Run("new user", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo)
+
+		user := &domain.User{Name: "John Doe", Email: "john@example.com"}
+		mockRepo.On("GetByEmail", "john@example.com").Return(nil, nil)
+		mockRepo.On("Create", user).Return(nil)
+
+		status, err := service.ImportUser(user, domain.ImportOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ImportStatusCreated, status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("dry run does not write", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo)
+
+		mockRepo.On("GetByEmail", "john@example.com").Return(nil, nil)
+
+		status, err := service.ImportUser(&domain.User{Name: "John Doe", Email: "john@example.com"}, domain.ImportOptions{DryRun: true})
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ImportStatusWouldCreate, status)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("existing email without upsert", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo)
+
+		mockRepo.On("GetByEmail", "john@example.com").Return(&domain.User{ID: 1, Name: "John", Email: "john@example.com"}, nil)
+
+		status, err := service.ImportUser(&domain.User{Name: "John Doe", Email: "john@example.com"}, domain.ImportOptions{})
+		assert.Equal(t, errors.ErrEmailTaken, err)
+		assert.Equal(t, domain.ImportStatusFailed, status)
+	})
+
+	t.Run("upsert updates existing user", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo)
+
+		existing := &domain.User{ID: 1, Name: "John", Email: "john@example.com"}
+		mockRepo.On("GetByEmail", "john@example.com").Return(existing, nil)
+		mockRepo.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Name: "John", Email: "john@example.com"}, nil)
+		mockRepo.On("Update", mock.MatchedBy(func(u *domain.User) bool { return u.Name == "John Doe" })).Return(nil)
+
+		user := &domain.User{Name: "John Doe", Email: "john@example.com"}
+		status, err := service.ImportUser(user, domain.ImportOptions{Upsert: true})
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ImportStatusUpdated, status)
+		assert.Equal(t, int64(1), user.ID)
+	})
+
+	t.Run("invalid row", func(t *testing.T) {
+		service := NewUserService(new(MockUserRepository))
+
+		status, err := service.ImportUser(&domain.User{Name: "John Doe", Email: "nope"}, domain.ImportOptions{})
+		assert.Equal(t, errors.ErrInvalidEmail, err)
+		assert.Equal(t, domain.ImportStatusFailed, status)
+	})
+}
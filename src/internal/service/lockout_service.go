@@ -0,0 +1,146 @@
+package service
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+type LockoutPolicy struct {
+	UserThreshold int
+	IPThreshold   int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	Window        time.Duration
+}
+
+// LockoutService tracks failed credential checks per account and per client
+// IP and locks the subject out with exponentially growing delays once its
+// threshold is reached.
+type LockoutService struct {
+	repo   domain.LockoutRepository
+	policy LockoutPolicy
+	now    func() time.Time
+}
+
+func NewLockoutService(repo domain.LockoutRepository, policy LockoutPolicy) *LockoutService {
+	return &LockoutService{
+		repo:   repo,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// Reserve counts an attempt against the account and the client IP before
+// the code is checked, and locks a subject as soon as the attempt reaches its
+// threshold. Parallel guesses therefore cannot get past the threshold by all
+// passing a check before any of them is recorded. The attempt stays counted
+// as a failure unless it is refunded.
+func (s *LockoutService) Reserve(userID int64, ip string) (*LockoutAttempt, error) {
+	now := s.now()
+	attempt := &LockoutAttempt{service: s}
+	for _, subject := range s.subjects(userID, ip) {
+		state, err := s.repo.Reserve(subject.scope, subject.key, now, s.policy.Window)
+		if err == nil && state.LockedUntil != nil && now.Before(*state.LockedUntil) {
+			err = &errors.LockedError{Until: *state.LockedUntil}
+		}
+		if err != nil {
+			attempt.Refund(false)
+			return nil, err
+		}
+		attempt.reserved = append(attempt.reserved, reservedSubject{subject, state.Failures})
+
+		if delay := s.delay(state.Failures, subject.threshold); delay > 0 {
+			if err := s.repo.Lock(subject.scope, subject.key, now.Add(delay)); err != nil {
+				attempt.Refund(false)
+				return nil, err
+			}
+		}
+	}
+	return attempt, nil
+}
+
+// Unlock clears the failures and the lock of an account, a client IP or
+// both.
+func (s *LockoutService) Unlock(userID int64, ip string) error {
+	if (userID == 0 && ip == "") || (ip != "" && net.ParseIP(ip) == nil) {
+		return errors.ErrInvalidInput
+	}
+	if userID != 0 {
+		if err := s.repo.Reset(domain.LockoutScopeUser, strconv.FormatInt(userID, 10)); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		return s.repo.Reset(domain.LockoutScopeIP, ip)
+	}
+	return nil
+}
+
+// LockoutAttempt is an attempt reserved by LockoutService.Reserve.
+type LockoutAttempt struct {
+	service  *LockoutService
+	reserved []reservedSubject
+}
+
+type reservedSubject struct {
+	lockoutSubject
+	failures int
+}
+
+// Refund takes the attempt back for an outcome that is not a failure. A
+// successful attempt also clears the earlier failures of the account.
+func (a *LockoutAttempt) Refund(succeeded bool) error {
+	var firstErr error
+	for _, r := range a.reserved {
+		var err error
+		if succeeded && r.scope == domain.LockoutScopeUser {
+			err = a.service.repo.Reset(r.scope, r.key)
+		} else {
+			err = a.service.repo.Refund(r.scope, r.key, r.failures)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (s *LockoutService) delay(failures, threshold int) time.Duration {
+	if threshold <= 0 || failures < threshold {
+		return 0
+	}
+	delay := s.policy.BaseDelay
+	for i := threshold; i < failures; i++ {
+		delay *= 2
+		if delay >= s.policy.MaxDelay {
+			return s.policy.MaxDelay
+		}
+	}
+	return delay
+}
+
+type lockoutSubject struct {
+	scope     string
+	key       string
+	threshold int
+}
+
+func (s *LockoutService) subjects(userID int64, ip string) []lockoutSubject {
+	subjects := []lockoutSubject{{
+		scope:     domain.LockoutScopeUser,
+		key:       strconv.FormatInt(userID, 10),
+		threshold: s.policy.UserThreshold,
+	}}
+	if ip != "" {
+		subjects = append(subjects, lockoutSubject{
+			scope:     domain.LockoutScopeIP,
+			key:       ip,
+			threshold: s.policy.IPThreshold,
+		})
+	}
+	return subjects
+}
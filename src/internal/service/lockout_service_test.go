@@ -0,0 +1,159 @@
+package service
+
+import (
+	stdErrors "errors"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLockoutRepository struct {
+	mock.Mock
+}
+
+func (m *MockLockoutRepository) Reserve(scope, subject string, at time.Time, window time.Duration) (*domain.LockoutState, error) {
+	args := m.Called(scope, subject, at, window)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LockoutState), args.Error(1)
+}
+
+func (m *MockLockoutRepository) Lock(scope, subject string, until time.Time) error {
+	args := m.Called(scope, subject, until)
+	return args.Error(0)
+}
+
+func (m *MockLockoutRepository) Refund(scope, subject string, failures int) error {
+	args := m.Called(scope, subject, failures)
+	return args.Error(0)
+}
+
+func (m *MockLockoutRepository) Reset(scope, subject string) error {
+	args := m.Called(scope, subject)
+	return args.Error(0)
+}
+
+var testLockoutPolicy = LockoutPolicy{
+	UserThreshold: 3,
+	IPThreshold:   10,
+	BaseDelay:     30 * time.Second,
+	MaxDelay:      5 * time.Minute,
+	Window:        time.Hour,
+}
+
+func TestLockoutDelay(t *testing.T) {
+	service := NewLockoutService(new(MockLockoutRepository), testLockoutPolicy)
+
+	assert.Equal(t, time.Duration(0), service.delay(2, 3))
+	assert.Equal(t, 30*time.Second, service.delay(3, 3))
+	assert.Equal(t, time.Minute, service.delay(4, 3))
+	assert.Equal(t, 2*time.Minute, service.delay(5, 3))
+	assert.Equal(t, 5*time.Minute, service.delay(20, 3))
+}
+
+func TestLockoutReserve(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	newService := func(repo *MockLockoutRepository) *LockoutService {
+		service := NewLockoutService(repo, testLockoutPolicy)
+		service.now = func() time.Time { return now }
+		return service
+	}
+
+	t.Run("locked account", func(t *testing.T) {
+		repo := new(MockLockoutRepository)
+		until := now.Add(time.Minute)
+		repo.On("Reserve", domain.LockoutScopeUser, "1", now, time.Hour).Return(&domain.LockoutState{Failures: 3, LockedUntil: &until}, nil)
+
+		_, err := newService(repo).Reserve(1, "192.0.2.1")
+		assert.True(t, stdErrors.Is(err, errors.ErrAccountLocked))
+		repo.AssertNotCalled(t, "Reserve", domain.LockoutScopeIP, mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "Refund", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("locked ip gives back the account attempt", func(t *testing.T) {
+		repo := new(MockLockoutRepository)
+		until := now.Add(time.Minute)
+		repo.On("Reserve", domain.LockoutScopeUser, "1", now, time.Hour).Return(&domain.LockoutState{Failures: 1}, nil)
+		repo.On("Reserve", domain.LockoutScopeIP, "192.0.2.1", now, time.Hour).Return(&domain.LockoutState{Failures: 10, LockedUntil: &until}, nil)
+		repo.On("Refund", domain.LockoutScopeUser, "1", 1).Return(nil)
+
+		_, err := newService(repo).Reserve(1, "192.0.2.1")
+		assert.True(t, stdErrors.Is(err, errors.ErrAccountLocked))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("expired lock", func(t *testing.T) {
+		repo := new(MockLockoutRepository)
+		until := now.Add(-time.Minute)
+		repo.On("Reserve", domain.LockoutScopeUser, "1", now, time.Hour).Return(&domain.LockoutState{Failures: 1, LockedUntil: &until}, nil)
+		repo.On("Reserve", domain.LockoutScopeIP, "192.0.2.1", now, time.Hour).Return(&domain.LockoutState{Failures: 1}, nil)
+
+		_, err := newService(repo).Reserve(1, "192.0.2.1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("attempt reaching the threshold locks before its outcome", func(t *testing.T) {
+		repo := new(MockLockoutRepository)
+		repo.On("Reserve", domain.LockoutScopeUser, "1", now, time.Hour).Return(&domain.LockoutState{Failures: 4}, nil)
+		repo.On("Reserve", domain.LockoutScopeIP, "192.0.2.1", now, time.Hour).Return(&domain.LockoutState{Failures: 4}, nil)
+		repo.On("Lock", domain.LockoutScopeUser, "1", now.Add(time.Minute)).Return(nil)
+
+		_, err := newService(repo).Reserve(1, "192.0.2.1")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestLockoutRefund(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	reserve := func(repo *MockLockoutRepository) *LockoutAttempt {
+		repo.On("Reserve", domain.LockoutScopeUser, "1", now, time.Hour).Return(&domain.LockoutState{Failures: 3}, nil)
+		repo.On("Reserve", domain.LockoutScopeIP, "192.0.2.1", now, time.Hour).Return(&domain.LockoutState{Failures: 5}, nil)
+		repo.On("Lock", domain.LockoutScopeUser, "1", now.Add(30*time.Second)).Return(nil)
+		service := NewLockoutService(repo, testLockoutPolicy)
+		service.now = func() time.Time { return now }
+		attempt, err := service.Reserve(1, "192.0.2.1")
+		assert.NoError(t, err)
+		return attempt
+	}
+
+	t.Run("success clears the account", func(t *testing.T) {
+		repo := new(MockLockoutRepository)
+		attempt := reserve(repo)
+		repo.On("Reset", domain.LockoutScopeUser, "1").Return(nil)
+		repo.On("Refund", domain.LockoutScopeIP, "192.0.2.1", 5).Return(nil)
+
+		assert.NoError(t, attempt.Refund(true))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("other outcomes give the attempt back", func(t *testing.T) {
+		repo := new(MockLockoutRepository)
+		attempt := reserve(repo)
+		repo.On("Refund", domain.LockoutScopeUser, "1", 3).Return(nil)
+		repo.On("Refund", domain.LockoutScopeIP, "192.0.2.1", 5).Return(nil)
+
+		assert.NoError(t, attempt.Refund(false))
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "Reset", mock.Anything, mock.Anything)
+	})
+}
+
+func TestLockoutUnlock(t *testing.T) {
+	repo := new(MockLockoutRepository)
+	service := NewLockoutService(repo, testLockoutPolicy)
+
+	repo.On("Reset", domain.LockoutScopeUser, "1").Return(nil)
+	repo.On("Reset", domain.LockoutScopeIP, "192.0.2.1").Return(nil)
+
+	assert.NoError(t, service.Unlock(1, ""))
+	assert.NoError(t, service.Unlock(0, "192.0.2.1"))
+	assert.Equal(t, errors.ErrInvalidInput, service.Unlock(0, ""))
+	assert.Equal(t, errors.ErrInvalidInput, service.Unlock(1, "not-an-ip"))
+	repo.AssertNumberOfCalls(t, "Reset", 2)
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+	"users-api/src/internal/token"
+	"users-api/src/internal/totp"
+)
+
+const (
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 5
+	totpSkew          = 1
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type MFAService struct {
+	users   domain.UserRepository
+	mfa     domain.MFARepository
+	lockout *LockoutService
+	issuer  string
+	now     func() time.Time
+}
+
+func NewMFAService(users domain.UserRepository, mfa domain.MFARepository, lockout *LockoutService, issuer string) *MFAService {
+	return &MFAService{
+		users:   users,
+		mfa:     mfa,
+		lockout: lockout,
+		issuer:  issuer,
+		now:     time.Now,
+	}
+}
+
+func (s *MFAService) Enroll(userID int64) (*domain.MFAEnrollment, error) {
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	enrolled, err := s.mfa.Enroll(&domain.UserMFA{UserID: userID, Secret: secret})
+	if err != nil {
+		return nil, err
+	}
+	if !enrolled {
+		return nil, errors.ErrMFAAlreadyEnabled
+	}
+
+	return &domain.MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables MFA once the user proves they set up the secret, and
+// returns the first recovery codes. Failed attempts count towards the
+// lockout like those of Verify.
+func (s *MFAService) Confirm(userID int64, code, clientIP string) ([]string, error) {
+	var current *domain.UserMFA
+	var step int64
+	err := s.attempt(userID, clientIP, func() error {
+		var err error
+		current, err = s.mfa.Get(userID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return errors.ErrMFANotEnrolled
+		}
+		if current.EnabledAt != nil {
+			return errors.ErrMFAAlreadyEnabled
+		}
+
+		var ok bool
+		if step, ok = totp.Validate(current.Secret, code, s.now(), totpSkew); !ok {
+			return errors.ErrInvalidMFACode
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.mfa.Enable(userID, current.Secret, s.now(), step)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		// Confirmed by a concurrent request, or replaced by a new enrollment.
+		latest, err := s.mfa.Get(userID)
+		if err != nil {
+			return nil, err
+		}
+		if latest != nil && latest.EnabledAt != nil {
+			return nil, errors.ErrMFAAlreadyEnabled
+		}
+		return nil, errors.ErrMFANotEnrolled
+	}
+
+	return s.issueRecoveryCodes(userID)
+}
+
+// Verify checks the second factor for an enabled user. It accepts either a
+// current TOTP code or one of the unused recovery codes. Failed attempts
+// count towards the account and client IP lockout.
+func (s *MFAService) Verify(userID int64, code, clientIP string) error {
+	return s.attempt(userID, clientIP, func() error {
+		return s.verifyEnabled(userID, code)
+	})
+}
+
+func (s *MFAService) Disable(userID int64, code, clientIP string) error {
+	err := s.attempt(userID, clientIP, func() error {
+		return s.verifyEnabled(userID, code)
+	})
+	if err != nil {
+		return err
+	}
+	return s.mfa.Delete(userID)
+}
+
+func (s *MFAService) RegenerateRecoveryCodes(userID int64, code, clientIP string) ([]string, error) {
+	err := s.attempt(userID, clientIP, func() error {
+		return s.verifyEnabled(userID, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(userID)
+}
+
+// attempt runs verify for every request that accepts a code, refusing users
+// outside the tenant and locked accounts and client IPs. The attempt is
+// counted as a failure before verify runs and refunded unless verify returns
+// ErrInvalidMFACode.
+func (s *MFAService) attempt(userID int64, clientIP string, verify func() error) error {
+	if _, err := s.user(userID); err != nil {
+		return err
+	}
+	if s.lockout == nil {
+		return verify()
+	}
+
+	attempt, err := s.lockout.Reserve(userID, clientIP)
+	if err != nil {
+		return err
+	}
+	err = verify()
+	if err == errors.ErrInvalidMFACode {
+		return err
+	}
+	if refundErr := attempt.Refund(err == nil); refundErr != nil && err == nil {
+		return refundErr
+	}
+	return err
+}
+
+// user looks the user up through the tenant's repository. MFA settings and
+// lockouts are keyed by user ID alone, so this is what keeps a tenant away
+// from the users of others.
+func (s *MFAService) user(userID int64) (*domain.User, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *MFAService) verifyEnabled(userID int64, code string) error {
+	current, err := s.enabledMFA(userID)
+	if err != nil {
+		return err
+	}
+	return s.verifyCode(current, code)
+}
+
+func (s *MFAService) enabledMFA(userID int64) (*domain.UserMFA, error) {
+	current, err := s.mfa.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.EnabledAt == nil {
+		return nil, errors.ErrMFANotEnabled
+	}
+	return current, nil
+}
+
+func (s *MFAService) verifyCode(current *domain.UserMFA, code string) error {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(current.Secret, code, s.now(), totpSkew)
+		if !ok {
+			return errors.ErrInvalidMFACode
+		}
+		advanced, err := s.mfa.AdvanceStep(current.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return errors.ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.mfa.UseRecoveryCode(current.UserID, token.Hash(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errors.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) issueRecoveryCodes(userID int64) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	buf := make([]byte, recoveryCodeBytes)
+
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(buf))
+		codes[i] = raw[:4] + "-" + raw[4:]
+		hashes[i] = token.Hash(raw)
+	}
+
+	if err := s.mfa.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	stdErrors "errors"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+	"users-api/src/internal/token"
+	"users-api/src/internal/totp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) Get(userID int64) (*domain.UserMFA, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserMFA), args.Error(1)
+}
+
+func (m *MockMFARepository) Enroll(mfa *domain.UserMFA) (bool, error) {
+	args := m.Called(mfa)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) Enable(userID int64, secret string, enabledAt time.Time, step int64) (bool, error) {
+	args := m.Called(userID, secret, enabledAt, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) Delete(userID int64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) AdvanceStep(userID int64, step int64) (bool, error) {
+	args := m.Called(userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(userID int64, hashes []string) error {
+	args := m.Called(userID, hashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseRecoveryCode(userID int64, hash string) (bool, error) {
+	args := m.Called(userID, hash)
+	return args.Bool(0), args.Error(1)
+}
+
+const testSecret = "JBSWY3DPEHPK3PXP"
+
+func newTestMFAService(users *MockUserRepository, repo *MockMFARepository, now time.Time) *MFAService {
+	s := NewMFAService(users, repo, nil, "users-api")
+	s.now = func() time.Time { return now }
+	return s
+}
+
+// existingUsers finds every user in the tenant.
+func existingUsers() *MockUserRepository {
+	users := new(MockUserRepository)
+	users.On("GetByID", mock.Anything).Return(&domain.User{Email: "john@example.com"}, nil)
+	return users
+}
+
+func TestMFAEnroll(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	t.Run("new enrollment", func(t *testing.T) {
+		users := new(MockUserRepository)
+		repo := new(MockMFARepository)
+		service := newTestMFAService(users, repo, now)
+
+		users.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Email: "john@example.com"}, nil)
+		repo.On("Enroll", mock.MatchedBy(func(m *domain.UserMFA) bool {
+			return m.UserID == 1 && m.Secret != "" && m.EnabledAt == nil
+		})).Return(true, nil)
+
+		enrollment, err := service.Enroll(1)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, enrollment.Secret)
+		assert.Contains(t, enrollment.URI, "otpauth://totp/users-api:john@example.com?")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("already enabled", func(t *testing.T) {
+		users := new(MockUserRepository)
+		repo := new(MockMFARepository)
+		service := newTestMFAService(users, repo, now)
+
+		users.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Email: "john@example.com"}, nil)
+		repo.On("Enroll", mock.AnythingOfType("*domain.UserMFA")).Return(false, nil)
+
+		_, err := service.Enroll(1)
+		assert.Equal(t, errors.ErrMFAAlreadyEnabled, err)
+	})
+}
+
+func TestMFAConfirm(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	code, _ := totp.Code(testSecret, totp.Step(now))
+
+	t.Run("valid code enables mfa", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := newTestMFAService(existingUsers(), repo, now)
+
+		repo.On("Get", int64(1)).Return(&domain.UserMFA{UserID: 1, Secret: testSecret}, nil)
+		repo.On("Enable", int64(1), testSecret, now, totp.Step(now)).Return(true, nil)
+		repo.On("ReplaceRecoveryCodes", int64(1), mock.AnythingOfType("[]string")).Return(nil)
+
+		codes, err := service.Confirm(1, code, "")
+		assert.NoError(t, err)
+		assert.Len(t, codes, recoveryCodeCount)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("enrollment replaced meanwhile", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := newTestMFAService(existingUsers(), repo, now)
+
+		repo.On("Get", int64(1)).Return(&domain.UserMFA{UserID: 1, Secret: testSecret}, nil)
+		repo.On("Enable", int64(1), testSecret, now, totp.Step(now)).Return(false, nil)
+
+		_, err := service.Confirm(1, code, "")
+		assert.Equal(t, errors.ErrMFANotEnrolled, err)
+		repo.AssertNotCalled(t, "ReplaceRecoveryCodes", mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := newTestMFAService(existingUsers(), repo, now)
+
+		repo.On("Get", int64(1)).Return(&domain.UserMFA{UserID: 1, Secret: testSecret}, nil)
+
+		_, err := service.Confirm(1, "000000", "")
+		assert.Equal(t, errors.ErrInvalidMFACode, err)
+	})
+
+	t.Run("not enrolled", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := newTestMFAService(existingUsers(), repo, now)
+
+		repo.On("Get", int64(1)).Return(nil, nil)
+
+		_, err := service.Confirm(1, code, "")
+		assert.Equal(t, errors.ErrMFANotEnrolled, err)
+	})
+}
+
+func TestMFAVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	code, _ := totp.Code(testSecret, totp.Step(now))
+	enabled := &domain.UserMFA{UserID: 1, Secret: testSecret, EnabledAt: &now}
+
+	t.Run("totp code", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := newTestMFAService(existingUsers(), repo, now)
+
+		repo.On("Get", int64(1)).Return(enabled, nil)
+		repo.On("AdvanceStep", int64(1), totp.Step(now)).Return(true, nil)
+
+		assert.NoError(t, service.Verify(1, code, ""))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("replayed totp code", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := newTestMFAService(existingUsers(), repo, now)
+
+		repo.On("Get", int64(1)).Return(enabled, nil)
+		repo.On("AdvanceStep", int64(1), totp.Step(now)).Return(false, nil)
+
+		assert.Equal(t, errors.ErrInvalidMFACode, service.Verify(1, code, ""))
+	})
+
+	t.Run("recovery code", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := newTestMFAService(existingUsers(), repo, now)
+
+		repo.On("Get", int64(1)).Return(enabled, nil)
+		repo.On("UseRecoveryCode", int64(1), token.Hash("abcdefgh")).Return(true, nil)
+
+		assert.NoError(t, service.Verify(1, "ABCD-EFGH", ""))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("mfa not enabled", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := newTestMFAService(existingUsers(), repo, now)
+
+		repo.On("Get", int64(2)).Return(&domain.UserMFA{UserID: 2, Secret: testSecret}, nil)
+
+		assert.Equal(t, errors.ErrMFANotEnabled, service.Verify(2, code, ""))
+	})
+}
+
+func TestMFALockout(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	code, _ := totp.Code(testSecret, totp.Step(now))
+	until := now.Add(time.Minute)
+
+	newLockedService := func(repo *MockMFARepository) *MFAService {
+		lockoutRepo := new(MockLockoutRepository)
+		lockoutRepo.On("Reserve", domain.LockoutScopeUser, "1", now, time.Hour).Return(&domain.LockoutState{Failures: 3, LockedUntil: &until}, nil)
+		lockout := NewLockoutService(lockoutRepo, testLockoutPolicy)
+		lockout.now = func() time.Time { return now }
+		service := NewMFAService(existingUsers(), repo, lockout, "users-api")
+		service.now = func() time.Time { return now }
+		return service
+	}
+
+	t.Run("locked account cannot disable", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := newLockedService(repo)
+
+		err := service.Disable(1, code, "192.0.2.1")
+		assert.True(t, stdErrors.Is(err, errors.ErrAccountLocked))
+		repo.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+
+	t.Run("locked account cannot regenerate recovery codes", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := newLockedService(repo)
+
+		_, err := service.RegenerateRecoveryCodes(1, code, "192.0.2.1")
+		assert.True(t, stdErrors.Is(err, errors.ErrAccountLocked))
+		repo.AssertNotCalled(t, "ReplaceRecoveryCodes", mock.Anything, mock.Anything)
+	})
+
+	t.Run("locked account cannot confirm", func(t *testing.T) {
+		repo := new(MockMFARepository)
+		service := newLockedService(repo)
+
+		_, err := service.Confirm(1, code, "192.0.2.1")
+		assert.True(t, stdErrors.Is(err, errors.ErrAccountLocked))
+		repo.AssertNotCalled(t, "Enable", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong code on disable counts as a failure", func(t *testing.T) {
+		lockoutRepo := new(MockLockoutRepository)
+		lockoutRepo.On("Reserve", domain.LockoutScopeUser, "1", now, time.Hour).Return(&domain.LockoutState{Failures: 1}, nil)
+		lockoutRepo.On("Reserve", domain.LockoutScopeIP, "192.0.2.1", now, time.Hour).Return(&domain.LockoutState{Failures: 1}, nil)
+		lockout := NewLockoutService(lockoutRepo, testLockoutPolicy)
+		lockout.now = func() time.Time { return now }
+		repo := new(MockMFARepository)
+		service := NewMFAService(existingUsers(), repo, lockout, "users-api")
+		service.now = func() time.Time { return now }
+
+		repo.On("Get", int64(1)).Return(&domain.UserMFA{UserID: 1, Secret: testSecret, EnabledAt: &now}, nil)
+		repo.On("UseRecoveryCode", int64(1), mock.Anything).Return(false, nil)
+
+		assert.Equal(t, errors.ErrInvalidMFACode, service.Disable(1, "wrong-code", "192.0.2.1"))
+		lockoutRepo.AssertExpectations(t)
+		lockoutRepo.AssertNotCalled(t, "Refund", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+
+	t.Run("accepted code gives the attempt back", func(t *testing.T) {
+		lockoutRepo := new(MockLockoutRepository)
+		lockoutRepo.On("Reserve", domain.LockoutScopeUser, "1", now, time.Hour).Return(&domain.LockoutState{Failures: 2}, nil)
+		lockoutRepo.On("Reserve", domain.LockoutScopeIP, "192.0.2.1", now, time.Hour).Return(&domain.LockoutState{Failures: 7}, nil)
+		lockoutRepo.On("Reset", domain.LockoutScopeUser, "1").Return(nil)
+		lockoutRepo.On("Refund", domain.LockoutScopeIP, "192.0.2.1", 7).Return(nil)
+		lockout := NewLockoutService(lockoutRepo, testLockoutPolicy)
+		lockout.now = func() time.Time { return now }
+		repo := new(MockMFARepository)
+		service := NewMFAService(existingUsers(), repo, lockout, "users-api")
+		service.now = func() time.Time { return now }
+
+		repo.On("Get", int64(1)).Return(&domain.UserMFA{UserID: 1, Secret: testSecret, EnabledAt: &now}, nil)
+		repo.On("AdvanceStep", int64(1), mock.Anything).Return(true, nil)
+
+		assert.NoError(t, service.Verify(1, code, "192.0.2.1"))
+		lockoutRepo.AssertExpectations(t)
+	})
+}
+
+func TestMFAOtherTenant(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	code, _ := totp.Code(testSecret, totp.Step(now))
+
+	// User 5 belongs to another tenant, so the scoped repository does not
+	// find it; the shared MFA and lockout tables must not be touched.
+	users := new(MockUserRepository)
+	users.On("GetByID", int64(5)).Return(nil, nil)
+	repo := new(MockMFARepository)
+	lockoutRepo := new(MockLockoutRepository)
+	service := NewMFAService(users, repo, NewLockoutService(lockoutRepo, testLockoutPolicy), "users-api")
+	service.now = func() time.Time { return now }
+
+	_, err := service.Confirm(5, code, "192.0.2.1")
+	assert.Equal(t, errors.ErrUserNotFound, err)
+	assert.Equal(t, errors.ErrUserNotFound, service.Verify(5, code, "192.0.2.1"))
+	assert.Equal(t, errors.ErrUserNotFound, service.Disable(5, code, "192.0.2.1"))
+	_, err = service.RegenerateRecoveryCodes(5, code, "192.0.2.1")
+	assert.Equal(t, errors.ErrUserNotFound, err)
+
+	repo.AssertNotCalled(t, "Get", mock.Anything)
+	lockoutRepo.AssertNotCalled(t, "Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"time"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/emailaddr"
+	"users-api/src/internal/mailer"
+)
+
+type Option func(*UserService)
+
+type EmailVerificationConfig struct {
+	BaseURL string
+	From    string
+	TTL     time.Duration
+}
+
+func WithEmailVerification(tokens domain.EmailVerificationRepository, m mailer.Mailer, cfg EmailVerificationConfig) Option {
+	return func(s *UserService) {
+		s.verification = &emailVerification{
+			tokens: tokens,
+			mailer: m,
+			cfg:    cfg,
+		}
+	}
+}
+
+func WithTxManager(tx domain.TxManager) Option {
+	return func(s *UserService) {
+		s.tx = tx
+	}
+}
+
+// WithEventLog persists every published user event so that subscribers can
+// resume from the last event they saw.
+func WithEventLog(log domain.UserEventRepository) Option {
+	return func(s *UserService) {
+		s.events.log = log
+	}
+}
+
+// WithWebhookQueue queues a delivery of every logged user event for the
+// webhook subscriptions interested in it. It has no effect without
+// WithEventLog.
+func WithWebhookQueue(queue domain.WebhookDeliveryRepository) Option {
+	return func(s *UserService) {
+		s.webhooks = queue
+	}
+}
+
+// WithOutbox stages every logged user event for publishing to the message
+// broker. It has no effect without WithEventLog.
+func WithOutbox(outbox domain.UserEventOutbox) Option {
+	return func(s *UserService) {
+		s.outbox = outbox
+	}
+}
+
+// WithGmailCanonicalization makes Gmail addresses that differ only in dots
+// or a "+tag" suffix count as the same email, both for uniqueness and for
+// GetUserByEmail.
+func WithGmailCanonicalization() Option {
+	return func(s *UserService) {
+		s.gmailFolding = true
+	}
+}
+
+// WithEmailValidators vets every new or changed email address, after it is
+// parsed and normalized, with validators in order.
+func WithEmailValidators(validators ...emailaddr.Validator) Option {
+	return func(s *UserService) {
+		s.validators = validators
+	}
+}
+
+func WithBatchConfig(cfg BatchConfig) Option {
+	return func(s *UserService) {
+		s.batch = cfg
+	}
+}
+
+// WithAttributeSchema validates the custom attributes of users against the
+// definitions managed by attrs. Without it users cannot have attributes.
+func WithAttributeSchema(attrs *AttributeService) Option {
+	return func(s *UserService) {
+		s.attributes = attrs
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+	"users-api/src/internal/search"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchQuery     = 200
+)
+
+// SearchUsers finds users whose name or email matches query, tolerating
+// typos, best match first. Repositories that implement domain.UserSearcher
+// search their own indexes; the rest are scanned and scored in memory with
+// the same trigram similarity pg_trgm uses.
+func (s *UserService) SearchUsers(query string, limit int) ([]*domain.UserSearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || utf8.RuneCountInString(query) > maxSearchQuery || limit < 0 {
+		return nil, errors.ErrInvalidInput
+	}
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	var (
+		results []*domain.UserSearchResult
+		err     error
+	)
+	if searcher, ok := s.repo.(domain.UserSearcher); ok {
+		results, err = searcher.Search(query, limit)
+	} else {
+		results, err = s.scanSearch(query, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		result.NameHighlight, _ = search.Highlight(result.User.Name, query)
+		result.EmailHighlight, _ = search.Highlight(result.User.Email, query)
+	}
+	return results, nil
+}
+
+func (s *UserService) scanSearch(query string, limit int) ([]*domain.UserSearchResult, error) {
+	results := []*domain.UserSearchResult{}
+	err := s.ExportUsers(domain.UserFilter{}, func(user *domain.User) error {
+		score := max(
+			search.WordSimilarity(query, user.Name),
+			search.WordSimilarity(query, user.Email),
+			search.Similarity(query, user.Email),
+		)
+		if score >= search.FuzzyThreshold {
+			results = append(results, &domain.UserSearchResult{User: user, Score: score})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].User.ID < results[j].User.ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchUsersWithoutSearcher(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo)
+
+	mockRepo.On("List", domain.UserFilter{Limit: exportPageSize}).Return([]*domain.User{
+		{ID: 1, Name: "Anna Smirnova", Email: "anna@example.com"},
+		{ID: 2, Name: "Ivan Petrov", Email: "ivan@example.com"},
+		{ID: 3, Name: "Olga Sidorova", Email: "olga@example.com"},
+	}, nil)
+
+	results, err := service.SearchUsers("  petrvo ", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, int64(2), results[0].User.ID)
+		assert.GreaterOrEqual(t, results[0].Score, 0.3)
+		assert.Equal(t, "Ivan <mark>Petrov</mark>", results[0].NameHighlight)
+		assert.Equal(t, "ivan@example.com", results[0].EmailHighlight)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearchUsersInvalidQuery(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo)
+
+	for _, query := range []string{"", "   ", string(make([]rune, maxSearchQuery+1))} {
+		_, err := service.SearchUsers(query, 10)
+		assert.Equal(t, errors.ErrInvalidInput, err)
+	}
+	_, err := service.SearchUsers("anna", -1)
+	assert.Equal(t, errors.ErrInvalidInput, err)
+	mockRepo.AssertNotCalled(t, "List")
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+)
+
+const maxStatusReason = 500
+
+// statusTransitions lists the statuses a user may move to from each status.
+var statusTransitions = map[string][]string{
+	domain.UserStatusPending:     {domain.UserStatusActive, domain.UserStatusDeactivated},
+	domain.UserStatusActive:      {domain.UserStatusSuspended, domain.UserStatusDeactivated},
+	domain.UserStatusSuspended:   {domain.UserStatusActive, domain.UserStatusDeactivated},
+	domain.UserStatusDeactivated: {domain.UserStatusActive},
+}
+
+func validStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+func canTransition(from, to string) bool {
+	return slices.Contains(statusTransitions[from], to)
+}
+
+// ChangeStatus moves a user to status and records reason and the time of the
+// change. Moving a user to the status it already has is rejected like any
+// other transition the state machine does not allow. The row stays locked
+// from the check to the write, so concurrent changes cannot both pass it.
+func (s *UserService) ChangeStatus(id int64, status, reason string) (*domain.User, error) {
+	if id == 0 || !validStatus(status) || utf8.RuneCountInString(reason) > maxStatusReason {
+		return nil, errors.ErrInvalidInput
+	}
+
+	var user *domain.User
+	err := s.write(func(u *unitOfWork) error {
+		current, err := u.users.GetByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return errors.ErrUserNotFound
+		}
+		if !canTransition(current.Status, status) {
+			return errors.ErrInvalidStatusTransition
+		}
+		if err := s.normalizeEmail(current); err != nil {
+			return err
+		}
+
+		s.setStatus(current, status, reason)
+		if err := u.users.Update(current); err != nil {
+			return err
+		}
+		user = current
+		return s.record(u, domain.UserEventUpdated, user.ID, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *UserService) setStatus(user *domain.User, status, reason string) {
+	changedAt := s.now().UTC().Format(time.RFC3339)
+	user.Status = status
+	user.StatusReason = reason
+	user.StatusChangedAt = &changedAt
+}
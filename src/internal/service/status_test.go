@@ -0,0 +1,111 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestChangeStatus(t *testing.T) {
+	now := time.Date(2025, 3, 21, 13, 45, 30, 0, time.UTC)
+
+	tests := []struct {
+		from, to string
+		err      error
+	}{
+		{domain.UserStatusPending, domain.UserStatusActive, nil},
+		{domain.UserStatusPending, domain.UserStatusSuspended, errors.ErrInvalidStatusTransition},
+		{domain.UserStatusActive, domain.UserStatusSuspended, nil},
+		{domain.UserStatusActive, domain.UserStatusActive, errors.ErrInvalidStatusTransition},
+		{domain.UserStatusSuspended, domain.UserStatusActive, nil},
+		{domain.UserStatusSuspended, domain.UserStatusDeactivated, nil},
+		{domain.UserStatusDeactivated, domain.UserStatusSuspended, errors.ErrInvalidStatusTransition},
+		{domain.UserStatusDeactivated, domain.UserStatusActive, nil},
+		{domain.UserStatusActive, "banned", errors.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			service := NewUserService(mockRepo)
+			service.now = func() time.Time { return now }
+
+			mockRepo.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Name: "Ivan", Email: "ivan@example.com", Status: tt.from}, nil)
+			mockRepo.On("Update", mock.Anything).Return(nil)
+
+			user, err := service.ChangeStatus(1, tt.to, "chargeback")
+			assert.Equal(t, tt.err, err)
+			if tt.err != nil {
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+				return
+			}
+			assert.Equal(t, tt.to, user.Status)
+			assert.Equal(t, "chargeback", user.StatusReason)
+			assert.Equal(t, "2025-03-21T13:45:30Z", *user.StatusChangedAt)
+			assert.Equal(t, "ivan@example.com", user.EmailCanonical)
+			assert.Equal(t, []int64{1}, mockRepo.locked)
+		})
+	}
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("GetByID", int64(2)).Return(nil, nil)
+
+		_, err := NewUserService(mockRepo).ChangeStatus(2, domain.UserStatusSuspended, "")
+		assert.Equal(t, errors.ErrUserNotFound, err)
+	})
+}
+
+func TestUpdateUserStatus(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo)
+
+	mockRepo.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Name: "Ivan", Email: "ivan@example.com", Status: domain.UserStatusDeactivated}, nil)
+	mockRepo.On("Update", mock.Anything).Return(nil)
+
+	err := service.UpdateUser(&domain.User{ID: 1, Status: domain.UserStatusSuspended})
+	assert.Equal(t, errors.ErrInvalidStatusTransition, err)
+
+	// Sending the current status again is not a transition.
+	user := &domain.User{ID: 1, Name: "Ivan P", Status: domain.UserStatusDeactivated}
+	assert.NoError(t, service.UpdateUser(user))
+	assert.Nil(t, user.StatusChangedAt)
+
+	user = &domain.User{ID: 1, Status: domain.UserStatusActive, StatusReason: "rehired"}
+	assert.NoError(t, service.UpdateUser(user))
+	assert.Equal(t, domain.UserStatusActive, user.Status)
+	assert.Equal(t, "rehired", user.StatusReason)
+	assert.NotNil(t, user.StatusChangedAt)
+	assert.Equal(t, []int64{1, 1, 1}, mockRepo.locked)
+}
+
+func TestCreateUserStatus(t *testing.T) {
+	tests := []struct {
+		status, want string
+		err          error
+	}{
+		{"", domain.UserStatusActive, nil},
+		{domain.UserStatusPending, domain.UserStatusPending, nil},
+		{domain.UserStatusActive, domain.UserStatusActive, nil},
+		{domain.UserStatusSuspended, "", errors.ErrInvalidInput},
+		{domain.UserStatusDeactivated, "", errors.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run("status "+tt.status, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockRepo.On("Create", mock.Anything).Return(nil)
+
+			user := &domain.User{Name: "Ivan", Email: "ivan@example.com", Status: tt.status}
+			err := NewUserService(mockRepo).CreateUser(user)
+			assert.Equal(t, tt.err, err)
+			if tt.err != nil {
+				mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+			assert.Equal(t, tt.want, user.Status)
+		})
+	}
+}
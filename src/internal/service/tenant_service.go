@@ -0,0 +1,81 @@
+package service
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+	"users-api/src/internal/token"
+)
+
+const (
+	apiKeyPrefix      = "uak_"
+	maxTenantNameSize = 255
+)
+
+// TenantService manages tenants and resolves the API keys that authenticate
+// as them. Only SHA-256 hashes of the keys are stored.
+type TenantService struct {
+	repo domain.TenantRepository
+}
+
+func NewTenantService(repo domain.TenantRepository) *TenantService {
+	return &TenantService{repo: repo}
+}
+
+// CreateTenant creates a tenant together with its first API key, which is
+// only ever returned here.
+func (s *TenantService) CreateTenant(name string) (*domain.Tenant, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxTenantNameSize {
+		return nil, "", errors.ErrInvalidInput
+	}
+
+	tenant := &domain.Tenant{Name: name}
+	if err := s.repo.Create(tenant); err != nil {
+		return nil, "", err
+	}
+	key, err := s.IssueAPIKey(tenant.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	return tenant, key, nil
+}
+
+// IssueAPIKey adds an API key for an existing tenant. Earlier keys stay
+// valid.
+func (s *TenantService) IssueAPIKey(tenantID int64) (string, error) {
+	tenant, err := s.repo.GetByID(tenantID)
+	if err != nil {
+		return "", err
+	}
+	if tenant == nil {
+		return "", errors.ErrTenantNotFound
+	}
+
+	plain, _, err := token.Generate()
+	if err != nil {
+		return "", err
+	}
+	key := apiKeyPrefix + plain
+	if err := s.repo.AddAPIKey(tenantID, token.Hash(key)); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Authenticate returns the tenant apiKey belongs to.
+func (s *TenantService) Authenticate(apiKey string) (*domain.Tenant, error) {
+	if apiKey == "" {
+		return nil, errors.ErrInvalidAPIKey
+	}
+	tenant, err := s.repo.GetByAPIKey(token.Hash(apiKey))
+	if err != nil {
+		return nil, err
+	}
+	if tenant == nil {
+		return nil, errors.ErrInvalidAPIKey
+	}
+	return tenant, nil
+}
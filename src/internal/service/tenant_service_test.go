@@ -0,0 +1,93 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+	"users-api/src/internal/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTenantRepository struct {
+	mock.Mock
+}
+
+func (m *MockTenantRepository) Create(tenant *domain.Tenant) error {
+	args := m.Called(tenant)
+	return args.Error(0)
+}
+
+func (m *MockTenantRepository) GetByID(id int64) (*domain.Tenant, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepository) AddAPIKey(tenantID int64, keyHash string) error {
+	args := m.Called(tenantID, keyHash)
+	return args.Error(0)
+}
+
+func (m *MockTenantRepository) GetByAPIKey(keyHash string) (*domain.Tenant, error) {
+	args := m.Called(keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Tenant), args.Error(1)
+}
+
+func TestCreateTenant(t *testing.T) {
+	repo := new(MockTenantRepository)
+	tenants := NewTenantService(repo)
+
+	_, _, err := tenants.CreateTenant("  ")
+	assert.Equal(t, errors.ErrInvalidInput, err)
+
+	repo.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*domain.Tenant).ID = 2
+	}).Return(nil)
+	repo.On("GetByID", int64(2)).Return(&domain.Tenant{ID: 2, Name: "Acme"}, nil)
+	var storedHash string
+	repo.On("AddAPIKey", int64(2), mock.Anything).Run(func(args mock.Arguments) {
+		storedHash = args.String(1)
+	}).Return(nil)
+
+	tenant, key, err := tenants.CreateTenant(" Acme ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Acme", tenant.Name)
+	assert.True(t, strings.HasPrefix(key, apiKeyPrefix))
+	// Only the hash of the key is stored.
+	assert.Equal(t, token.Hash(key), storedHash)
+}
+
+func TestIssueAPIKeyUnknownTenant(t *testing.T) {
+	repo := new(MockTenantRepository)
+	repo.On("GetByID", int64(9)).Return(nil, nil)
+
+	_, err := NewTenantService(repo).IssueAPIKey(9)
+	assert.Equal(t, errors.ErrTenantNotFound, err)
+	repo.AssertNotCalled(t, "AddAPIKey", mock.Anything, mock.Anything)
+}
+
+func TestAuthenticate(t *testing.T) {
+	repo := new(MockTenantRepository)
+	tenants := NewTenantService(repo)
+
+	repo.On("GetByAPIKey", token.Hash("uak_good")).Return(&domain.Tenant{ID: 2, Name: "Acme"}, nil)
+	repo.On("GetByAPIKey", token.Hash("uak_bad")).Return(nil, nil)
+
+	tenant, err := tenants.Authenticate("uak_good")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), tenant.ID)
+
+	_, err = tenants.Authenticate("uak_bad")
+	assert.Equal(t, errors.ErrInvalidAPIKey, err)
+
+	_, err = tenants.Authenticate("")
+	assert.Equal(t, errors.ErrInvalidAPIKey, err)
+}
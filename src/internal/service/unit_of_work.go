@@ -0,0 +1,120 @@
+package service
+
+import (
+	"log"
+
+	"users-api/src/internal/domain"
+)
+
+// unitOfWork carries the repositories a user write goes through and the
+// events it produces. Inside a transaction each event is appended to the
+// change log and queued for webhook delivery atomically with the write, so a
+// failure to record it rolls the write back. Subscribers and other side
+// effects only see the change once it is committed.
+type unitOfWork struct {
+	users    domain.UserRepository
+	events   domain.UserEventRepository
+	webhooks domain.WebhookDeliveryRepository
+	outbox   domain.UserEventOutbox
+	tokens   domain.EmailVerificationRepository
+	inTx     bool
+
+	recorded    []domain.UserEvent
+	afterCommit []func()
+}
+
+// write runs fn in a transaction when a TxManager is configured. Without one
+// the write goes straight to the repository and the events are recorded on a
+// best-effort basis.
+func (s *UserService) write(fn func(u *unitOfWork) error) error {
+	if s.tx == nil {
+		u := &unitOfWork{users: s.repo, events: s.events.log, webhooks: s.webhooks, outbox: s.outbox}
+		if s.verification != nil {
+			u.tokens = s.verification.tokens
+		}
+		if err := fn(u); err != nil {
+			return err
+		}
+		s.commit(u)
+		return nil
+	}
+
+	var u *unitOfWork
+	err := s.tx.WithinTx(func(tx domain.Tx) error {
+		u = &unitOfWork{users: tx.Users(), inTx: true}
+		if s.events.log != nil {
+			u.events = tx.Events()
+		}
+		if s.webhooks != nil {
+			u.webhooks = tx.Webhooks()
+		}
+		if s.outbox != nil {
+			u.outbox = tx.Outbox()
+		}
+		if s.verification != nil {
+			u.tokens = tx.EmailVerifications()
+		}
+		return fn(u)
+	})
+	if err != nil {
+		return err
+	}
+	s.commit(u)
+	return nil
+}
+
+func (s *UserService) commit(u *unitOfWork) {
+	for _, fn := range u.afterCommit {
+		fn()
+	}
+	for _, event := range u.recorded {
+		s.broadcast(event)
+	}
+}
+
+func (u *unitOfWork) onCommit(fn func()) {
+	u.afterCommit = append(u.afterCommit, fn)
+}
+
+func (s *UserService) record(u *unitOfWork, eventType string, userID int64, user *domain.User) error {
+	event := domain.UserEvent{
+		Type:       eventType,
+		UserID:     userID,
+		OccurredAt: s.now().UTC(),
+	}
+	if user != nil {
+		snapshot := *user
+		event.User = &snapshot
+	}
+
+	if err := u.persist(&event); err != nil {
+		if u.inTx {
+			return err
+		}
+		log.Printf("Failed to record %s event for user %d: %v", event.Type, event.UserID, err)
+	}
+
+	u.recorded = append(u.recorded, event)
+	return nil
+}
+
+// persist appends the event to the change log and fans it out to the webhook
+// queue and the broker outbox. Both reference the logged event, so nothing is
+// queued without a log.
+func (u *unitOfWork) persist(event *domain.UserEvent) error {
+	if u.events == nil {
+		return nil
+	}
+	if err := u.events.Append(event); err != nil {
+		return err
+	}
+	if u.webhooks != nil {
+		if err := u.webhooks.Enqueue(event); err != nil {
+			return err
+		}
+	}
+	if u.outbox != nil {
+		return u.outbox.Add(event)
+	}
+	return nil
+}
@@ -1,8 +1,12 @@
 package service
 
 import (
-	"regexp"
+	"database/sql"
+	"strings"
+	"time"
+	"unicode/utf8"
 	"users-api/src/internal/domain"
+	"users-api/src/internal/emailaddr"
 	"users-api/src/internal/errors"
 )
 
@@ -11,28 +15,126 @@ var (
 	ErrInvalidInput = errors.ErrInvalidInput
 )
 
-var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-
 type UserService struct {
-	repo domain.UserRepository
+	repo         domain.UserRepository
+	tx           domain.TxManager
+	batch        BatchConfig
+	verification *emailVerification
+	events       *eventBroker
+	webhooks     domain.WebhookDeliveryRepository
+	outbox       domain.UserEventOutbox
+	gmailFolding bool
+	validators   []emailaddr.Validator
+	attributes   *AttributeService
+	now          func() time.Time
 }
 
-func NewUserService(repo domain.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo domain.UserRepository, opts ...Option) *UserService {
+	s := &UserService{
+		repo:   repo,
+		batch:  defaultBatchConfig,
+		events: newEventBroker(),
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *UserService) validateEmail(email string) bool {
-	return emailRegex.MatchString(email)
+// validateEmail runs a normalized address through the configured
+// validators.
+func (s *UserService) validateEmail(email string) error {
+	addr, err := emailaddr.Parse(email)
+	if err != nil {
+		return err
+	}
+	for _, v := range s.validators {
+		if err := v.Validate(addr); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// normalizeEmail rewrites user.Email to its normalized form and sets the
+// canonical key it must be unique under.
+func (s *UserService) normalizeEmail(user *domain.User) error {
+	normalized, err := emailaddr.Normalize(user.Email)
+	if err != nil {
+		return err
+	}
+	user.Email = normalized
+	user.EmailCanonical = emailaddr.Canonical(normalized, s.gmailFolding)
+	return nil
+}
+
+// CreateUser validates user before opening the unit of work, so that slow
+// email validators such as the MX lookup do not hold a transaction open.
 func (s *UserService) CreateUser(user *domain.User) error {
-	if user.Name == "" || user.Email == "" {
+	if err := s.validateNewUser(user); err != nil {
+		return err
+	}
+	return s.insertUser(user)
+}
+
+// insertUser stores a user validateNewUser has accepted.
+func (s *UserService) insertUser(user *domain.User) error {
+	return s.write(func(u *unitOfWork) error {
+		if err := u.users.Create(user); err != nil {
+			return err
+		}
+		u.onCommit(func() { s.requestEmailVerification(user) })
+		return s.record(u, domain.UserEventCreated, user.ID, user)
+	})
+}
+
+func (s *UserService) validateNewUser(user *domain.User) error {
+	if user.Name == "" || strings.TrimSpace(user.Email) == "" {
+		return errors.ErrInvalidInput
+	}
+	if err := s.normalizeEmail(user); err != nil {
+		return err
+	}
+	if err := s.validateEmail(user.Email); err != nil {
+		return err
+	}
+	if user.Status == "" {
+		user.Status = domain.UserStatusActive
+	}
+	if user.Status != domain.UserStatusPending && user.Status != domain.UserStatusActive {
 		return errors.ErrInvalidInput
 	}
-	if !s.validateEmail(user.Email) {
-		return errors.ErrInvalidEmail
+	user.StatusReason, user.StatusChangedAt = "", nil
+	if err := s.validateAttributes(user.Attributes, false); err != nil {
+		return err
+	}
+	if err := s.requireAttributes(user.Attributes); err != nil {
+		return err
 	}
-	return s.repo.Create(user)
+	user.EmailVerifiedAt = nil
+	return nil
+}
+
+// validateAttributes checks custom attribute values against the schema set
+// with WithAttributeSchema. Without one no attribute is defined.
+func (s *UserService) validateAttributes(values domain.Attributes, allowNull bool) error {
+	if s.attributes != nil {
+		return s.attributes.Validate(values, allowNull)
+	}
+	for name, value := range values {
+		if value != nil || !allowNull {
+			return &errors.AttributeError{Name: name, Reason: "is not defined"}
+		}
+	}
+	return nil
+}
+
+func (s *UserService) requireAttributes(values domain.Attributes) error {
+	if s.attributes == nil {
+		return nil
+	}
+	return s.attributes.CheckRequired(values)
 }
 
 func (s *UserService) GetUser(id int64) (*domain.User, error) {
@@ -46,35 +148,154 @@ func (s *UserService) GetUser(id int64) (*domain.User, error) {
 	return user, nil
 }
 
+// GetUserByEmail finds the user registered under address however it is
+// spelled: case, surrounding spaces and a Unicode or punycode domain make no
+// difference, and neither do Gmail dots and "+tag" suffixes when
+// WithGmailCanonicalization is set.
+func (s *UserService) GetUserByEmail(address string) (*domain.User, error) {
+	lookup := &domain.User{Email: address}
+	if err := s.normalizeEmail(lookup); err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetByEmail(lookup.EmailCanonical)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// GetUsersByIDs loads several users in one round trip. Missing IDs are
+// absent from the result rather than reported as errors.
+func (s *UserService) GetUsersByIDs(ids []int64) ([]*domain.User, error) {
+	return s.repo.GetByIDs(ids)
+}
+
 func (s *UserService) UpdateUser(user *domain.User) error {
+	if err := s.validateNewEmail(user); err != nil {
+		return err
+	}
+	return s.write(func(u *unitOfWork) error {
+		emailChanged, err := s.update(u.users, user)
+		if err != nil {
+			return err
+		}
+		if emailChanged {
+			u.onCommit(func() { s.requestEmailVerification(user) })
+		}
+		return s.record(u, domain.UserEventUpdated, user.ID, user)
+	})
+}
+
+// validateNewEmail normalizes the address an update sets and, unless it is
+// the user's current one, runs it through the validators. Updates call it
+// before opening the unit of work.
+func (s *UserService) validateNewEmail(user *domain.User) error {
+	if user.Email == "" {
+		return nil
+	}
 	if user.ID == 0 {
 		return errors.ErrInvalidInput
 	}
-
-	currentUser, err := s.repo.GetByID(user.ID)
+	if err := s.normalizeEmail(user); err != nil {
+		return err
+	}
+	current, err := s.repo.GetByID(user.ID)
 	if err != nil {
 		return err
 	}
+	if current != nil && current.Email == user.Email {
+		return nil
+	}
+	return s.validateEmail(user.Email)
+}
+
+// update applies user to the stored user. A new email must have passed
+// validateNewEmail. The stored user stays locked until the unit of work ends,
+// so concurrent attribute patches are merged one after another instead of
+// overwriting each other.
+func (s *UserService) update(repo domain.UserRepository, user *domain.User) (bool, error) {
+	if user.ID == 0 {
+		return false, errors.ErrInvalidInput
+	}
+
+	currentUser, err := repo.GetByIDForUpdate(user.ID)
+	if err != nil {
+		return false, err
+	}
 	if currentUser == nil {
-		return errors.ErrUserNotFound
+		return false, errors.ErrUserNotFound
 	}
 
 	if user.Name != "" {
 		currentUser.Name = user.Name
 	}
+	if user.Status != "" && user.Status != currentUser.Status {
+		if !validStatus(user.Status) || utf8.RuneCountInString(user.StatusReason) > maxStatusReason {
+			return false, errors.ErrInvalidInput
+		}
+		if !canTransition(currentUser.Status, user.Status) {
+			return false, errors.ErrInvalidStatusTransition
+		}
+		s.setStatus(currentUser, user.Status, user.StatusReason)
+	}
+	emailChanged := false
 	if user.Email != "" {
-		if !s.validateEmail(user.Email) {
-			return errors.ErrInvalidEmail
+		if err := s.normalizeEmail(user); err != nil {
+			return false, err
+		}
+		if user.Email != currentUser.Email {
+			currentUser.Email = user.Email
+			currentUser.EmailVerifiedAt = nil
+			emailChanged = true
+		}
+	}
+	if err := s.normalizeEmail(currentUser); err != nil {
+		return false, err
+	}
+	if user.Attributes != nil {
+		if err := s.mergeAttributes(currentUser, user.Attributes); err != nil {
+			return false, err
 		}
-		currentUser.Email = user.Email
 	}
 
-	err = s.repo.Update(currentUser)
+	err = repo.Update(currentUser)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	*user = *currentUser
+	return emailChanged, nil
+}
+
+// mergeAttributes applies patch to the attributes of user: keys with a null
+// value are removed, the rest are validated and set. Required attributes are
+// checked against the result, so a patch cannot remove one. Values the patch
+// leaves alone are not revalidated.
+func (s *UserService) mergeAttributes(user *domain.User, patch domain.Attributes) error {
+	if err := s.validateAttributes(patch, true); err != nil {
+		return err
+	}
+	merged := make(domain.Attributes, len(user.Attributes)+len(patch))
+	for name, value := range user.Attributes {
+		merged[name] = value
+	}
+	for name, value := range patch {
+		if value == nil {
+			delete(merged, name)
+		} else {
+			merged[name] = value
+		}
+	}
+	if err := s.requireAttributes(merged); err != nil {
+		return err
+	}
+	if len(merged) == 0 {
+		merged = nil
+	}
+	user.Attributes = merged
 	return nil
 }
 
@@ -82,5 +303,13 @@ func (s *UserService) DeleteUser(id int64) error {
 	if id == 0 {
 		return errors.ErrInvalidInput
 	}
-	return s.repo.Delete(id)
+	return s.write(func(u *unitOfWork) error {
+		if err := u.users.Delete(id); err != nil {
+			if err == sql.ErrNoRows {
+				return errors.ErrUserNotFound
+			}
+			return err
+		}
+		return s.record(u, domain.UserEventDeleted, id, nil)
+	})
 }
@@ -3,6 +3,8 @@ package service
 import (
 	"testing"
 	"users-api/src/internal/domain"
+	"users-api/src/internal/emailaddr"
+	"users-api/src/internal/errors"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -10,6 +12,7 @@ import (
 
 type MockUserRepository struct {
 	mock.Mock
+	locked []int64
 }
 
 func (m *MockUserRepository) Create(user *domain.User) error {
@@ -25,6 +28,36 @@ func (m *MockUserRepository) GetByID(id int64) (*domain.User, error) {
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+// GetByIDForUpdate records the lock; tests set up GetByID.
+func (m *MockUserRepository) GetByIDForUpdate(id int64) (*domain.User, error) {
+	m.locked = append(m.locked, id)
+	return m.GetByID(id)
+}
+
+func (m *MockUserRepository) GetByEmail(email string) (*domain.User, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByIDs(ids []int64) ([]*domain.User, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) List(filter domain.UserFilter) ([]*domain.User, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
 func (m *MockUserRepository) Update(user *domain.User) error {
 	args := m.Called(user)
 	return args.Error(0)
@@ -64,6 +97,102 @@ func TestCreateUser(t *testing.T) {
 	})
 }
 
+func TestCreateUserNormalizesEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, WithGmailCanonicalization())
+
+	mockRepo.On("Create", mock.MatchedBy(func(user *domain.User) bool {
+		return user.Email == "j.doe+news@gmail.com" && user.EmailCanonical == "jdoe@gmail.com"
+	})).Return(nil)
+
+	user := &domain.User{Name: "John Doe", Email: "  J.Doe+News@GMail.com "}
+	assert.NoError(t, service.CreateUser(user))
+	assert.Equal(t, "j.doe+news@gmail.com", user.Email)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestEmailValidators(t *testing.T) {
+	deny, _ := emailaddr.NewDomainList([]string{"mailinator.com"})
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, WithEmailValidators(emailaddr.DomainPolicy(nil, deny)))
+
+	err := service.CreateUser(&domain.User{Name: "Spam", Email: "spam@Mailinator.com"})
+	assert.Equal(t, errors.ErrEmailRejected, err)
+
+	err = service.CreateUser(&domain.User{Name: "Bad", Email: "a..b@example.com"})
+	assert.Equal(t, errors.ErrInvalidEmail, err)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+
+	mockRepo.On("GetByID", int64(1)).Return(&domain.User{ID: 1, Name: "Old", Email: "old@mailinator.com"}, nil)
+	mockRepo.On("Update", mock.Anything).Return(nil)
+
+	err = service.UpdateUser(&domain.User{ID: 1, Email: "new@mailinator.com"})
+	assert.Equal(t, errors.ErrEmailRejected, err)
+
+	// Existing addresses are not re-vetted when other fields change.
+	err = service.UpdateUser(&domain.User{ID: 1, Name: "New"})
+	assert.NoError(t, err)
+}
+
+// txCheck is an email validator recording whether it ran inside a
+// transaction.
+type txCheck struct {
+	tm    *fakeTxManager
+	calls int
+	inTx  bool
+}
+
+func (c *txCheck) Validate(emailaddr.Address) error {
+	c.calls++
+	c.inTx = c.inTx || c.tm.active
+	return nil
+}
+
+func TestEmailValidatorsRunOutsideTx(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	txRepo := new(MockUserRepository)
+	tm := &fakeTxManager{tx: &fakeTx{users: txRepo}}
+	check := &txCheck{tm: tm}
+	service := NewUserService(mockRepo, WithTxManager(tm), WithEmailValidators(check))
+
+	current := &domain.User{ID: 1, Name: "Old", Email: "old@example.com"}
+	mockRepo.On("GetByID", int64(1)).Return(current, nil)
+	txRepo.On("GetByID", int64(1)).Return(current, nil)
+	txRepo.On("Create", mock.Anything).Return(nil)
+	txRepo.On("Update", mock.Anything).Return(nil)
+
+	assert.NoError(t, service.CreateUser(&domain.User{Name: "John", Email: "john@example.com"}))
+	assert.NoError(t, service.UpdateUser(&domain.User{ID: 1, Email: "new@example.com"}))
+	_, err := service.Batch([]domain.BatchOperation{
+		{Op: domain.BatchOpCreate, User: &domain.User{Name: "Jane", Email: "jane@example.com"}},
+		{Op: domain.BatchOpCreate, User: &domain.User{Name: "Jim", Email: "jim@example.com"}},
+	}, true)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 4, check.calls)
+	assert.False(t, check.inTx)
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo)
+
+	expectedUser := &domain.User{ID: 1, Name: "John Doe", Email: "john@xn--bcher-kva.de"}
+	mockRepo.On("GetByEmail", "john@xn--bcher-kva.de").Return(expectedUser, nil)
+	mockRepo.On("GetByEmail", "nobody@example.com").Return(nil, nil)
+
+	user, err := service.GetUserByEmail(" John@Bücher.de")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedUser, user)
+
+	_, err = service.GetUserByEmail("nobody@example.com")
+	assert.Equal(t, ErrUserNotFound, err)
+
+	_, err = service.GetUserByEmail("not-an-email")
+	assert.Equal(t, errors.ErrInvalidEmail, err)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestGetUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	service := NewUserService(mockRepo)
@@ -105,6 +234,11 @@ func TestUpdateUser(t *testing.T) {
 			Email: "john.updated@example.com",
 		}
 
+		mockRepo.On("GetByID", int64(1)).Return(&domain.User{
+			ID:    1,
+			Name:  "John Doe",
+			Email: "john@example.com",
+		}, nil)
 		mockRepo.On("Update", user).Return(nil)
 
 		err := service.UpdateUser(user)
@@ -114,7 +248,7 @@ func TestUpdateUser(t *testing.T) {
 
 	t.Run("invalid user data", func(t *testing.T) {
 		user := &domain.User{
-			ID:    1,
+			ID:    0,
 			Name:  "", // пустое имя
 			Email: "john@example.com",
 		}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"database/sql"
+	"net/url"
+	"time"
+
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+	"users-api/src/internal/token"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+
+	defaultDeliveriesPageSize = 50
+	maxDeliveriesPageSize     = 1000
+)
+
+var webhookEventTypes = map[string]bool{
+	domain.UserEventCreated: true,
+	domain.UserEventUpdated: true,
+	domain.UserEventDeleted: true,
+}
+
+type WebhookService struct {
+	subscriptions domain.WebhookSubscriptionRepository
+	deliveries    domain.WebhookDeliveryRepository
+	now           func() time.Time
+}
+
+func NewWebhookService(subscriptions domain.WebhookSubscriptionRepository, deliveries domain.WebhookDeliveryRepository) *WebhookService {
+	return &WebhookService{
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+		now:           time.Now,
+	}
+}
+
+// CreateSubscription registers an active subscription with a freshly
+// generated signing secret. The secret is only ever returned here.
+func (s *WebhookService) CreateSubscription(sub *domain.WebhookSubscription) error {
+	if err := validateSubscription(sub); err != nil {
+		return err
+	}
+
+	secret, _, err := token.Generate()
+	if err != nil {
+		return err
+	}
+	sub.Secret = webhookSecretPrefix + secret
+	sub.Active = true
+
+	return s.subscriptions.Create(sub)
+}
+
+func (s *WebhookService) GetSubscription(id int64) (*domain.WebhookSubscription, error) {
+	sub, err := s.subscriptions.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, errors.ErrWebhookNotFound
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+func (s *WebhookService) ListSubscriptions() ([]*domain.WebhookSubscription, error) {
+	subs, err := s.subscriptions.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+	return subs, nil
+}
+
+// UpdateSubscription replaces the URL, event types and active flag of an
+// existing subscription. Its secret is left unchanged.
+func (s *WebhookService) UpdateSubscription(sub *domain.WebhookSubscription) error {
+	if sub.ID == 0 {
+		return errors.ErrInvalidInput
+	}
+	if err := validateSubscription(sub); err != nil {
+		return err
+	}
+
+	current, err := s.subscriptions.GetByID(sub.ID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return errors.ErrWebhookNotFound
+	}
+
+	current.URL = sub.URL
+	current.EventTypes = sub.EventTypes
+	current.Active = sub.Active
+	if err := s.subscriptions.Update(current); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrWebhookNotFound
+		}
+		return err
+	}
+
+	*sub = *current
+	sub.Secret = ""
+	return nil
+}
+
+func (s *WebhookService) DeleteSubscription(id int64) error {
+	if id == 0 {
+		return errors.ErrInvalidInput
+	}
+	if err := s.subscriptions.Delete(id); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrWebhookNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListDeliveries returns the delivery log, newest first.
+func (s *WebhookService) ListDeliveries(filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	switch filter.Status {
+	case "", domain.WebhookDeliveryPending, domain.WebhookDeliverySucceeded, domain.WebhookDeliveryDead:
+	default:
+		return nil, errors.ErrInvalidInput
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultDeliveriesPageSize
+	}
+	if filter.Limit > maxDeliveriesPageSize {
+		filter.Limit = maxDeliveriesPageSize
+	}
+	return s.deliveries.List(filter)
+}
+
+// RetryDelivery puts a dead-lettered delivery back in the queue with a fresh
+// attempt budget.
+func (s *WebhookService) RetryDelivery(id int64) error {
+	if id == 0 {
+		return errors.ErrInvalidInput
+	}
+	requeued, err := s.deliveries.Requeue(id, s.now())
+	if err != nil {
+		return err
+	}
+	if !requeued {
+		return errors.ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+func validateSubscription(sub *domain.WebhookSubscription) error {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.ErrInvalidWebhookURL
+	}
+	if sub.EventTypes == nil {
+		sub.EventTypes = []string{}
+	}
+	for _, eventType := range sub.EventTypes {
+		if !webhookEventTypes[eventType] {
+			return errors.ErrInvalidInput
+		}
+	}
+	return nil
+}
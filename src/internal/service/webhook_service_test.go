@@ -0,0 +1,134 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookSubscriptionRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookSubscriptionRepository) Create(sub *domain.WebhookSubscription) error {
+	args := m.Called(sub)
+	return args.Error(0)
+}
+
+func (m *MockWebhookSubscriptionRepository) GetByID(id int64) (*domain.WebhookSubscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookSubscriptionRepository) List() ([]*domain.WebhookSubscription, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookSubscriptionRepository) Update(sub *domain.WebhookSubscription) error {
+	args := m.Called(sub)
+	return args.Error(0)
+}
+
+func (m *MockWebhookSubscriptionRepository) Delete(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+type MockWebhookDeliveryRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookDeliveryRepository) Enqueue(event *domain.UserEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) Claim(now, leaseUntil time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	args := m.Called(now, leaseUntil, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) Complete(id int64, claimToken string, attempt domain.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	args := m.Called(id, claimToken, attempt, status, nextAttemptAt)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) List(filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) Requeue(id int64, at time.Time) (bool, error) {
+	args := m.Called(id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestCreateSubscription(t *testing.T) {
+	subs := new(MockWebhookSubscriptionRepository)
+	service := NewWebhookService(subs, new(MockWebhookDeliveryRepository))
+
+	t.Run("generates a secret", func(t *testing.T) {
+		sub := &domain.WebhookSubscription{URL: "https://example.com/hook", EventTypes: []string{domain.UserEventCreated}}
+		subs.On("Create", sub).Return(nil).Once()
+
+		assert.NoError(t, service.CreateSubscription(sub))
+		assert.True(t, strings.HasPrefix(sub.Secret, webhookSecretPrefix))
+		assert.True(t, sub.Active)
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		err := service.CreateSubscription(&domain.WebhookSubscription{URL: "ftp://example.com"})
+		assert.Equal(t, errors.ErrInvalidWebhookURL, err)
+	})
+
+	t.Run("unknown event type", func(t *testing.T) {
+		err := service.CreateSubscription(&domain.WebhookSubscription{URL: "https://example.com/hook", EventTypes: []string{"user.renamed"}})
+		assert.Equal(t, errors.ErrInvalidInput, err)
+	})
+}
+
+func TestGetSubscriptionHidesSecret(t *testing.T) {
+	subs := new(MockWebhookSubscriptionRepository)
+	service := NewWebhookService(subs, new(MockWebhookDeliveryRepository))
+
+	subs.On("GetByID", int64(1)).Return(&domain.WebhookSubscription{ID: 1, Secret: "whsec_x"}, nil)
+	subs.On("GetByID", int64(2)).Return(nil, nil)
+
+	sub, err := service.GetSubscription(1)
+	assert.NoError(t, err)
+	assert.Empty(t, sub.Secret)
+
+	_, err = service.GetSubscription(2)
+	assert.Equal(t, errors.ErrWebhookNotFound, err)
+}
+
+func TestRetryDelivery(t *testing.T) {
+	deliveries := new(MockWebhookDeliveryRepository)
+	service := NewWebhookService(new(MockWebhookSubscriptionRepository), deliveries)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	deliveries.On("Requeue", int64(1), now).Return(true, nil)
+	deliveries.On("Requeue", int64(2), now).Return(false, nil)
+
+	assert.NoError(t, service.RetryDelivery(1))
+	assert.Equal(t, errors.ErrWebhookDeliveryNotFound, service.RetryDelivery(2))
+}
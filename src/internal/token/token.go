@@ -0,0 +1,27 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const size = 32
+
+// Generate returns a random URL-safe token together with the hash that
+// should be persisted instead of the token itself.
+func Generate() (string, string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("error generating token: %w", err)
+	}
+	plain := base64.RawURLEncoding.EncodeToString(buf)
+	return plain, Hash(plain), nil
+}
+
+func Hash(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,71 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHOTPMatchesRFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, c := range cases {
+		step := Step(time.Unix(c.unix, 0))
+		assert.Equal(t, c.code, hotp(key, uint64(step), 8))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+
+	t.Run("current code", func(t *testing.T) {
+		code, err := Code(secret, Step(now))
+		assert.NoError(t, err)
+
+		step, ok := Validate(secret, code, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, Step(now), step)
+	})
+
+	t.Run("previous step within skew", func(t *testing.T) {
+		code, err := Code(secret, Step(now)-1)
+		assert.NoError(t, err)
+
+		_, ok := Validate(secret, code, now, 1)
+		assert.True(t, ok)
+	})
+
+	t.Run("outside skew", func(t *testing.T) {
+		code, err := Code(secret, Step(now)-5)
+		assert.NoError(t, err)
+
+		_, ok := Validate(secret, code, now, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("malformed code", func(t *testing.T) {
+		_, ok := Validate(secret, "12", now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("users-api", "john@example.com", "JBSWY3DPEHPK3PXP")
+	assert.Equal(t, "otpauth://totp/users-api:john@example.com?algorithm=SHA1&digits=6&issuer=users-api&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
@@ -0,0 +1,182 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"users-api/src/internal/domain"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Id"
+
+	batchSize = 50
+)
+
+type Config struct {
+	// MaxAttempts is the number of attempts after which a delivery is moved
+	// to the dead letter state.
+	MaxAttempts  int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	Timeout      time.Duration
+	PollInterval time.Duration
+}
+
+// Dispatcher sends queued webhook deliveries. Several dispatchers may share
+// one queue: deliveries are leased while being sent, so each attempt is made
+// by exactly one of them.
+type Dispatcher struct {
+	deliveries domain.WebhookDeliveryRepository
+	client     *http.Client
+	cfg        Config
+	now        func() time.Time
+	jitter     func(d time.Duration) time.Duration
+}
+
+func NewDispatcher(deliveries domain.WebhookDeliveryRepository, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		deliveries: deliveries,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		cfg:        cfg,
+		now:        time.Now,
+		jitter: func(d time.Duration) time.Duration {
+			return time.Duration(rand.Int63n(int64(d)/2 + 1))
+		},
+	}
+}
+
+// Run dispatches due deliveries every PollInterval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := d.DispatchDue()
+			if err != nil {
+				log.Printf("Failed to dispatch webhooks: %v", err)
+			}
+			if err != nil || n < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue attempts up to batchSize due deliveries and returns how many
+// were claimed. Deliveries are claimed one at a time, right before they are
+// sent, so that a lease only has to outlast a single attempt.
+func (d *Dispatcher) DispatchDue() (int, error) {
+	for n := 0; n < batchSize; n++ {
+		now := d.now()
+		deliveries, err := d.deliveries.Claim(now, now.Add(2*d.cfg.Timeout), 1)
+		if err != nil {
+			return n, err
+		}
+		if len(deliveries) == 0 {
+			return n, nil
+		}
+
+		if err := d.attempt(deliveries[0]); err != nil {
+			log.Printf("Failed to record webhook delivery %d: %v", deliveries[0].ID, err)
+		}
+	}
+	return batchSize, nil
+}
+
+func (d *Dispatcher) attempt(delivery *domain.WebhookDelivery) error {
+	attempt := d.send(delivery)
+	attempts := delivery.Attempts + 1
+
+	switch {
+	case attempt.Error == "":
+		return d.deliveries.Complete(delivery.ID, delivery.ClaimToken, attempt, domain.WebhookDeliverySucceeded, time.Time{})
+	case attempts >= d.cfg.MaxAttempts:
+		return d.deliveries.Complete(delivery.ID, delivery.ClaimToken, attempt, domain.WebhookDeliveryDead, time.Time{})
+	default:
+		next := attempt.AttemptedAt.Add(d.backoff(attempts))
+		return d.deliveries.Complete(delivery.ID, delivery.ClaimToken, attempt, domain.WebhookDeliveryPending, next)
+	}
+}
+
+// backoff doubles the delay with every failed attempt up to MaxDelay and adds
+// up to 50% jitter so that retries of a burst of failures spread out.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.BaseDelay
+	for i := 1; i < attempts && delay < d.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.cfg.MaxDelay {
+		delay = d.cfg.MaxDelay
+	}
+	return delay + d.jitter(delay)
+}
+
+func (d *Dispatcher) send(delivery *domain.WebhookDelivery) domain.WebhookAttempt {
+	attempt := domain.WebhookAttempt{AttemptedAt: d.now().UTC()}
+
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := attempt.AttemptedAt.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "users-api-webhooks")
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = &resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// Sign returns the signature header value for body: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription secret. Receivers should
+// recompute it and reject stale timestamps to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"users-api/src/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type completion struct {
+	attempt domain.WebhookAttempt
+	status  string
+	next    time.Time
+}
+
+type fakeQueue struct {
+	mu        sync.Mutex
+	due       []*domain.WebhookDelivery
+	leases    []time.Time
+	completed map[int64]completion
+}
+
+func (q *fakeQueue) Enqueue(event *domain.UserEvent) error {
+	return nil
+}
+
+func (q *fakeQueue) Claim(now, leaseUntil time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if limit > len(q.due) {
+		limit = len(q.due)
+	}
+	claimed := q.due[:limit]
+	q.due = q.due[limit:]
+	for _, d := range claimed {
+		d.ClaimToken = "token-" + strconv.FormatInt(d.ID, 10)
+		q.leases = append(q.leases, leaseUntil)
+	}
+	return claimed, nil
+}
+
+func (q *fakeQueue) Complete(id int64, claimToken string, attempt domain.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if claimToken != "token-"+strconv.FormatInt(id, 10) {
+		return sql.ErrNoRows
+	}
+	q.completed[id] = completion{attempt, status, nextAttemptAt}
+	return nil
+}
+
+func (q *fakeQueue) List(filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (q *fakeQueue) Requeue(id int64, at time.Time) (bool, error) {
+	return false, nil
+}
+
+func newTestDispatcher(queue *fakeQueue, now time.Time) *Dispatcher {
+	d := NewDispatcher(queue, Config{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    10 * time.Minute,
+		Timeout:     time.Second,
+	})
+	d.now = func() time.Time { return now }
+	d.jitter = func(time.Duration) time.Duration { return 0 }
+	return d
+}
+
+func delivery(id int64, url string, attempts int) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:        id,
+		EventType: domain.UserEventCreated,
+		Attempts:  attempts,
+		URL:       url,
+		Secret:    "whsec_test",
+		Event:     &domain.UserEvent{ID: 7, Type: domain.UserEventCreated, UserID: 1},
+	}
+}
+
+func TestDispatchSignsRequests(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	now := time.Unix(1700000000, 0)
+	queue := &fakeQueue{due: []*domain.WebhookDelivery{delivery(1, server.URL, 0)}, completed: map[int64]completion{}}
+
+	n, err := newTestDispatcher(queue, now).DispatchDue()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.Equal(t, domain.WebhookDeliverySucceeded, queue.completed[1].status)
+	assert.Equal(t, 200, *queue.completed[1].attempt.StatusCode)
+	assert.Equal(t, "1", header.Get(DeliveryHeader))
+	assert.Equal(t, domain.UserEventCreated, header.Get(EventHeader))
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), header.Get(TimestampHeader))
+	assert.Equal(t, Sign("whsec_test", now.Unix(), body), header.Get(SignatureHeader))
+	assert.JSONEq(t, `{"id":7,"type":"user.created","user_id":1,"occurred_at":"0001-01-01T00:00:00Z"}`, string(body))
+}
+
+func TestDispatchRetriesAndDeadLetters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	now := time.Now().UTC()
+	queue := &fakeQueue{
+		due: []*domain.WebhookDelivery{
+			delivery(1, server.URL, 0),
+			delivery(2, server.URL, 1),
+			delivery(3, server.URL, 2),
+		},
+		completed: map[int64]completion{},
+	}
+
+	n, err := newTestDispatcher(queue, now).DispatchDue()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// Each delivery is leased on its own for the length of one attempt.
+	assert.Equal(t, []time.Time{now.Add(2 * time.Second), now.Add(2 * time.Second), now.Add(2 * time.Second)}, queue.leases)
+
+	assert.Equal(t, domain.WebhookDeliveryPending, queue.completed[1].status)
+	assert.Equal(t, now.Add(time.Minute), queue.completed[1].next)
+	assert.Equal(t, domain.WebhookDeliveryPending, queue.completed[2].status)
+	assert.Equal(t, now.Add(2*time.Minute), queue.completed[2].next)
+	assert.Equal(t, domain.WebhookDeliveryDead, queue.completed[3].status)
+	assert.Equal(t, "unexpected status 503", queue.completed[3].attempt.Error)
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	d := newTestDispatcher(&fakeQueue{}, time.Now())
+	assert.Equal(t, 10*time.Minute, d.backoff(50))
+}
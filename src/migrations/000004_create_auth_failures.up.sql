@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS auth_failures (
+    scope VARCHAR(16) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP NULL,
+    PRIMARY KEY (scope, subject)
+);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS user_event_outbox (
+    event_id BIGINT PRIMARY KEY REFERENCES user_events(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,32 @@
+-- UNIQUE(email) is case-sensitive, so the table may hold addresses that
+-- differ only in case. They would share a canonical address and break the
+-- unique index below; stop with the list instead, to be resolved by hand.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(canonical || ' (ids ' || ids || ')', ', ')
+    INTO duplicates
+    FROM (
+        SELECT LOWER(BTRIM(email)) AS canonical, string_agg(id::TEXT, ', ' ORDER BY id) AS ids
+        FROM users
+        GROUP BY LOWER(BTRIM(email))
+        HAVING COUNT(*) > 1
+    ) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users with emails differing only in case: %', duplicates
+            USING HINT = 'Change or delete all but one user of each address, run migrate force 10 and restart.';
+    END IF;
+END
+$$;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_canonical VARCHAR(255);
+
+-- Existing rows get the case-folded address. Gmail canonicalization, when
+-- enabled, applies from the next write of each user.
+UPDATE users SET email_canonical = LOWER(BTRIM(email)) WHERE email_canonical IS NULL;
+
+ALTER TABLE users ALTER COLUMN email_canonical SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_canonical ON users(email_canonical);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS user_attribute_definitions (
+    name VARCHAR(63) PRIMARY KEY,
+    type VARCHAR(16) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    pattern TEXT NOT NULL DEFAULT '',
+    enum TEXT[] NOT NULL DEFAULT '{}',
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_users_attributes ON users USING GIN (attributes jsonb_path_ops);
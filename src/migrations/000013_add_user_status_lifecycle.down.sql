@@ -0,0 +1,5 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_status_check;
+UPDATE users SET status = 'deactivated' WHERE status = 'suspended';
+UPDATE users SET status = 'active' WHERE status = 'pending';
+ALTER TABLE users DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE users DROP COLUMN IF EXISTS status_reason;
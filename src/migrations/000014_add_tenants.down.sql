@@ -0,0 +1,24 @@
+DROP POLICY IF EXISTS user_events_tenant_isolation ON user_events;
+ALTER TABLE user_events NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE user_events DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS users_tenant_isolation ON users;
+ALTER TABLE users NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+
+DELETE FROM idempotency_keys WHERE LENGTH(key) > 255;
+ALTER TABLE idempotency_keys ALTER COLUMN key TYPE VARCHAR(255);
+
+DROP INDEX IF EXISTS idx_user_events_tenant_id;
+DROP INDEX IF EXISTS idx_user_events_tenant_user_id;
+CREATE INDEX IF NOT EXISTS idx_user_events_user_id ON user_events(user_id, id);
+
+-- Fails if two tenants share an email; merge or delete those users first.
+DROP INDEX IF EXISTS idx_users_tenant_email_canonical;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_canonical ON users(email_canonical);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+ALTER TABLE user_events DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenant_api_keys;
+DROP TABLE IF EXISTS tenants;
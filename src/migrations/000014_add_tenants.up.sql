@@ -0,0 +1,50 @@
+CREATE TABLE IF NOT EXISTS tenants (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Existing users and events belong to the default tenant.
+INSERT INTO tenants (id, name) VALUES (1, 'default') ON CONFLICT (id) DO NOTHING;
+SELECT setval('tenants_id_seq', GREATEST((SELECT MAX(id) FROM tenants), 1));
+
+CREATE TABLE IF NOT EXISTS tenant_api_keys (
+    key_hash CHAR(64) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_api_keys_tenant_id ON tenant_api_keys(tenant_id);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id BIGINT NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE user_events ADD COLUMN IF NOT EXISTS tenant_id BIGINT NOT NULL DEFAULT 1;
+
+-- Emails are unique within a tenant, not across the deployment.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+DROP INDEX IF EXISTS idx_users_email_canonical;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_email_canonical ON users(tenant_id, email_canonical);
+
+-- Idempotency keys are stored as "<tenant id>:<key>".
+ALTER TABLE idempotency_keys ALTER COLUMN key TYPE VARCHAR(300);
+
+DROP INDEX IF EXISTS idx_user_events_user_id;
+CREATE INDEX IF NOT EXISTS idx_user_events_tenant_user_id ON user_events(tenant_id, user_id, id);
+CREATE INDEX IF NOT EXISTS idx_user_events_tenant_id ON user_events(tenant_id, id);
+
+-- Row-level security backs up the tenant_id conditions in the queries.
+-- Sessions that set app.tenant_id, which the application does with
+-- DB_ROW_LEVEL_SECURITY=true, only see and write that tenant's rows; other
+-- sessions, such as migrations and background workers, are not restricted.
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS users_tenant_isolation ON users;
+CREATE POLICY users_tenant_isolation ON users
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL OR tenant_id = current_setting('app.tenant_id', true)::BIGINT)
+    WITH CHECK (NULLIF(current_setting('app.tenant_id', true), '') IS NULL OR tenant_id = current_setting('app.tenant_id', true)::BIGINT);
+
+ALTER TABLE user_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE user_events FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS user_events_tenant_isolation ON user_events;
+CREATE POLICY user_events_tenant_isolation ON user_events
+    USING (NULLIF(current_setting('app.tenant_id', true), '') IS NULL OR tenant_id = current_setting('app.tenant_id', true)::BIGINT)
+    WITH CHECK (NULLIF(current_setting('app.tenant_id', true), '') IS NULL OR tenant_id = current_setting('app.tenant_id', true)::BIGINT);
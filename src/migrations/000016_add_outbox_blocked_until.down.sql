@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_event_outbox_user_id;
+
+ALTER TABLE user_event_outbox DROP COLUMN IF EXISTS blocked_until;
@@ -0,0 +1,5 @@
+-- Set on events that could not be published; the relay skips every event of
+-- the user until it passes.
+ALTER TABLE user_event_outbox ADD COLUMN IF NOT EXISTS blocked_until TIMESTAMP WITH TIME ZONE NULL;
+
+CREATE INDEX IF NOT EXISTS idx_user_event_outbox_user_id ON user_event_outbox(user_id);
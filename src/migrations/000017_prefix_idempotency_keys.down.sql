@@ -0,0 +1 @@
+UPDATE idempotency_keys SET key = substr(key, 3) WHERE key LIKE '1:%';
@@ -0,0 +1,7 @@
+-- Keys of the default tenant used to be stored without a prefix. Keys that
+-- start with the ID of another tenant are taken to be that tenant's.
+UPDATE idempotency_keys SET key = '1:' || key
+WHERE NOT EXISTS (
+    SELECT 1 FROM tenants t
+    WHERE t.id <> 1 AND idempotency_keys.key LIKE t.id || ':%'
+);
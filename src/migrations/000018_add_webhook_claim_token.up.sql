@@ -0,0 +1,3 @@
+-- Set whenever a delivery is claimed; an attempt is recorded only under the
+-- token of the claim it was made under.
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS claim_token UUID NULL;
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"users-api/src/internal/domain"
+)
+
+type AttributeDefinition = domain.AttributeDefinition
+
+const (
+	AttributeTypeString  = domain.AttributeTypeString
+	AttributeTypeNumber  = domain.AttributeTypeNumber
+	AttributeTypeInteger = domain.AttributeTypeInteger
+	AttributeTypeBoolean = domain.AttributeTypeBoolean
+)
+
+// CreateAttributeDefinition declares a custom user attribute and fills in
+// its timestamps.
+func (c *Client) CreateAttributeDefinition(ctx context.Context, def *AttributeDefinition) error {
+	_, err := c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       "/users/attributes",
+		body:       definitionInput(def),
+		wantStatus: []int{http.StatusCreated},
+	}, def)
+	return err
+}
+
+func (c *Client) GetAttributeDefinition(ctx context.Context, name string) (*AttributeDefinition, error) {
+	var def AttributeDefinition
+	_, err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/users/attributes",
+		query:      url.Values{"name": {name}},
+		wantStatus: []int{http.StatusOK},
+	}, &def)
+	if err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// ListAttributeDefinitions returns every definition, ordered by name.
+func (c *Client) ListAttributeDefinitions(ctx context.Context) ([]*AttributeDefinition, error) {
+	var resp struct {
+		Attributes []*AttributeDefinition `json:"attributes"`
+	}
+	_, err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/users/attributes",
+		wantStatus: []int{http.StatusOK},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Attributes, nil
+}
+
+// UpdateAttributeDefinition replaces everything but the name of def.Name.
+func (c *Client) UpdateAttributeDefinition(ctx context.Context, def *AttributeDefinition) error {
+	_, err := c.do(ctx, request{
+		method:     http.MethodPut,
+		path:       "/users/attributes",
+		body:       definitionInput(def),
+		wantStatus: []int{http.StatusOK},
+	}, def)
+	return err
+}
+
+func (c *Client) DeleteAttributeDefinition(ctx context.Context, name string) error {
+	_, err := c.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       "/users/attributes",
+		query:      url.Values{"name": {name}},
+		wantStatus: []int{http.StatusNoContent},
+	}, nil)
+	return err
+}
+
+// definitionInput leaves out the timestamps, which the server sets.
+func definitionInput(def *AttributeDefinition) map[string]interface{} {
+	body := map[string]interface{}{
+		"name":     def.Name,
+		"type":     def.Type,
+		"required": def.Required,
+	}
+	if def.Pattern != "" {
+		body["pattern"] = def.Pattern
+	}
+	if len(def.Enum) > 0 {
+		body["enum"] = def.Enum
+	}
+	if def.Description != "" {
+		body["description"] = def.Description
+	}
+	return body
+}
@@ -0,0 +1,258 @@
+// Package client is a Go client for the users API. It follows the document
+// served at /openapi.json and returns the same sentinel errors the service
+// uses internally, so callers can compare with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"users-api/src/internal/token"
+)
+
+const (
+	userAgent = "users-api-go-client"
+
+	idempotencyKeyHeader = "Idempotency-Key"
+)
+
+// RetryPolicy controls how failed requests are retried. Requests are retried
+// on 429, on 5xx other than 501 and on transport errors, but only when they
+// are safe to repeat: GET, PUT and DELETE, and POSTs sent with an
+// Idempotency-Key.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	apiKey     string
+	retry      RetryPolicy
+	jitter     func(d time.Duration) time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey sends key in the X-API-Key header. The server acts as the
+// tenant the key belongs to and rate limits per key instead of per IP.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// New returns a client for the API at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base url: scheme must be http or https")
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		retry:      DefaultRetryPolicy,
+		jitter: func(d time.Duration) time.Duration {
+			return time.Duration(rand.Int63n(int64(d)/2 + 1))
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// request describes one API call. The body is encoded once so that it can be
+// sent again on retries.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        interface{}
+	idempotent  bool
+	wantStatus  []int
+	errorStatus []int
+}
+
+// do sends req, retrying it according to the policy, and decodes a JSON
+// response into out when out is not nil. Responses with a status in
+// req.wantStatus are decoded into out; any other status becomes an *APIError,
+// except that statuses in req.errorStatus are also decoded into out and
+// returned alongside the error.
+func (c *Client) do(ctx context.Context, req request, out interface{}) (int, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return 0, err
+		}
+	}
+
+	idempotencyKey := ""
+	if req.method == http.MethodPost && req.idempotent {
+		key, _, err := token.Generate()
+		if err != nil {
+			return 0, err
+		}
+		idempotencyKey = key
+	}
+	retryable := req.method != http.MethodPost || idempotencyKey != ""
+
+	u := *c.baseURL
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+
+	for attempt := 1; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		httpReq.Header.Set("Accept", "application/json")
+		httpReq.Header.Set("User-Agent", userAgent)
+		if body != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+		if c.apiKey != "" {
+			httpReq.Header.Set("X-API-Key", c.apiKey)
+		}
+		if idempotencyKey != "" {
+			httpReq.Header.Set(idempotencyKeyHeader, idempotencyKey)
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			if ctx.Err() != nil || !retryable || attempt >= c.retry.MaxAttempts {
+				return 0, err
+			}
+			if err := c.wait(ctx, c.backoff(attempt)); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if retryable && attempt < c.retry.MaxAttempts && shouldRetry(resp.StatusCode) {
+			delay := c.backoff(attempt)
+			if after, ok := retryAfter(resp); ok {
+				delay = min(after, c.retry.MaxDelay)
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if err := c.wait(ctx, delay); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		return resp.StatusCode, decodeResponse(resp, req, out)
+	}
+}
+
+func decodeResponse(resp *http.Response, req request, out interface{}) error {
+	defer resp.Body.Close()
+
+	if containsStatus(req.wantStatus, resp.StatusCode) {
+		if out == nil || resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if containsStatus(req.errorStatus, resp.StatusCode) && out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return newAPIError(resp, data)
+}
+
+func shouldRetry(status int) bool {
+	return status == http.StatusTooManyRequests || (status >= 500 && status != http.StatusNotImplemented)
+}
+
+// retryAfter reads a Retry-After header given in seconds.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// backoff doubles BaseDelay after every attempt up to MaxDelay and adds up to
+// 50% jitter.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retry.BaseDelay
+	for i := 1; i < attempt && delay < c.retry.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > c.retry.MaxDelay {
+		delay = c.retry.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay + c.jitter(delay)
+}
+
+func (c *Client) wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func containsStatus(statuses []int, status int) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
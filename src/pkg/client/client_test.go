@@ -0,0 +1,761 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"users-api/src/api/openapi"
+	"users-api/src/internal/delivery/handlers"
+	httpDelivery "users-api/src/internal/delivery/http"
+	"users-api/src/internal/delivery/middleware"
+	"users-api/src/internal/domain"
+	"users-api/src/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryUserRepository is just enough of a repository to run the real
+// service and handlers behind a test server.
+type memoryUserRepository struct {
+	mu     sync.Mutex
+	users  map[int64]*domain.User
+	nextID int64
+}
+
+func newMemoryUserRepository() *memoryUserRepository {
+	return &memoryUserRepository{users: make(map[int64]*domain.User)}
+}
+
+func (r *memoryUserRepository) Create(user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.EmailCanonical == user.EmailCanonical {
+			return ErrEmailTaken
+		}
+	}
+	r.nextID++
+	user.ID = r.nextID
+	user.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	user.UpdatedAt = user.CreatedAt
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *memoryUserRepository) GetByID(id int64) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[id]; ok {
+		user := *u
+		return &user, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) GetByIDForUpdate(id int64) (*domain.User, error) {
+	return r.GetByID(id)
+}
+
+func (r *memoryUserRepository) GetByEmail(canonical string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.EmailCanonical == canonical {
+			user := *u
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) GetByIDs(ids []int64) ([]*domain.User, error) {
+	var users []*domain.User
+	for _, id := range ids {
+		if u, _ := r.GetByID(id); u != nil {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+func (r *memoryUserRepository) List(filter domain.UserFilter) ([]*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users := []*domain.User{}
+	for _, u := range r.users {
+		if filter.Status != "" && u.Status != filter.Status {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, u.Status) {
+			continue
+		}
+		if filter.NameContains != "" && !strings.Contains(strings.ToLower(u.Name), strings.ToLower(filter.NameContains)) {
+			continue
+		}
+		user := *u
+		users = append(users, &user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	if filter.Offset >= len(users) {
+		return []*domain.User{}, nil
+	}
+	users = users[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(users) {
+		users = users[:filter.Limit]
+	}
+	return users, nil
+}
+
+func (r *memoryUserRepository) Update(user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *memoryUserRepository) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, id)
+	return nil
+}
+
+type memoryAttributeRepository struct {
+	mu   sync.Mutex
+	defs map[string]domain.AttributeDefinition
+}
+
+func (r *memoryAttributeRepository) Create(def *domain.AttributeDefinition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.defs[def.Name]; ok {
+		return ErrAttributeExists
+	}
+	def.CreatedAt = time.Now().UTC()
+	def.UpdatedAt = def.CreatedAt
+	r.defs[def.Name] = *def
+	return nil
+}
+
+func (r *memoryAttributeRepository) Get(name string) (*domain.AttributeDefinition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if def, ok := r.defs[name]; ok {
+		return &def, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryAttributeRepository) List() ([]*domain.AttributeDefinition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defs := []*domain.AttributeDefinition{}
+	for _, def := range r.defs {
+		def := def
+		defs = append(defs, &def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs, nil
+}
+
+func (r *memoryAttributeRepository) Update(def *domain.AttributeDefinition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.defs[def.Name]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	def.CreatedAt, def.UpdatedAt = current.CreatedAt, time.Now().UTC()
+	r.defs[def.Name] = *def
+	return nil
+}
+
+func (r *memoryAttributeRepository) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.defs[name]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.defs, name)
+	return nil
+}
+
+// memoryGroupRepository keeps the groups of the test server. Its members are
+// looked up in the user repository the service uses.
+type memoryGroupRepository struct {
+	mu      sync.Mutex
+	groups  map[int64]domain.Group
+	members map[[2]int64]time.Time
+	users   *memoryUserRepository
+	nextID  int64
+}
+
+func newMemoryGroupRepository(users *memoryUserRepository) *memoryGroupRepository {
+	return &memoryGroupRepository{groups: make(map[int64]domain.Group), members: make(map[[2]int64]time.Time), users: users}
+}
+
+func (r *memoryGroupRepository) Create(group *domain.Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, g := range r.groups {
+		if g.Name == group.Name {
+			return ErrGroupExists
+		}
+	}
+	r.nextID++
+	group.ID = r.nextID
+	group.CreatedAt = time.Now().UTC()
+	group.UpdatedAt = group.CreatedAt
+	r.groups[group.ID] = *group
+	return nil
+}
+
+func (r *memoryGroupRepository) GetByID(id int64) (*domain.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if g, ok := r.groups[id]; ok {
+		return &g, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryGroupRepository) List(filter domain.GroupFilter) ([]*domain.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	groups := []*domain.Group{}
+	for _, g := range r.groups {
+		g := g
+		groups = append(groups, &g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return page(groups, filter), nil
+}
+
+func (r *memoryGroupRepository) Update(group *domain.Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.groups[group.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	for parent := group.ParentID; parent != nil; parent = r.groups[*parent].ParentID {
+		if *parent == group.ID {
+			return ErrGroupCycle
+		}
+	}
+	group.CreatedAt, group.UpdatedAt = current.CreatedAt, time.Now().UTC()
+	r.groups[group.ID] = *group
+	return nil
+}
+
+func (r *memoryGroupRepository) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.groups[id]; !ok {
+		return sql.ErrNoRows
+	}
+	for _, g := range r.groups {
+		if g.ParentID != nil && *g.ParentID == id {
+			return ErrGroupHasSubgroups
+		}
+	}
+	delete(r.groups, id)
+	for key := range r.members {
+		if key[0] == id {
+			delete(r.members, key)
+		}
+	}
+	return nil
+}
+
+func (r *memoryGroupRepository) GetMember(groupID, userID int64) (*domain.GroupMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if createdAt, ok := r.members[[2]int64{groupID, userID}]; ok {
+		return &domain.GroupMember{GroupID: groupID, UserID: userID, Direct: true, CreatedAt: &createdAt}, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryGroupRepository) AddMember(member *domain.GroupMember) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.groups[member.GroupID]; !ok {
+		return false, sql.ErrNoRows
+	}
+	key := [2]int64{member.GroupID, member.UserID}
+	createdAt, exists := r.members[key]
+	if !exists {
+		createdAt = time.Now().UTC()
+		r.members[key] = createdAt
+	}
+	member.Direct, member.CreatedAt = true, &createdAt
+	return !exists, nil
+}
+
+func (r *memoryGroupRepository) RemoveMember(groupID, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]int64{groupID, userID}
+	if _, ok := r.members[key]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.members, key)
+	return nil
+}
+
+func (r *memoryGroupRepository) ListMembers(groupID int64, filter domain.GroupFilter) ([]*domain.User, error) {
+	r.mu.Lock()
+	var ids []int64
+	for key := range r.members {
+		if key[0] == groupID {
+			ids = append(ids, key[1])
+		}
+	}
+	r.mu.Unlock()
+
+	users, err := r.users.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return page(users, filter), nil
+}
+
+func (r *memoryGroupRepository) ListUserGroups(userID int64) ([]*domain.UserGroup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	direct := map[int64]bool{}
+	for key := range r.members {
+		if key[1] != userID {
+			continue
+		}
+		direct[key[0]] = true
+		for parent := r.groups[key[0]].ParentID; parent != nil; parent = r.groups[*parent].ParentID {
+			if _, ok := direct[*parent]; !ok {
+				direct[*parent] = false
+			}
+		}
+	}
+	groups := []*domain.UserGroup{}
+	for id, isDirect := range direct {
+		groups = append(groups, &domain.UserGroup{Group: r.groups[id], Direct: isDirect})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups, nil
+}
+
+func page[T any](items []T, filter domain.GroupFilter) []T {
+	items = items[min(filter.Offset, len(items)):]
+	if filter.Limit > 0 && filter.Limit < len(items) {
+		items = items[:filter.Limit]
+	}
+	return items
+}
+
+// newTestServer serves the real router, behind the OpenAPI validator so the
+// client is also checked against the document.
+func newTestServer(t *testing.T, wrap func(http.Handler) http.Handler) *httptest.Server {
+	attributeService := service.NewAttributeService(&memoryAttributeRepository{defs: make(map[string]domain.AttributeDefinition)})
+	users := newMemoryUserRepository()
+	userService := service.NewUserService(users, service.WithAttributeSchema(attributeService))
+	groupService := service.NewGroupService(newMemoryGroupRepository(users), users)
+	router := httpDelivery.NewRouter(
+		handlers.NewUserHandler(userService), nil, nil, nil, nil, nil,
+		handlers.NewAttributeHandler(attributeService),
+		handlers.NewGroupHandler(groupService),
+		handlers.NewDocsHandler(openapi.Spec, openapi.DocsPage),
+		http.NotFoundHandler(), http.NotFoundHandler(),
+	)
+	validator, err := middleware.NewOpenAPIValidator(openapi.Spec, true)
+	require.NoError(t, err)
+
+	var handler http.Handler = validator.Handler(router)
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestClient(t *testing.T, server *httptest.Server) *Client {
+	c, err := New(server.URL, WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}))
+	require.NoError(t, err)
+	return c
+}
+
+func TestUserOperations(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, newTestServer(t, nil))
+
+	user := &User{Name: "Ivan", Email: "ivan@example.com"}
+	require.NoError(t, c.CreateUser(ctx, user))
+	assert.NotZero(t, user.ID)
+	assert.Equal(t, UserStatusActive, user.Status)
+	assert.NotEmpty(t, user.CreatedAt)
+
+	err := c.CreateUser(ctx, &User{Name: "Other", Email: "Ivan@Example.COM"})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+
+	err = c.CreateUser(ctx, &User{Name: "Bad", Email: "not-an-email"})
+	assert.ErrorIs(t, err, ErrInvalidEmail)
+
+	got, err := c.GetUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Email, got.Email)
+
+	_, err = c.GetUser(ctx, 999)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	got, err = c.GetUserByEmail(ctx, "  IVAN@Example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	_, err = c.GetUserByEmail(ctx, "nobody@example.com")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	update := &User{ID: user.ID, Status: UserStatusDeactivated}
+	require.NoError(t, c.UpdateUser(ctx, update))
+	assert.Equal(t, "Ivan", update.Name)
+	assert.Equal(t, UserStatusDeactivated, update.Status)
+
+	list, err := c.ListUsers(ctx, UserFilter{Status: UserStatusDeactivated})
+	require.NoError(t, err)
+	require.Len(t, list.Users, 1)
+	assert.Equal(t, user.ID, list.Users[0].ID)
+
+	require.NoError(t, c.DeleteUser(ctx, user.ID))
+	assert.ErrorIs(t, c.DeleteUser(ctx, user.ID), ErrUserNotFound)
+}
+
+func TestUserStatus(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, newTestServer(t, nil))
+
+	user := &User{Name: "Ivan", Email: "ivan@example.com"}
+	require.NoError(t, c.CreateUser(ctx, user))
+	require.NoError(t, c.CreateUser(ctx, &User{Name: "Olga", Email: "olga@example.com"}))
+
+	suspended, err := c.SuspendUser(ctx, user.ID, "chargeback")
+	require.NoError(t, err)
+	assert.Equal(t, UserStatusSuspended, suspended.Status)
+	assert.Equal(t, "chargeback", suspended.StatusReason)
+	assert.NotNil(t, suspended.StatusChangedAt)
+
+	_, err = c.SuspendUser(ctx, user.ID, "")
+	assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	_, err = c.ActivateUser(ctx, 999, "")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	list, err := c.ListUsers(ctx, UserFilter{Statuses: []string{UserStatusSuspended, UserStatusDeactivated}})
+	require.NoError(t, err)
+	require.Len(t, list.Users, 1)
+	assert.Equal(t, user.ID, list.Users[0].ID)
+
+	deactivated, err := c.DeactivateUser(ctx, user.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, UserStatusDeactivated, deactivated.Status)
+	assert.Empty(t, deactivated.StatusReason)
+
+	activated, err := c.ActivateUser(ctx, user.ID, "appeal granted")
+	require.NoError(t, err)
+	assert.Equal(t, UserStatusActive, activated.Status)
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	ops := []BatchOperation{
+		{Op: BatchOpCreate, User: &User{Name: "A", Email: "a@example.com"}},
+		{Op: BatchOpUpdate, ID: 999, User: &User{Name: "B"}},
+	}
+
+	t.Run("best effort", func(t *testing.T) {
+		c := newTestClient(t, newTestServer(t, nil))
+
+		resp, err := c.Batch(ctx, ops, false)
+		require.NoError(t, err)
+		assert.True(t, resp.Committed)
+		require.Len(t, resp.Results, 2)
+		assert.Equal(t, domain.BatchStatusCreated, resp.Results[0].Status)
+		assert.Equal(t, domain.BatchStatusFailed, resp.Results[1].Status)
+	})
+
+	t.Run("atomic without transactions", func(t *testing.T) {
+		c := newTestClient(t, newTestServer(t, nil))
+
+		_, err := c.Batch(ctx, ops, true)
+		assert.ErrorIs(t, err, ErrTxNotSupported)
+	})
+
+	t.Run("rolled back", func(t *testing.T) {
+		server := newTestServer(t, func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(`{"mode":"atomic","committed":false,"results":[{"index":0,"op":"create","status":"rolled_back"},{"index":1,"op":"update","status":"failed","id":999,"error":"user not found"}]}`))
+			})
+		})
+		c := newTestClient(t, server)
+
+		resp, err := c.Batch(ctx, ops, true)
+		assert.ErrorIs(t, err, ErrBatchAborted)
+		require.NotNil(t, resp)
+		assert.False(t, resp.Committed)
+		require.Len(t, resp.Results, 2)
+		assert.Equal(t, "user not found", resp.Results[1].Error)
+	})
+}
+
+func TestUsersIterator(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, newTestServer(t, nil))
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(t, c.CreateUser(ctx, &User{Name: name, Email: name + "@example.com"}))
+	}
+
+	var names []string
+	it := c.Users(ctx, UserFilter{Limit: 2, Offset: 1})
+	for it.Next() {
+		names = append(names, it.User().Name)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []string{"b", "c", "d", "e"}, names)
+}
+
+func TestSearchUsers(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, newTestServer(t, nil))
+
+	require.NoError(t, c.CreateUser(ctx, &User{Name: "Ivan Petrov", Email: "ivan@example.com"}))
+	require.NoError(t, c.CreateUser(ctx, &User{Name: "Olga Sidorova", Email: "olga@example.com"}))
+
+	results, err := c.SearchUsers(ctx, "petrvo", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Ivan Petrov", results[0].User.Name)
+	assert.Equal(t, "Ivan <mark>Petrov</mark>", results[0].NameHighlight)
+
+	_, err = c.SearchUsers(ctx, "", 0)
+	assert.ErrorIs(t, err, ErrRequestInvalid)
+}
+
+func TestUserAttributes(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, newTestServer(t, nil))
+
+	def := &AttributeDefinition{Name: "department", Type: AttributeTypeString, Required: true, Enum: []string{"Sales", "IT"}}
+	require.NoError(t, c.CreateAttributeDefinition(ctx, def))
+	assert.False(t, def.CreatedAt.IsZero())
+	assert.ErrorIs(t, c.CreateAttributeDefinition(ctx, def), ErrAttributeExists)
+	require.NoError(t, c.CreateAttributeDefinition(ctx, &AttributeDefinition{Name: "level", Type: AttributeTypeInteger}))
+
+	defs, err := c.ListAttributeDefinitions(ctx)
+	require.NoError(t, err)
+	require.Len(t, defs, 2)
+	assert.Equal(t, "department", defs[0].Name)
+
+	err = c.CreateUser(ctx, &User{Name: "Ivan", Email: "ivan@example.com"})
+	assert.ErrorIs(t, err, ErrInvalidAttribute)
+
+	user := &User{Name: "Ivan", Email: "ivan@example.com", Attributes: Attributes{"department": "IT", "level": 2}}
+	require.NoError(t, c.CreateUser(ctx, user))
+
+	update := &User{ID: user.ID, Attributes: Attributes{"level": nil}}
+	require.NoError(t, c.UpdateUser(ctx, update))
+	assert.Equal(t, Attributes{"department": "IT"}, update.Attributes)
+
+	require.NoError(t, c.DeleteAttributeDefinition(ctx, "level"))
+	_, err = c.GetAttributeDefinition(ctx, "level")
+	assert.ErrorIs(t, err, ErrAttributeNotFound)
+}
+
+func TestGroups(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, newTestServer(t, nil))
+
+	engineering := &Group{Name: "Engineering"}
+	require.NoError(t, c.CreateGroup(ctx, engineering))
+	assert.NotZero(t, engineering.ID)
+	assert.ErrorIs(t, c.CreateGroup(ctx, &Group{Name: "Engineering"}), ErrGroupExists)
+	missing := int64(999)
+	assert.ErrorIs(t, c.CreateGroup(ctx, &Group{Name: "Orphan", ParentID: &missing}), ErrGroupNotFound)
+
+	backend := &Group{Name: "Backend", ParentID: &engineering.ID}
+	require.NoError(t, c.CreateGroup(ctx, backend))
+
+	engineering.ParentID = &backend.ID
+	assert.ErrorIs(t, c.UpdateGroup(ctx, engineering), ErrGroupCycle)
+	assert.ErrorIs(t, c.DeleteGroup(ctx, engineering.ID), ErrGroupHasSubgroups)
+
+	user := &User{Name: "Ivan", Email: "ivan@example.com"}
+	require.NoError(t, c.CreateUser(ctx, user))
+
+	member, added, err := c.AddGroupMember(ctx, backend.ID, user.ID)
+	require.NoError(t, err)
+	assert.True(t, added)
+	assert.True(t, member.Direct)
+	_, added, err = c.AddGroupMember(ctx, backend.ID, user.ID)
+	require.NoError(t, err)
+	assert.False(t, added)
+	_, _, err = c.AddGroupMember(ctx, backend.ID, 999)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	member, err = c.GetGroupMember(ctx, engineering.ID, user.ID)
+	require.NoError(t, err)
+	assert.False(t, member.Direct)
+
+	groups, err := c.ListUserGroups(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, engineering.ID, groups[0].ID)
+	assert.False(t, groups[0].Direct)
+	assert.True(t, groups[1].Direct)
+
+	members, err := c.ListGroupMembers(ctx, backend.ID, GroupFilter{})
+	require.NoError(t, err)
+	require.Len(t, members.Users, 1)
+	assert.Equal(t, user.ID, members.Users[0].ID)
+
+	assert.ErrorIs(t, c.RemoveGroupMember(ctx, engineering.ID, user.ID), ErrMemberNotFound)
+	require.NoError(t, c.RemoveGroupMember(ctx, backend.ID, user.ID))
+	_, err = c.GetGroupMember(ctx, engineering.ID, user.ID)
+	assert.ErrorIs(t, err, ErrMemberNotFound)
+
+	require.NoError(t, c.DeleteGroup(ctx, backend.ID))
+	_, err = c.GetGroup(ctx, backend.ID)
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+	list, err := c.ListGroups(ctx, GroupFilter{})
+	require.NoError(t, err)
+	assert.Len(t, list.Groups, 1)
+}
+
+type apiKeys map[string]*domain.Tenant
+
+func (k apiKeys) Authenticate(apiKey string) (*domain.Tenant, error) {
+	if tenant, ok := k[apiKey]; ok {
+		return tenant, nil
+	}
+	return nil, ErrInvalidAPIKey
+}
+
+func TestAPIKey(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, middleware.Tenant(apiKeys{"acme-key": {ID: domain.DefaultTenantID}}, true))
+
+	_, err := newTestClient(t, server).GetUser(ctx, 1)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	c, err := New(server.URL, WithAPIKey("other-key"))
+	require.NoError(t, err)
+	_, err = c.GetUser(ctx, 1)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	c, err = New(server.URL, WithAPIKey("acme-key"))
+	require.NoError(t, err)
+	_, err = c.GetUser(ctx, 1)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("5xx and 429 are retried with the same idempotency key", func(t *testing.T) {
+		var calls atomic.Int32
+		var keys sync.Map
+		server := newTestServer(t, func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				keys.Store(r.Header.Get("Idempotency-Key"), true)
+				switch calls.Add(1) {
+				case 1:
+					w.WriteHeader(http.StatusServiceUnavailable)
+				case 2:
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusTooManyRequests)
+				default:
+					next.ServeHTTP(w, r)
+				}
+			})
+		})
+		c := newTestClient(t, server)
+
+		require.NoError(t, c.CreateUser(ctx, &User{Name: "Ivan", Email: "ivan@example.com"}))
+		assert.Equal(t, int32(3), calls.Load())
+		n := 0
+		keys.Range(func(key, _ interface{}) bool {
+			assert.NotEmpty(t, key)
+			n++
+			return true
+		})
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("gives up after MaxAttempts", func(t *testing.T) {
+		var calls atomic.Int32
+		server := newTestServer(t, func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"error":"boom","code":500,"message":"Failed"}`))
+			})
+		})
+		c := newTestClient(t, server)
+
+		_, err := c.GetUser(ctx, 1)
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+		assert.Equal(t, "boom", apiErr.Code)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("non-idempotent requests are not retried", func(t *testing.T) {
+		var calls atomic.Int32
+		server := newTestServer(t, func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(http.StatusBadGateway)
+			})
+		})
+		c := newTestClient(t, server)
+
+		_, err := c.VerifyEmail(ctx, "token")
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("context cancels the backoff", func(t *testing.T) {
+		server := newTestServer(t, func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "60")
+				w.WriteHeader(http.StatusTooManyRequests)
+			})
+		})
+		c, err := New(server.URL, WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Minute}))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = c.GetUser(ctx, 1)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"users-api/src/internal/errors"
+)
+
+// The sentinel errors are the ones the service itself returns, so
+// errors.Is(err, client.ErrUserNotFound) works for errors returned by the
+// client.
+var (
+	ErrUserNotFound  = errors.ErrUserNotFound
+	ErrInvalidInput  = errors.ErrInvalidInput
+	ErrInvalidEmail  = errors.ErrInvalidEmail
+	ErrEmailRejected = errors.ErrEmailRejected
+	ErrEmailTaken    = errors.ErrEmailTaken
+
+	ErrInvalidStatusTransition = errors.ErrInvalidStatusTransition
+
+	ErrInvalidAttribute  = errors.ErrInvalidAttribute
+	ErrAttributeNotFound = errors.ErrAttributeNotFound
+	ErrAttributeExists   = errors.ErrAttributeExists
+
+	ErrGroupNotFound     = errors.ErrGroupNotFound
+	ErrGroupExists       = errors.ErrGroupExists
+	ErrGroupCycle        = errors.ErrGroupCycle
+	ErrGroupHasSubgroups = errors.ErrGroupHasSubgroups
+	ErrMemberNotFound    = errors.ErrMemberNotFound
+
+	ErrNotAcceptable        = errors.ErrNotAcceptable
+	ErrMethodNotAllowed     = errors.ErrMethodNotAllowed
+	ErrUnsupportedMediaType = errors.ErrUnsupportedMediaType
+	ErrRequestInvalid       = errors.ErrRequestInvalid
+
+	ErrBatchTooLarge  = errors.ErrBatchTooLarge
+	ErrBatchAborted   = errors.ErrBatchAborted
+	ErrInvalidBatchOp = errors.ErrInvalidBatchOp
+	ErrTxNotSupported = errors.ErrTxNotSupported
+
+	ErrInvalidToken = errors.ErrInvalidToken
+	ErrTokenExpired = errors.ErrTokenExpired
+
+	ErrRateLimited = errors.ErrRateLimited
+
+	ErrUnauthenticated = errors.ErrUnauthenticated
+	ErrInvalidAPIKey   = errors.ErrInvalidAPIKey
+	ErrForbidden       = errors.ErrForbidden
+)
+
+var sentinels = map[string]error{}
+
+func init() {
+	for _, err := range []error{
+		ErrUserNotFound, ErrInvalidInput, ErrInvalidEmail, ErrEmailRejected, ErrEmailTaken,
+		ErrInvalidStatusTransition,
+		ErrInvalidAttribute, ErrAttributeNotFound, ErrAttributeExists,
+		ErrGroupNotFound, ErrGroupExists, ErrGroupCycle, ErrGroupHasSubgroups, ErrMemberNotFound,
+		ErrNotAcceptable, ErrMethodNotAllowed, ErrUnsupportedMediaType, ErrRequestInvalid,
+		ErrBatchTooLarge, ErrBatchAborted, ErrInvalidBatchOp, ErrTxNotSupported,
+		ErrInvalidToken, ErrTokenExpired,
+		ErrRateLimited,
+		ErrUnauthenticated, ErrInvalidAPIKey, ErrForbidden,
+	} {
+		sentinels[err.Error()] = err
+	}
+}
+
+// APIError is returned for every response with an unexpected status. It
+// unwraps to the matching sentinel error when the server reported one.
+type APIError struct {
+	StatusCode int
+	// Code is the "error" field of the response body.
+	Code    string
+	Message string
+
+	sentinel error
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("users api: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	}
+	return fmt.Sprintf("users api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *APIError) Unwrap() error {
+	return e.sentinel
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var payload struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		apiErr.Code = payload.Error
+		apiErr.Message = payload.Message
+		apiErr.sentinel = sentinels[payload.Error]
+	}
+	return apiErr
+}
+
+// resultError maps the error of a batch result to a sentinel when possible.
+func resultError(status int, message string) error {
+	if err, ok := sentinels[message]; ok {
+		return &APIError{StatusCode: status, Code: message, Message: message, sentinel: err}
+	}
+	return &APIError{StatusCode: status, Code: message, Message: message}
+}
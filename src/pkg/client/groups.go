@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"users-api/src/internal/domain"
+)
+
+type (
+	Group       = domain.Group
+	GroupFilter = domain.GroupFilter
+	GroupMember = domain.GroupMember
+	UserGroup   = domain.UserGroup
+)
+
+// GroupList is one page of ListGroups.
+type GroupList struct {
+	Groups []*Group `json:"groups"`
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+}
+
+// CreateGroup creates group and fills in the fields set by the server. A
+// missing parent fails with ErrGroupNotFound, a taken name with
+// ErrGroupExists.
+func (c *Client) CreateGroup(ctx context.Context, group *Group) error {
+	_, err := c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       "/groups",
+		body:       groupInput(group),
+		idempotent: true,
+		wantStatus: []int{http.StatusCreated},
+	}, group)
+	return err
+}
+
+func (c *Client) GetGroup(ctx context.Context, id int64) (*Group, error) {
+	var group Group
+	_, err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       groupPath(id),
+		wantStatus: []int{http.StatusOK},
+	}, &group)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// ListGroups returns one page of groups, ordered by ID.
+func (c *Client) ListGroups(ctx context.Context, filter GroupFilter) (*GroupList, error) {
+	var list GroupList
+	_, err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/groups",
+		query:      pageQuery(filter),
+		wantStatus: []int{http.StatusOK},
+	}, &list)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// UpdateGroup replaces the name, description and parent of group.ID.
+// Nesting a group in itself or one of its subgroups fails with ErrGroupCycle.
+func (c *Client) UpdateGroup(ctx context.Context, group *Group) error {
+	_, err := c.do(ctx, request{
+		method:     http.MethodPut,
+		path:       groupPath(group.ID),
+		body:       groupInput(group),
+		wantStatus: []int{http.StatusOK},
+	}, group)
+	return err
+}
+
+// DeleteGroup deletes a group and its memberships. It fails with
+// ErrGroupHasSubgroups while other groups are nested in it.
+func (c *Client) DeleteGroup(ctx context.Context, id int64) error {
+	_, err := c.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       groupPath(id),
+		wantStatus: []int{http.StatusNoContent},
+	}, nil)
+	return err
+}
+
+// ListGroupMembers returns one page of the direct members of a group,
+// ordered by ID.
+func (c *Client) ListGroupMembers(ctx context.Context, groupID int64, filter GroupFilter) (*UserList, error) {
+	var list UserList
+	_, err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       groupPath(groupID) + "/members",
+		query:      pageQuery(filter),
+		wantStatus: []int{http.StatusOK},
+	}, &list)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetGroupMember reports how a user belongs to a group, directly or through
+// a subgroup. It fails with ErrMemberNotFound if they do not.
+func (c *Client) GetGroupMember(ctx context.Context, groupID, userID int64) (*GroupMember, error) {
+	var member GroupMember
+	_, err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       memberPath(groupID, userID),
+		wantStatus: []int{http.StatusOK},
+	}, &member)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// AddGroupMember makes a user a direct member of a group. added is false
+// when they already were one.
+func (c *Client) AddGroupMember(ctx context.Context, groupID, userID int64) (member *GroupMember, added bool, err error) {
+	member = &GroupMember{}
+	status, err := c.do(ctx, request{
+		method:     http.MethodPut,
+		path:       memberPath(groupID, userID),
+		wantStatus: []int{http.StatusOK, http.StatusCreated},
+	}, member)
+	if err != nil {
+		return nil, false, err
+	}
+	return member, status == http.StatusCreated, nil
+}
+
+// RemoveGroupMember ends a direct membership. It fails with
+// ErrMemberNotFound for users who only belong through a subgroup.
+func (c *Client) RemoveGroupMember(ctx context.Context, groupID, userID int64) error {
+	_, err := c.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       memberPath(groupID, userID),
+		wantStatus: []int{http.StatusNoContent},
+	}, nil)
+	return err
+}
+
+// ListUserGroups returns every group a user belongs to, including the groups
+// above the ones they were added to.
+func (c *Client) ListUserGroups(ctx context.Context, userID int64) ([]*UserGroup, error) {
+	var resp struct {
+		Groups []*UserGroup `json:"groups"`
+	}
+	_, err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/users/" + strconv.FormatInt(userID, 10) + "/groups",
+		wantStatus: []int{http.StatusOK},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Groups, nil
+}
+
+func groupPath(id int64) string {
+	return "/groups/" + strconv.FormatInt(id, 10)
+}
+
+func memberPath(groupID, userID int64) string {
+	return groupPath(groupID) + "/members/" + strconv.FormatInt(userID, 10)
+}
+
+// groupInput leaves out the fields the server sets.
+func groupInput(group *Group) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        group.Name,
+		"description": group.Description,
+		"parent_id":   group.ParentID,
+	}
+}
+
+func pageQuery(filter GroupFilter) url.Values {
+	query := url.Values{}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		query.Set("offset", strconv.Itoa(filter.Offset))
+	}
+	return query
+}
@@ -0,0 +1,349 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"users-api/src/internal/domain"
+)
+
+type (
+	User           = domain.User
+	UserFilter     = domain.UserFilter
+	BatchOperation = domain.BatchOperation
+	BatchResult    = domain.BatchResult
+
+	UserSearchResult = domain.UserSearchResult
+
+	Attributes = domain.Attributes
+)
+
+const (
+	UserStatusPending     = domain.UserStatusPending
+	UserStatusActive      = domain.UserStatusActive
+	UserStatusSuspended   = domain.UserStatusSuspended
+	UserStatusDeactivated = domain.UserStatusDeactivated
+
+	BatchOpCreate = domain.BatchOpCreate
+	BatchOpUpdate = domain.BatchOpUpdate
+	BatchOpDelete = domain.BatchOpDelete
+
+	// DefaultPageSize is the page size Users uses when the filter sets none.
+	DefaultPageSize = 100
+)
+
+// UserList is one page of ListUsers.
+type UserList struct {
+	Users  []*User `json:"users"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+}
+
+// BatchResponse is the outcome of Batch. When an atomic batch is rolled back,
+// Batch returns it together with an error wrapping ErrBatchAborted, and the
+// results say which operation failed.
+type BatchResponse struct {
+	Mode      string        `json:"mode"`
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// CreateUser creates user and fills in the fields set by the server. The
+// request carries a generated Idempotency-Key, so retries never create the
+// user twice.
+func (c *Client) CreateUser(ctx context.Context, user *User) error {
+	body := userInput(user)
+	// Decoding into an existing map would keep keys the server dropped.
+	user.Attributes = nil
+	_, err := c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       "/users",
+		body:       body,
+		idempotent: true,
+		wantStatus: []int{http.StatusCreated},
+	}, user)
+	return err
+}
+
+func (c *Client) GetUser(ctx context.Context, id int64) (*User, error) {
+	var user User
+	_, err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/users",
+		query:      url.Values{"id": {strconv.FormatInt(id, 10)}},
+		wantStatus: []int{http.StatusOK},
+	}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserByEmail returns the user registered under address. The server
+// ignores case and surrounding spaces when matching.
+func (c *Client) GetUserByEmail(ctx context.Context, address string) (*User, error) {
+	var user User
+	_, err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/users",
+		query:      url.Values{"email": {address}},
+		wantStatus: []int{http.StatusOK},
+	}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsers returns one page of users matching filter, ordered by ID.
+func (c *Client) ListUsers(ctx context.Context, filter UserFilter) (*UserList, error) {
+	var list UserList
+	_, err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/users",
+		query:      filterQuery(filter),
+		wantStatus: []int{http.StatusOK},
+	}, &list)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// SearchUsers finds users by a partial or misspelled name or email, best
+// match first. A zero limit means the server default.
+func (c *Client) SearchUsers(ctx context.Context, query string, limit int) ([]*UserSearchResult, error) {
+	params := url.Values{"q": {query}}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	var resp struct {
+		Results []*UserSearchResult `json:"results"`
+	}
+	_, err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/users/search",
+		query:      params,
+		wantStatus: []int{http.StatusOK},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// UpdateUser updates the name, email and status of user.ID; empty fields are
+// left unchanged. The attributes in user.Attributes are set, or removed when
+// nil, and the others kept. On success user holds the stored user.
+func (c *Client) UpdateUser(ctx context.Context, user *User) error {
+	body := userInput(user)
+	body["id"] = user.ID
+	user.Attributes = nil
+	_, err := c.do(ctx, request{
+		method:     http.MethodPut,
+		path:       "/users",
+		body:       body,
+		wantStatus: []int{http.StatusOK},
+	}, user)
+	return err
+}
+
+// DeleteUser deletes a user through a single-operation batch.
+func (c *Client) DeleteUser(ctx context.Context, id int64) error {
+	resp, err := c.Batch(ctx, []BatchOperation{{Op: BatchOpDelete, ID: id}}, false)
+	if err != nil {
+		return err
+	}
+	if len(resp.Results) == 1 && resp.Results[0].Status == domain.BatchStatusFailed {
+		return resultError(http.StatusOK, resp.Results[0].Error)
+	}
+	return nil
+}
+
+func (c *Client) VerifyEmail(ctx context.Context, verificationToken string) (*User, error) {
+	var user User
+	_, err := c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       "/users/verify-email",
+		body:       map[string]string{"token": verificationToken},
+		wantStatus: []int{http.StatusOK},
+	}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SuspendUser moves an active user to suspended, recording reason, which
+// may be empty. Disallowed moves fail with ErrInvalidStatusTransition.
+func (c *Client) SuspendUser(ctx context.Context, id int64, reason string) (*User, error) {
+	return c.changeStatus(ctx, id, "suspend", reason)
+}
+
+func (c *Client) ActivateUser(ctx context.Context, id int64, reason string) (*User, error) {
+	return c.changeStatus(ctx, id, "activate", reason)
+}
+
+func (c *Client) DeactivateUser(ctx context.Context, id int64, reason string) (*User, error) {
+	return c.changeStatus(ctx, id, "deactivate", reason)
+}
+
+func (c *Client) changeStatus(ctx context.Context, id int64, action, reason string) (*User, error) {
+	var user User
+	_, err := c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       "/users/" + strconv.FormatInt(id, 10) + "/" + action,
+		body:       map[string]string{"reason": reason},
+		wantStatus: []int{http.StatusOK},
+	}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Batch applies ops in one request. With atomic set either every operation
+// is committed or none is.
+func (c *Client) Batch(ctx context.Context, ops []BatchOperation, atomic bool) (*BatchResponse, error) {
+	mode := "best_effort"
+	if atomic {
+		mode = "atomic"
+	}
+
+	var resp BatchResponse
+	_, err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/users:batch",
+		body: map[string]interface{}{
+			"mode":       mode,
+			"operations": ops,
+		},
+		idempotent:  true,
+		wantStatus:  []int{http.StatusOK},
+		errorStatus: []int{http.StatusUnprocessableEntity},
+	}, &resp)
+	if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode == http.StatusUnprocessableEntity && apiErr.Code == "" {
+		apiErr.sentinel = ErrBatchAborted
+		apiErr.Message = "batch was rolled back"
+		return &resp, apiErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UserIterator pages through every user matching a filter:
+//
+//	it := c.Users(ctx, client.UserFilter{Status: client.UserStatusActive})
+//	for it.Next() {
+//		user := it.User()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type UserIterator struct {
+	client *Client
+	ctx    context.Context
+	filter UserFilter
+
+	page []*User
+	user *User
+	done bool
+	err  error
+}
+
+// Users returns an iterator over all users matching filter, starting at
+// filter.Offset and fetching filter.Limit users per request.
+func (c *Client) Users(ctx context.Context, filter UserFilter) *UserIterator {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	return &UserIterator{client: c, ctx: ctx, filter: filter}
+}
+
+// Next advances to the next user, fetching the next page when needed. It
+// returns false when there are no more users or a request failed.
+func (it *UserIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+		list, err := it.client.ListUsers(it.ctx, it.filter)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = list.Users
+		it.filter.Offset += len(list.Users)
+		// The server caps the page size, so a short page only means the end
+		// when it is shorter than what the server actually used.
+		it.done = len(list.Users) == 0 || len(list.Users) < list.Limit
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+	it.user, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+func (it *UserIterator) User() *User {
+	return it.user
+}
+
+func (it *UserIterator) Err() error {
+	return it.err
+}
+
+// userInput keeps the writable fields, so server-managed ones such as
+// created_at are never sent.
+func userInput(user *User) map[string]interface{} {
+	body := map[string]interface{}{
+		"name":  user.Name,
+		"email": user.Email,
+	}
+	if user.Status != "" {
+		body["status"] = user.Status
+	}
+	if len(user.Attributes) > 0 {
+		body["attributes"] = user.Attributes
+	}
+	return body
+}
+
+func filterQuery(filter UserFilter) url.Values {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("name_contains", filter.NameContains)
+	set("email_contains", filter.EmailContains)
+	set("status", filter.Status)
+	if len(filter.Statuses) > 0 {
+		set("status", strings.Join(filter.Statuses, ","))
+	}
+	if filter.CreatedAfter != nil {
+		set("created_after", filter.CreatedAfter.Format(time.RFC3339Nano))
+	}
+	if filter.CreatedBefore != nil {
+		set("created_before", filter.CreatedBefore.Format(time.RFC3339Nano))
+	}
+	for name, value := range filter.Attributes {
+		set("attr."+name, fmt.Sprint(value))
+	}
+	if filter.Limit > 0 {
+		set("limit", strconv.Itoa(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		set("offset", strconv.Itoa(filter.Offset))
+	}
+	return query
+}